@@ -3,9 +3,11 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/zmsocc/practice/webook/internal/event"
+	"github.com/zmsocc/practice/webook/internal/job"
 )
 
 type App struct {
 	web       *gin.Engine
 	consumers []event.Consumer
+	jobs      []job.Job
 }
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// 个人访问令牌的权限范围，格式和权限点一样是 资源:动作
+const (
+	ScopeArticleRead  = "article:read"
+	ScopeArticleWrite = "article:write"
+	ScopeUserRead     = "user:read"
+)
+
+var AccessTokenScopes = []string{ScopeArticleRead, ScopeArticleWrite, ScopeUserRead}
+
+// AccessToken 个人访问令牌，给脚本和第三方集成用的
+type AccessToken struct {
+	Id   int64
+	Uid  int64
+	Name string
+	// Prefix 令牌的前几位，方便用户在列表里面认出是哪一个
+	Prefix string
+	Scopes []string
+	// 零值代表从来没用过
+	LastUsedAt time.Time
+	// 零值代表永不过期
+	ExpiresAt time.Time
+	Ctime     time.Time
+}
+
+func (t AccessToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
+func (t AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,8 @@ import "time"
 type Author struct {
 	Id   int64
 	Name string
+	// 头像小图
+	Avatar string
 }
 
 type Article struct {
@@ -0,0 +1,13 @@
+package domain
+
+// AsyncSMS 同步发送失败，等着后台重试的短信
+type AsyncSMS struct {
+	Id int64
+	// 抢占的时候拿到的版本号，更新结果的时候要带上，防止被别的实例抢走了还去更新
+	Version  int64
+	Biz      string
+	Args     []string
+	Numbers  []string
+	RetryCnt int32
+	MaxRetry int32
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// AuthorProfile 作者公开主页的信息
+type AuthorProfile struct {
+	Id       int64
+	Nickname string
+	AboutMe  string
+	Avatar   string
+	// 注册时间
+	Ctime time.Time
+	// 已发表的文章数
+	ArticleCnt int64
+	// 所有已发表文章的阅读、点赞、收藏数之和
+	ReadCnt    int64
+	LikeCnt    int64
+	CollectCnt int64
+	// 粉丝数和关注数
+	Followers int64
+	Followees int64
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// FollowRelation Follower 关注了 Followee
+type FollowRelation struct {
+	Id       int64
+	Follower int64
+	Followee int64
+	Ctime    time.Time
+}
+
+// FollowStatics 用户的粉丝数和关注数
+type FollowStatics struct {
+	Uid int64
+	// 粉丝数
+	Followers int64
+	// 关注数
+	Followees int64
+}
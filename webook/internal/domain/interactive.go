@@ -14,3 +14,11 @@ type Interactive struct {
 	Liked      bool
 	Collected  bool
 }
+
+// BizRecord 用户对某个资源的一次操作记录，比如点赞、收藏、阅读
+type BizRecord struct {
+	Biz   string
+	BizId int64
+	Ctime time.Time
+	Utime time.Time
+}
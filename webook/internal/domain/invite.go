@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// InviteCode 内测期间注册用的邀请码，可以用 MaxUses 次，ExpireAt 为零值代表不过期
+type InviteCode struct {
+	Id   int64
+	Code string
+	// 谁生成的，管理员生成的也记在管理员名下
+	Inviter  int64
+	MaxUses  int32
+	Used     int32
+	ExpireAt time.Time
+	Ctime    time.Time
+}
+
+func (c InviteCode) Usable(now time.Time) bool {
+	if c.Used >= c.MaxUses {
+		return false
+	}
+	return c.ExpireAt.IsZero() || c.ExpireAt.After(now)
+}
+
+// Invitation 谁邀请了谁
+type Invitation struct {
+	Inviter int64
+	Invitee int64
+	Code    string
+	Ctime   time.Time
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// 登录方式
+const (
+	LoginMethodPassword     = "password"
+	LoginMethodTotp         = "totp"
+	LoginMethodSMS          = "sms"
+	LoginMethodRefreshToken = "refresh_token"
+)
+
+// LoginLog 一次登录尝试，成功失败都记
+type LoginLog struct {
+	Id int64
+	// 失败的时候可能不知道是哪个用户，就是 0
+	Uid    int64
+	Method string
+	// 登录用的邮箱或者手机号，刷新 token 的时候为空
+	Account   string
+	Ip        string
+	UserAgent string
+	Success   bool
+	// 失败原因
+	Reason string
+	Ctime  time.Time
+}
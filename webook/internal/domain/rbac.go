@@ -0,0 +1,46 @@
+package domain
+
+// 权限点，格式是 资源:动作
+const (
+	PermArticleTakedown = "article:takedown"
+	// PermRoleManage 给别人分配角色
+	PermRoleManage = "user:role"
+	PermJWTRotate  = "jwt:rotate"
+	// PermLoginAudit 查看别人的登录记录
+	PermLoginAudit = "user:login_audit"
+	// PermUserStatus 封禁、暂停、禁言用户
+	PermUserStatus = "user:status"
+	// PermInviteManage 生成不受配额限制的邀请码，查看所有的邀请关系
+	PermInviteManage = "user:invite"
+)
+
+// 内置角色
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleOperator  = "operator"
+)
+
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// DefaultRoles 部署的时候用 -seed-rbac 写进数据库，后面要调整某个角色的权限直接改表就可以
+var DefaultRoles = []Role{
+	{
+		Name: RoleAdmin,
+		Permissions: []string{PermArticleTakedown, PermRoleManage, PermJWTRotate, PermLoginAudit, PermUserStatus,
+			PermInviteManage},
+	},
+	{
+		// 审核，负责处理违规内容
+		Name:        RoleModerator,
+		Permissions: []string{PermArticleTakedown, PermUserStatus},
+	},
+	{
+		// 运维
+		Name:        RoleOperator,
+		Permissions: []string{PermJWTRotate},
+	},
+}
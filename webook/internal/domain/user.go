@@ -11,4 +11,68 @@ type User struct {
 	Nickname string
 	Birthday time.Time
 	AboutMe  string
+	// 头像 URL，AvatarThumb 是列表之类的地方用的小图
+	Avatar      string
+	AvatarThumb string
+	// 是否开启了 TOTP 两步验证，密钥不放在这里，免得跟着进了缓存
+	TotpEnabled bool
+	// 申请了注销，到这个时间就会真正删除，零值代表没有申请
+	DeleteAt time.Time
+	Status   UserStatus
+	// Status 是 UserStatusSuspended 的时候，暂停到这个时间
+	SuspendedUntil time.Time
+}
+
+// Suspended 是否还在暂停期间，过了时间自动恢复
+func (u User) Suspended(now time.Time) bool {
+	return u.Status == UserStatusSuspended && now.Before(u.SuspendedUntil)
+}
+
+// CanLogin 没被封禁，也不在暂停期间
+func (u User) CanLogin(now time.Time) bool {
+	return u.Status != UserStatusBanned && !u.Suspended(now)
+}
+
+// CanWrite 能登录，并且没被禁言
+func (u User) CanWrite(now time.Time) bool {
+	return u.CanLogin(now) && u.Status != UserStatusMuted
+}
+
+type UserStatus uint8
+
+const (
+	// UserStatusActive 零值，老数据都是正常状态
+	UserStatusActive UserStatus = iota
+	// UserStatusMuted 禁言，能看不能发文章、点赞、收藏
+	UserStatusMuted
+	// UserStatusSuspended 暂停使用一段时间，不能登录
+	UserStatusSuspended
+	// UserStatusBanned 永久封禁
+	UserStatusBanned
+)
+
+func (s UserStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+func (s UserStatus) Valid() bool {
+	return s <= UserStatusBanned
+}
+
+// OAuthInfo 第三方登录拿到的用户身份
+type OAuthInfo struct {
+	// 哪个平台，比如 wechat
+	Provider string
+	// 在这个应用下面唯一
+	OpenId string
+	// 同一个开放平台下面的所有应用唯一，可能为空
+	UnionId  string
+	Nickname string
+}
+
+// PhoneConflict 加密上线之前手机号没有唯一索引，同一个手机号可能注册过多个账号。
+// Uids 里面第一个是要保留的账号，已经补上盲索引的账号排在最前面，其它的按注册先后排
+type PhoneConflict struct {
+	Phone string
+	Uids  []int64
 }
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+type ExportStatus uint8
+
+const (
+	ExportStatusUnknown ExportStatus = iota
+	// ExportStatusPending 正在打包
+	ExportStatusPending
+	ExportStatusDone
+	ExportStatusFailed
+)
+
+func (s ExportStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+// ExportTask 导出个人数据的任务，一个用户同一时间只有一个
+type ExportTask struct {
+	Uid    int64
+	Status ExportStatus
+	// 打包好的 zip 在对象存储里面的 key
+	Key   string
+	Ctime time.Time
+	Utime time.Time
+}
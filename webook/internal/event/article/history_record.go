@@ -25,7 +25,8 @@ func NewHistoryReadEventConsumer(client sarama.Client, l logger.Logger,
 }
 
 func (k *HistoryReadEventConsumer) Start() error {
-	cg, err := sarama.NewConsumerGroupFromClient("interactive", k.client)
+	// 和阅读计数用不同的消费者组，两边都要收到全部的消息
+	cg, err := sarama.NewConsumerGroupFromClient("history_record", k.client)
 	if err != nil {
 		return err
 	}
@@ -39,8 +40,12 @@ func (k *HistoryReadEventConsumer) Start() error {
 	return err
 }
 
-// Consume 这个不是幂等的
+// Consume 同一篇文章只保留一条记录，所以是幂等的
 func (k *HistoryReadEventConsumer) Consume(msg *sarama.ConsumerMessage, t ReadEvent) error {
+	if t.Uid == 0 {
+		// 没登录的不记录
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 	return k.repo.AddRecord(ctx, t.Aid, t.Uid)
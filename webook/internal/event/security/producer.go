@@ -0,0 +1,54 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM/sarama"
+)
+
+const topicLoginAlert = "login_alert"
+
+// 告警类型
+const (
+	// AlertNewDevice 在一个没见过的设备上登录成功
+	AlertNewDevice = "new_device"
+	// AlertTooManyFailures 短时间内失败次数太多，可能是有人在猜密码
+	AlertTooManyFailures = "too_many_failures"
+)
+
+type Producer interface {
+	ProduceLoginAlert(ctx context.Context, evt LoginAlertEvent) error
+}
+
+type KafkaProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewKafkaProducer(pc sarama.SyncProducer) Producer {
+	return &KafkaProducer{
+		producer: pc,
+	}
+}
+
+func (k *KafkaProducer) ProduceLoginAlert(ctx context.Context, evt LoginAlertEvent) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topicLoginAlert,
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+type LoginAlertEvent struct {
+	Type      string
+	Uid       int64
+	Account   string
+	Method    string
+	Ip        string
+	UserAgent string
+	// 毫秒数
+	Ctime int64
+}
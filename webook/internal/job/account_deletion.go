@@ -0,0 +1,49 @@
+package job
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+// AccountDeletionJob 定时删除冷静期已经过了的账号。
+// 多个实例同时跑也没关系，真正删除的时候会用 delete_at 做条件更新
+type AccountDeletionJob struct {
+	svc      service.AccountService
+	interval time.Duration
+	l        logger.Logger
+}
+
+func NewAccountDeletionJob(svc service.AccountService, interval time.Duration,
+	l logger.Logger) *AccountDeletionJob {
+	return &AccountDeletionJob{
+		svc:      svc,
+		interval: interval,
+		l:        l,
+	}
+}
+
+func (j *AccountDeletionJob) Start() error {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			j.run()
+		}
+	}()
+	return nil
+}
+
+func (j *AccountDeletionJob) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), j.interval)
+	defer cancel()
+	cnt, err := j.svc.EraseDue(ctx, time.Now())
+	if err != nil {
+		j.l.Error("查找待注销账号失败", logger.Error(err))
+		return
+	}
+	if cnt > 0 {
+		j.l.Info("注销账号", logger.Int64("cnt", int64(cnt)))
+	}
+}
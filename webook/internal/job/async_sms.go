@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/service/sms/async"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+// AsyncSMSJob 重试同步发送失败的短信。有待发送的就一条接一条地发，没有了再等 interval。
+// 多个实例同时跑也没关系，每条短信都要先抢占才能发送
+type AsyncSMSJob struct {
+	svc      *async.Service
+	interval time.Duration
+	l        logger.Logger
+}
+
+func NewAsyncSMSJob(svc *async.Service, interval time.Duration, l logger.Logger) *AsyncSMSJob {
+	return &AsyncSMSJob{
+		svc:      svc,
+		interval: interval,
+		l:        l,
+	}
+}
+
+func (j *AsyncSMSJob) Start() error {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			err := j.svc.SendQueued(ctx)
+			cancel()
+			switch {
+			case err == nil:
+			case errors.Is(err, async.ErrNoQueued):
+				time.Sleep(j.interval)
+			default:
+				j.l.Error("重试异步短信失败", logger.Error(err))
+				time.Sleep(j.interval)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+// ExportCleanupJob 定时删除过期的个人数据导出文件。
+// 多个实例同时跑也没关系，文件已经被别人删掉了的话删除也算成功
+type ExportCleanupJob struct {
+	svc      service.AccountService
+	interval time.Duration
+	l        logger.Logger
+}
+
+func NewExportCleanupJob(svc service.AccountService, interval time.Duration,
+	l logger.Logger) *ExportCleanupJob {
+	return &ExportCleanupJob{
+		svc:      svc,
+		interval: interval,
+		l:        l,
+	}
+}
+
+func (j *ExportCleanupJob) Start() error {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			j.run()
+		}
+	}()
+	return nil
+}
+
+func (j *ExportCleanupJob) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), j.interval)
+	defer cancel()
+	cnt, err := j.svc.CleanExports(ctx, time.Now())
+	if err != nil {
+		j.l.Error("清理过期的导出文件失败", logger.Error(err))
+	}
+	if cnt > 0 {
+		j.l.Info("清理过期的导出文件", logger.Int64("cnt", int64(cnt)))
+	}
+}
@@ -0,0 +1,75 @@
+package job
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+// FieldEncryptionJob 把还是明文的，或者是用旧密钥加密的手机号和邮箱用当前的密钥重新加密，
+// 登录日志里面的明文账号换成脱敏之后的账号和盲索引。
+// 启动的时候马上跑一遍，加密刚上线的时候旧数据要尽快加密。补上盲索引之前，按手机号和邮箱查的时候会退回到按明文查。
+// 多个实例同时跑也没关系，更新的时候会带上旧的密文做条件
+type FieldEncryptionJob struct {
+	svc         service.UserService
+	loginLogSvc service.LoginLogService
+	interval    time.Duration
+	batchSize   int
+	l           logger.Logger
+}
+
+func NewFieldEncryptionJob(svc service.UserService, loginLogSvc service.LoginLogService,
+	interval time.Duration, l logger.Logger) *FieldEncryptionJob {
+	return &FieldEncryptionJob{
+		svc:         svc,
+		loginLogSvc: loginLogSvc,
+		interval:    interval,
+		batchSize:   100,
+		l:           l,
+	}
+}
+
+func (j *FieldEncryptionJob) Start() error {
+	go func() {
+		j.run()
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			j.run()
+		}
+	}()
+	return nil
+}
+
+func (j *FieldEncryptionJob) run() {
+	j.batch("重新加密用户数据", j.svc.ReEncrypt)
+	j.batch("脱敏登录日志", j.loginLogSvc.MaskLegacy)
+}
+
+// batch 分批调用 fn 直到没有需要处理的数据，fn 的语义同 UserService.ReEncrypt
+func (j *FieldEncryptionJob) batch(name string,
+	fn func(ctx context.Context, afterId int64, limit int) (int64, int, error)) {
+	var (
+		afterId int64
+		total   int
+	)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		lastId, cnt, err := fn(ctx, afterId, j.batchSize)
+		cancel()
+		total += cnt
+		if err != nil {
+			// 出错的那一条先跳过，不然它会一直卡住后面的
+			j.l.Error(name+"失败",
+				logger.Int64("id", lastId), logger.Error(err))
+		}
+		if lastId == afterId {
+			break
+		}
+		afterId = lastId
+	}
+	if total > 0 {
+		j.l.Info(name, logger.Int64("cnt", int64(total)))
+	}
+}
@@ -0,0 +1,6 @@
+package job
+
+// Job 后台定时任务，Start 之后自己在 goroutine 里面跑
+type Job interface {
+	Start() error
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"strings"
+	"time"
+)
+
+var ErrAccessTokenNotFound = dao.ErrAccessTokenNotFound
+
+type AccessTokenRepository interface {
+	Create(ctx context.Context, t domain.AccessToken, hash string) (int64, error)
+	FindByHash(ctx context.Context, hash string) (domain.AccessToken, error)
+	FindByUid(ctx context.Context, uid int64) ([]domain.AccessToken, error)
+	Delete(ctx context.Context, uid, id int64) error
+	UpdateLastUsed(ctx context.Context, id int64, lastUsed time.Time) error
+}
+
+type accessTokenRepository struct {
+	dao dao.AccessTokenDAO
+}
+
+func NewAccessTokenRepository(d dao.AccessTokenDAO) AccessTokenRepository {
+	return &accessTokenRepository{
+		dao: d,
+	}
+}
+
+func (r *accessTokenRepository) Create(ctx context.Context, t domain.AccessToken, hash string) (int64, error) {
+	entity := r.domainToEntity(t)
+	entity.TokenHash = hash
+	return r.dao.Insert(ctx, entity)
+}
+
+func (r *accessTokenRepository) FindByHash(ctx context.Context, hash string) (domain.AccessToken, error) {
+	t, err := r.dao.FindByHash(ctx, hash)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	return r.entityToDomain(t), nil
+}
+
+func (r *accessTokenRepository) FindByUid(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	ts, err := r.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.AccessToken, domain.AccessToken](ts, func(idx int, src dao.AccessToken) domain.AccessToken {
+		return r.entityToDomain(src)
+	}), nil
+}
+
+func (r *accessTokenRepository) Delete(ctx context.Context, uid, id int64) error {
+	return r.dao.Delete(ctx, uid, id)
+}
+
+func (r *accessTokenRepository) UpdateLastUsed(ctx context.Context, id int64, lastUsed time.Time) error {
+	return r.dao.UpdateLastUsed(ctx, id, lastUsed.UnixMilli())
+}
+
+func (r *accessTokenRepository) domainToEntity(t domain.AccessToken) dao.AccessToken {
+	var expiresAt int64
+	if !t.ExpiresAt.IsZero() {
+		expiresAt = t.ExpiresAt.UnixMilli()
+	}
+	return dao.AccessToken{
+		Id:        t.Id,
+		Uid:       t.Uid,
+		Name:      t.Name,
+		Prefix:    t.Prefix,
+		Scopes:    strings.Join(t.Scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (r *accessTokenRepository) entityToDomain(t dao.AccessToken) domain.AccessToken {
+	res := domain.AccessToken{
+		Id:     t.Id,
+		Uid:    t.Uid,
+		Name:   t.Name,
+		Prefix: t.Prefix,
+		Ctime:  time.UnixMilli(t.Ctime),
+	}
+	if t.Scopes != "" {
+		res.Scopes = strings.Split(t.Scopes, ",")
+	}
+	if t.LastUsedAt > 0 {
+		res.LastUsedAt = time.UnixMilli(t.LastUsedAt)
+	}
+	if t.ExpiresAt > 0 {
+		res.ExpiresAt = time.UnixMilli(t.ExpiresAt)
+	}
+	return res
+}
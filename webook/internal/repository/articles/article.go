@@ -17,8 +17,14 @@ type ArticleRepository interface {
 	Sync(ctx context.Context, art domain.Article) (int64, error)
 	SyncStatus(ctx context.Context, id, author int64, status domain.ArticleStatus) error
 	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
+	// ListAll 作者的全部文章，不走缓存
+	ListAll(ctx context.Context, uid int64) ([]domain.Article, error)
 	GetById(ctx context.Context, id int64) (domain.Article, error)
 	GetPubById(ctx context.Context, id int64) (domain.Article, error)
+	// ListPub 作者已发表的文章，不带作者信息
+	ListPub(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
+	// PubIds 作者已发表的全部文章 id
+	PubIds(ctx context.Context, uid int64) ([]int64, error)
 }
 
 type articleRepository struct {
@@ -29,10 +35,11 @@ type articleRepository struct {
 }
 
 func NewArticleRepository(dao articles.ArticleDAO, artCache cache.ArticleCache,
-	l logger.Logger) ArticleRepository {
+	userRepo repository.UserRepository, l logger.Logger) ArticleRepository {
 	return &articleRepository{
 		dao:      dao,
 		artCache: artCache,
+		userRepo: userRepo,
 		l:        l,
 	}
 }
@@ -74,7 +81,28 @@ func (ar *articleRepository) Sync(ctx context.Context, art domain.Article) (int6
 }
 
 func (ar *articleRepository) SyncStatus(ctx context.Context, id, author int64, status domain.ArticleStatus) error {
-	return ar.dao.SyncStatus(ctx, id, author, status.ToUint8())
+	err := ar.dao.SyncStatus(ctx, id, author, status.ToUint8())
+	if err != nil {
+		return err
+	}
+	// 状态变了，缓存里面的线上文章和列表都不能再用了
+	if er := ar.artCache.DelPub(ctx, id); er != nil {
+		ar.l.Error("删除线上文章缓存失败", logger.Int64("aid", id), logger.Error(er))
+	}
+	if er := ar.artCache.DelFirstPage(ctx, author); er != nil {
+		ar.l.Error("删除文章列表缓存失败", logger.Int64("author", author), logger.Error(er))
+	}
+	return nil
+}
+
+func (ar *articleRepository) ListAll(ctx context.Context, uid int64) ([]domain.Article, error) {
+	res, err := ar.dao.FindAllByAuthor(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[articles.Article, domain.Article](res, func(idx int, src articles.Article) domain.Article {
+		return ar.toDomain(src)
+	}), nil
 }
 
 func (ar *articleRepository) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error) {
@@ -125,8 +153,9 @@ func (ar *articleRepository) GetPubById(ctx context.Context, id int64) (domain.A
 		Content: art.Content,
 		Status:  domain.ArticleStatus(art.Status),
 		Author: domain.Author{
-			Id:   user.Id,
-			Name: user.Nickname,
+			Id:     user.Id,
+			Name:   user.Nickname,
+			Avatar: user.AvatarThumb,
 		},
 		Ctime: time.UnixMilli(art.Ctime),
 		Utime: time.UnixMilli(art.Utime),
@@ -134,6 +163,20 @@ func (ar *articleRepository) GetPubById(ctx context.Context, id int64) (domain.A
 	return res, nil
 }
 
+func (ar *articleRepository) ListPub(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error) {
+	res, err := ar.dao.FindPubByAuthor(ctx, uid, domain.ArticleStatusPublished.ToUint8(), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[articles.PublishedArticle, domain.Article](res, func(idx int, src articles.PublishedArticle) domain.Article {
+		return ar.toDomain(articles.Article(src))
+	}), nil
+}
+
+func (ar *articleRepository) PubIds(ctx context.Context, uid int64) ([]int64, error) {
+	return ar.dao.FindPubIdsByAuthor(ctx, uid, domain.ArticleStatusPublished.ToUint8())
+}
+
 func (ar *articleRepository) preCache(ctx context.Context, arts []domain.Article) {
 	if len(arts) > 0 && len(arts[0].Content) < 1024*1024 {
 		err := ar.artCache.Set(ctx, arts[0])
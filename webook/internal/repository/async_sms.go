@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"time"
+)
+
+var (
+	ErrNoAsyncSMS        = dao.ErrNoAsyncSMS
+	ErrAsyncSMSPreempted = dao.ErrAsyncSMSPreempted
+)
+
+type AsyncSMSRepository interface {
+	Create(ctx context.Context, s domain.AsyncSMS) error
+	// Preempt 抢占一条到了重试时间的短信，lease 之内别的实例拿不到它。
+	// 内容解密失败的时候也会返回 Id 和 Version，调用方可以把它标记为失败
+	Preempt(ctx context.Context, lease time.Duration) (domain.AsyncSMS, error)
+	MarkSuccess(ctx context.Context, s domain.AsyncSMS) error
+	// MarkRetry nextRetryAt 为零值代表不再重试
+	MarkRetry(ctx context.Context, s domain.AsyncSMS, nextRetryAt time.Time, reason string) error
+}
+
+type asyncSMSRepository struct {
+	dao    dao.AsyncSMSDAO
+	cipher *cryptox.FieldCipher
+}
+
+func NewAsyncSMSRepository(dao dao.AsyncSMSDAO, cipher *cryptox.FieldCipher) AsyncSMSRepository {
+	return &asyncSMSRepository{
+		dao:    dao,
+		cipher: cipher,
+	}
+}
+
+// asyncSMSPayload 手机号和验证码都不能明文落库
+type asyncSMSPayload struct {
+	Args    []string `json:"args"`
+	Numbers []string `json:"numbers"`
+}
+
+func (r *asyncSMSRepository) Create(ctx context.Context, s domain.AsyncSMS) error {
+	data, err := json.Marshal(asyncSMSPayload{Args: s.Args, Numbers: s.Numbers})
+	if err != nil {
+		return err
+	}
+	payload, err := r.cipher.Encrypt(string(data))
+	if err != nil {
+		return err
+	}
+	return r.dao.Insert(ctx, dao.AsyncSMS{
+		Biz:      s.Biz,
+		Payload:  payload,
+		MaxRetry: s.MaxRetry,
+	})
+}
+
+func (r *asyncSMSRepository) Preempt(ctx context.Context, lease time.Duration) (domain.AsyncSMS, error) {
+	s, err := r.dao.Preempt(ctx, time.Now().UnixMilli(), lease.Milliseconds())
+	if err != nil {
+		return domain.AsyncSMS{}, err
+	}
+	res := domain.AsyncSMS{
+		Id:       s.Id,
+		Version:  s.Version,
+		Biz:      s.Biz,
+		RetryCnt: s.RetryCnt,
+		MaxRetry: s.MaxRetry,
+	}
+	data, err := r.cipher.Decrypt(s.Payload)
+	if err != nil {
+		return res, err
+	}
+	var payload asyncSMSPayload
+	if err = json.Unmarshal([]byte(data), &payload); err != nil {
+		return res, err
+	}
+	res.Args, res.Numbers = payload.Args, payload.Numbers
+	return res, nil
+}
+
+func (r *asyncSMSRepository) MarkSuccess(ctx context.Context, s domain.AsyncSMS) error {
+	return r.dao.MarkSuccess(ctx, s.Id, s.Version)
+}
+
+func (r *asyncSMSRepository) MarkRetry(ctx context.Context, s domain.AsyncSMS, nextRetryAt time.Time, reason string) error {
+	var next int64
+	if !nextRetryAt.IsZero() {
+		next = nextRetryAt.UnixMilli()
+	}
+	return r.dao.MarkRetry(ctx, s.Id, s.Version, next, reason)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+//go:embed lua/incr_window.lua
+var luaIncrWindow string
+
+type CaptchaCache interface {
+	Set(ctx context.Context, id, answer string, expiration time.Duration) error
+	// GetDel 取出答案的同时删掉，保证只能用一次，不存在返回 ErrKeyNotExist
+	GetDel(ctx context.Context, id string) (string, error)
+	// IncrRequest 某个场景下某个来源（比如 IP）在 window 内的请求次数加一，返回加一之后的次数
+	IncrRequest(ctx context.Context, scene, source string, window time.Duration) (int64, error)
+}
+
+type RedisCaptchaCache struct {
+	cmd redis.Cmdable
+}
+
+func NewCaptchaCache(cmd redis.Cmdable) CaptchaCache {
+	return &RedisCaptchaCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisCaptchaCache) Set(ctx context.Context, id, answer string, expiration time.Duration) error {
+	return c.cmd.Set(ctx, c.key(id), answer, expiration).Err()
+}
+
+func (c *RedisCaptchaCache) GetDel(ctx context.Context, id string) (string, error) {
+	return c.cmd.GetDel(ctx, c.key(id)).Result()
+}
+
+func (c *RedisCaptchaCache) IncrRequest(ctx context.Context, scene, source string, window time.Duration) (int64, error) {
+	return c.cmd.Eval(ctx, luaIncrWindow,
+		[]string{fmt.Sprintf("captcha:risk:%s:%s", scene, source)},
+		window.Milliseconds()).Int64()
+}
+
+func (c *RedisCaptchaCache) key(id string) string {
+	return fmt.Sprintf("captcha:%s", id)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"strconv"
+	"time"
+)
+
+const (
+	fieldFollowerCnt = "follower_cnt"
+	fieldFolloweeCnt = "followee_cnt"
+)
+
+type FollowCache interface {
+	IncrFollowerCntIfPresent(ctx context.Context, uid int64) error
+	DecrFollowerCntIfPresent(ctx context.Context, uid int64) error
+	IncrFolloweeCntIfPresent(ctx context.Context, uid int64) error
+	DecrFolloweeCntIfPresent(ctx context.Context, uid int64) error
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+	SetStatics(ctx context.Context, s domain.FollowStatics) error
+	Del(ctx context.Context, uid int64) error
+}
+
+type RedisFollowCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisFollowCache(cmd redis.Cmdable) FollowCache {
+	return &RedisFollowCache{
+		cmd: cmd,
+	}
+}
+
+func (r *RedisFollowCache) IncrFollowerCntIfPresent(ctx context.Context, uid int64) error {
+	return r.cmd.Eval(ctx, luaIncrCnt, []string{r.key(uid)}, fieldFollowerCnt, 1).Err()
+}
+
+func (r *RedisFollowCache) DecrFollowerCntIfPresent(ctx context.Context, uid int64) error {
+	return r.cmd.Eval(ctx, luaIncrCnt, []string{r.key(uid)}, fieldFollowerCnt, -1).Err()
+}
+
+func (r *RedisFollowCache) IncrFolloweeCntIfPresent(ctx context.Context, uid int64) error {
+	return r.cmd.Eval(ctx, luaIncrCnt, []string{r.key(uid)}, fieldFolloweeCnt, 1).Err()
+}
+
+func (r *RedisFollowCache) DecrFolloweeCntIfPresent(ctx context.Context, uid int64) error {
+	return r.cmd.Eval(ctx, luaIncrCnt, []string{r.key(uid)}, fieldFolloweeCnt, -1).Err()
+}
+
+func (r *RedisFollowCache) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	data, err := r.cmd.HGetAll(ctx, r.key(uid)).Result()
+	if err != nil {
+		return domain.FollowStatics{}, err
+	}
+	if len(data) == 0 {
+		return domain.FollowStatics{}, ErrKeyNotExist
+	}
+	followers, _ := strconv.ParseInt(data[fieldFollowerCnt], 10, 64)
+	followees, _ := strconv.ParseInt(data[fieldFolloweeCnt], 10, 64)
+	return domain.FollowStatics{
+		Uid:       uid,
+		Followers: followers,
+		Followees: followees,
+	}, nil
+}
+
+func (r *RedisFollowCache) SetStatics(ctx context.Context, s domain.FollowStatics) error {
+	key := r.key(s.Uid)
+	err := r.cmd.HSet(ctx, key, map[string]interface{}{
+		fieldFollowerCnt: s.Followers,
+		fieldFolloweeCnt: s.Followees,
+	}).Err()
+	if err != nil {
+		return err
+	}
+	return r.cmd.Expire(ctx, key, time.Minute*15).Err()
+}
+
+func (r *RedisFollowCache) Del(ctx context.Context, uid int64) error {
+	return r.cmd.Del(ctx, r.key(uid)).Err()
+}
+
+func (r *RedisFollowCache) key(uid int64) string {
+	return fmt.Sprintf("follow:statics:%d", uid)
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"testing"
+)
+
+func TestRedisFollowCache_IncrIfPresent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := NewRedisFollowCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	// 没有缓存的时候不创建，免得缓存里面只有一个从 1 开始的计数
+	require.NoError(t, c.IncrFollowerCntIfPresent(ctx, 1))
+	_, err := c.GetStatics(ctx, 1)
+	assert.Equal(t, ErrKeyNotExist, err)
+
+	require.NoError(t, c.SetStatics(ctx, domain.FollowStatics{Uid: 1, Followers: 10, Followees: 3}))
+	require.NoError(t, c.IncrFollowerCntIfPresent(ctx, 1))
+	require.NoError(t, c.IncrFollowerCntIfPresent(ctx, 1))
+	require.NoError(t, c.DecrFolloweeCntIfPresent(ctx, 1))
+	s, err := c.GetStatics(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.FollowStatics{Uid: 1, Followers: 12, Followees: 2}, s)
+
+	// 别的用户不受影响
+	_, err = c.GetStatics(ctx, 2)
+	assert.Equal(t, ErrKeyNotExist, err)
+}
@@ -30,6 +30,7 @@ type InteractiveCache interface {
 	DecrLikeCntIfPresent(ctx context.Context, biz string, bizId int64) error
 	Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error)
 	Set(ctx context.Context, biz string, bizId int64, intr domain.Interactive) error
+	Del(ctx context.Context, biz string, bizId int64) error
 }
 
 type RedisInteractiveCache struct {
@@ -96,6 +97,10 @@ func (r *RedisInteractiveCache) Set(ctx context.Context, biz string, bizId int64
 	return r.cmd.Expire(ctx, key, time.Minute*15).Err()
 }
 
+func (r *RedisInteractiveCache) Del(ctx context.Context, biz string, bizId int64) error {
+	return r.cmd.Del(ctx, r.key(biz, bizId)).Err()
+}
+
 func (r *RedisInteractiveCache) key(biz string, bizId int64) string {
 	return fmt.Sprintf("interactive:%s:%d", biz, bizId)
 }
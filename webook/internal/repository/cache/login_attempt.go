@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+//go:embed lua/login_fail.lua
+var luaLoginFail string
+
+// LoginAttemptPolicy 锁定策略，连续失败 Threshold 次之后锁定 Base，之后每次翻倍，最多 Max
+type LoginAttemptPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	// 失败次数在这个时间内累计
+	Window time.Duration
+}
+
+type LoginAttemptCache interface {
+	// Fail 记录一次失败，返回这次失败之后要锁定多久，0 代表不锁定
+	Fail(ctx context.Context, key string, policy LoginAttemptPolicy) (time.Duration, error)
+	// LockedFor 还要锁定多久，0 代表没有锁定
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, keys ...string) error
+}
+
+type RedisLoginAttemptCache struct {
+	cmd redis.Cmdable
+}
+
+func NewLoginAttemptCache(cmd redis.Cmdable) LoginAttemptCache {
+	return &RedisLoginAttemptCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisLoginAttemptCache) Fail(ctx context.Context, key string, policy LoginAttemptPolicy) (time.Duration, error) {
+	res, err := c.cmd.Eval(ctx, luaLoginFail, []string{c.key(key)},
+		policy.Threshold, policy.Base.Milliseconds(),
+		policy.Max.Milliseconds(), policy.Window.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+func (c *RedisLoginAttemptCache) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.cmd.PTTL(ctx, c.lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在的时候是 -2，没有过期时间是 -1，都当作没有锁定
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (c *RedisLoginAttemptCache) Reset(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	redisKeys := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		redisKeys = append(redisKeys, c.key(key), c.lockKey(key))
+	}
+	return c.cmd.Del(ctx, redisKeys...).Err()
+}
+
+func (c *RedisLoginAttemptCache) key(key string) string {
+	return fmt.Sprintf("login:fail:%s", key)
+}
+
+func (c *RedisLoginAttemptCache) lockKey(key string) string {
+	return fmt.Sprintf("login:fail:%s:lock", key)
+}
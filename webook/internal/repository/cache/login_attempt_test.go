@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRedisLoginAttemptCache_Fail(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := NewLoginAttemptCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	policy := LoginAttemptPolicy{Threshold: 3, Base: time.Minute, Max: time.Minute * 3, Window: time.Hour}
+	ctx := context.Background()
+
+	// 前两次不锁定，第三次开始锁定，每次翻倍，最多 Max
+	wants := []time.Duration{0, 0, time.Minute, time.Minute * 2, time.Minute * 3, time.Minute * 3}
+	for i, want := range wants {
+		lock, err := c.Fail(ctx, "email:123@qq.com", policy)
+		require.NoError(t, err)
+		assert.Equal(t, want, lock, "第 %d 次失败", i+1)
+	}
+	lock, err := c.LockedFor(ctx, "email:123@qq.com")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute*3, lock)
+
+	// 锁定时间到了自动解锁，但是失败次数还在，再错一次继续锁
+	mr.FastForward(time.Minute * 3)
+	lock, err = c.LockedFor(ctx, "email:123@qq.com")
+	require.NoError(t, err)
+	assert.Zero(t, lock)
+
+	require.NoError(t, c.Reset(ctx, "email:123@qq.com"))
+	lock, err = c.Fail(ctx, "email:123@qq.com", policy)
+	require.NoError(t, err)
+	assert.Zero(t, lock)
+}
+
+func TestRedisLoginAttemptCache_Window(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := NewLoginAttemptCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	policy := LoginAttemptPolicy{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Minute * 10}
+	ctx := context.Background()
+
+	_, err := c.Fail(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	// 超过了统计窗口，重新计数
+	mr.FastForward(time.Minute * 11)
+	lock, err := c.Fail(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	assert.Zero(t, lock)
+}
@@ -0,0 +1,9 @@
+-- 固定窗口计数，第一次计数的时候设置过期时间，返回当前窗口内的次数
+local key = KEYS[1]
+-- 窗口长度，毫秒
+local window = tonumber(ARGV[1])
+local cnt = redis.call("INCR", key)
+if cnt == 1 then
+    redis.call("PEXPIRE", key, window)
+end
+return cnt
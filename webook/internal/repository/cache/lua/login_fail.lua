@@ -0,0 +1,26 @@
+-- 登录失败计数，超过阈值之后按指数退避锁定
+-- login:fail:email:xxx 或者 login:fail:ip:xxx
+local key = KEYS[1]
+local lockKey = key..":lock"
+-- 连续失败多少次开始锁定
+local threshold = tonumber(ARGV[1])
+-- 第一次锁定多久，毫秒
+local base = tonumber(ARGV[2])
+-- 最多锁定多久，毫秒
+local max = tonumber(ARGV[3])
+-- 失败次数在多长时间内累计，毫秒
+local window = tonumber(ARGV[4])
+
+local cnt = redis.call("INCR", key)
+redis.call("PEXPIRE", key, window)
+if cnt < threshold then
+    return 0
+end
+-- 每多失败一次，锁定时间翻倍
+local lock = base * 2 ^ (cnt - threshold)
+if lock > max then
+    lock = max
+end
+lock = math.floor(lock)
+redis.call("SET", lockKey, cnt, "PX", lock)
+return lock
@@ -12,6 +12,9 @@ import (
 type UserCache interface {
 	Get(ctx context.Context, id int64) (domain.User, error)
 	Set(ctx context.Context, u domain.User) error
+	Del(ctx context.Context, id int64) error
+	// MarkTotpUsed 标记 uid 在 step 这个周期的验证码已经用过了，之前已经标记过返回 false
+	MarkTotpUsed(ctx context.Context, uid, step int64, expiration time.Duration) (bool, error)
 }
 
 type userCache struct {
@@ -46,6 +49,14 @@ func (c *userCache) Set(ctx context.Context, u domain.User) error {
 	return c.cmd.Set(ctx, key, data, c.expiration).Err()
 }
 
+func (c *userCache) Del(ctx context.Context, id int64) error {
+	return c.cmd.Del(ctx, c.key(id)).Err()
+}
+
+func (c *userCache) MarkTotpUsed(ctx context.Context, uid, step int64, expiration time.Duration) (bool, error) {
+	return c.cmd.SetNX(ctx, fmt.Sprintf("user:totp:used:%d:%d", uid, step), 1, expiration).Result()
+}
+
 func (c *userCache) key(id int64) string {
 	return fmt.Sprintf("user:info:%d", id)
 }
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"time"
+)
+
+type UserExportCache interface {
+	// Get 没有任务返回 ErrKeyNotExist
+	Get(ctx context.Context, uid int64) (domain.ExportTask, error)
+	Set(ctx context.Context, task domain.ExportTask) error
+	// Lock 同一个用户同一时间只能有一个导出任务在打包，已经有了返回 false。
+	// 打包的实例挂了的话，锁过期之后可以重新导出
+	Lock(ctx context.Context, uid int64, expiration time.Duration) (bool, error)
+	Unlock(ctx context.Context, uid int64) error
+}
+
+type userExportCache struct {
+	cmd redis.Cmdable
+	// 导出的文件只保留这么久
+	expiration time.Duration
+}
+
+func NewUserExportCache(cmd redis.Cmdable) UserExportCache {
+	return &userExportCache{
+		cmd:        cmd,
+		expiration: time.Hour * 24,
+	}
+}
+
+func (c *userExportCache) Get(ctx context.Context, uid int64) (domain.ExportTask, error) {
+	data, err := c.cmd.Get(ctx, c.key(uid)).Bytes()
+	if err != nil {
+		return domain.ExportTask{}, err
+	}
+	var task domain.ExportTask
+	err = json.Unmarshal(data, &task)
+	return task, err
+}
+
+func (c *userExportCache) Set(ctx context.Context, task domain.ExportTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.key(task.Uid), data, c.expiration).Err()
+}
+
+func (c *userExportCache) Lock(ctx context.Context, uid int64, expiration time.Duration) (bool, error) {
+	return c.cmd.SetNX(ctx, c.lockKey(uid), 1, expiration).Result()
+}
+
+func (c *userExportCache) Unlock(ctx context.Context, uid int64) error {
+	return c.cmd.Del(ctx, c.lockKey(uid)).Err()
+}
+
+func (c *userExportCache) lockKey(uid int64) string {
+	return fmt.Sprintf("users:export:lock:%d", uid)
+}
+
+func (c *userExportCache) key(uid int64) string {
+	return fmt.Sprintf("users:export:%d", uid)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUserExportCache_Lock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := NewUserExportCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	ok, err := c.Lock(ctx, 1, time.Minute*10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	// 正在打包，别的请求拿不到锁
+	ok, err = c.Lock(ctx, 1, time.Minute*10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	// 别的用户不受影响
+	ok, err = c.Lock(ctx, 2, time.Minute*10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 打包完了释放
+	require.NoError(t, c.Unlock(ctx, 1))
+	ok, err = c.Lock(ctx, 1, time.Minute*10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 打包的实例挂了，锁过期之后可以重新导出
+	mr.FastForward(time.Minute * 10)
+	ok, err = c.Lock(ctx, 1, time.Minute*10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
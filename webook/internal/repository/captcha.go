@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	"time"
+)
+
+var ErrCaptchaNotFound = cache.ErrKeyNotExist
+
+type CaptchaRepository interface {
+	Store(ctx context.Context, id, answer string, expiration time.Duration) error
+	// Take 取出答案，取出之后就失效了
+	Take(ctx context.Context, id string) (string, error)
+	IncrRequest(ctx context.Context, scene, source string, window time.Duration) (int64, error)
+}
+
+type captchaRepository struct {
+	cache cache.CaptchaCache
+}
+
+func NewCaptchaRepository(c cache.CaptchaCache) CaptchaRepository {
+	return &captchaRepository{
+		cache: c,
+	}
+}
+
+func (repo *captchaRepository) Store(ctx context.Context, id, answer string, expiration time.Duration) error {
+	return repo.cache.Set(ctx, id, answer, expiration)
+}
+
+func (repo *captchaRepository) Take(ctx context.Context, id string) (string, error) {
+	return repo.cache.GetDel(ctx, id)
+}
+
+func (repo *captchaRepository) IncrRequest(ctx context.Context, scene, source string, window time.Duration) (int64, error) {
+	return repo.cache.IncrRequest(ctx, scene, source, window)
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrAccessTokenNotFound = gorm.ErrRecordNotFound
+
+type AccessTokenDAO interface {
+	Insert(ctx context.Context, t AccessToken) (int64, error)
+	FindByHash(ctx context.Context, hash string) (AccessToken, error)
+	FindByUid(ctx context.Context, uid int64) ([]AccessToken, error)
+	// Delete 只能删除自己的令牌，没删掉返回 ErrAccessTokenNotFound
+	Delete(ctx context.Context, uid, id int64) error
+	UpdateLastUsed(ctx context.Context, id int64, lastUsed int64) error
+}
+
+type accessTokenDAO struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenDAO(db *gorm.DB) AccessTokenDAO {
+	return &accessTokenDAO{
+		db: db,
+	}
+}
+
+func (d *accessTokenDAO) Insert(ctx context.Context, t AccessToken) (int64, error) {
+	now := time.Now().UnixMilli()
+	t.Ctime = now
+	t.Utime = now
+	err := d.db.WithContext(ctx).Create(&t).Error
+	return t.Id, err
+}
+
+func (d *accessTokenDAO) FindByHash(ctx context.Context, hash string) (AccessToken, error) {
+	var t AccessToken
+	err := d.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error
+	return t, err
+}
+
+func (d *accessTokenDAO) FindByUid(ctx context.Context, uid int64) ([]AccessToken, error) {
+	var res []AccessToken
+	err := d.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").Find(&res).Error
+	return res, err
+}
+
+func (d *accessTokenDAO) Delete(ctx context.Context, uid, id int64) error {
+	res := d.db.WithContext(ctx).Where("id = ? AND uid = ?", id, uid).
+		Delete(&AccessToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+func (d *accessTokenDAO) UpdateLastUsed(ctx context.Context, id int64, lastUsed int64) error {
+	return d.db.WithContext(ctx).Model(&AccessToken{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"last_used_at": lastUsed,
+			"utime":        time.Now().UnixMilli(),
+		}).Error
+}
+
+// AccessToken 个人访问令牌，只存 sha256 之后的结果
+type AccessToken struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement"`
+	Uid       int64  `gorm:"index"`
+	Name      string `gorm:"type:varchar(64)"`
+	TokenHash string `gorm:"type:varchar(64);unique"`
+	Prefix    string `gorm:"type:varchar(16)"`
+	// 逗号分隔
+	Scopes     string `gorm:"type:varchar(256)"`
+	LastUsedAt int64
+	// 0 代表永不过期
+	ExpiresAt int64
+	Ctime     int64
+	Utime     int64
+}
@@ -124,6 +124,15 @@ func (d *articleDao) FindByAuthor(ctx context.Context, uid int64, offset, limit
 	return arts, err
 }
 
+func (d *articleDao) FindAllByAuthor(ctx context.Context, uid int64) ([]Article, error) {
+	var arts []Article
+	err := d.db.WithContext(ctx).Model(&Article{}).
+		Where("author_id = ?", uid).
+		Order("id").
+		Find(&arts).Error
+	return arts, err
+}
+
 func (d *articleDao) GetById(ctx context.Context, id int64) (Article, error) {
 	var art Article
 	err := d.db.WithContext(ctx).Where("id = ?", id).First(&art).Error
@@ -131,7 +140,25 @@ func (d *articleDao) GetById(ctx context.Context, id int64) (Article, error) {
 }
 
 func (d *articleDao) GetPubById(ctx context.Context, id int64) (Article, error) {
-	var art Article
+	var art PublishedArticle
 	err := d.db.WithContext(ctx).Where("id = ?", id).First(&art).Error
-	return art, err
+	return Article(art), err
+}
+
+func (d *articleDao) FindPubByAuthor(ctx context.Context, uid int64, status uint8, offset, limit int) ([]PublishedArticle, error) {
+	var arts []PublishedArticle
+	err := d.db.WithContext(ctx).Model(&PublishedArticle{}).
+		Where("author_id = ? AND status = ?", uid, status).
+		Offset(offset).Limit(limit).
+		Order("utime DESC").
+		Find(&arts).Error
+	return arts, err
+}
+
+func (d *articleDao) FindPubIdsByAuthor(ctx context.Context, uid int64, status uint8) ([]int64, error) {
+	var ids []int64
+	err := d.db.WithContext(ctx).Model(&PublishedArticle{}).
+		Where("author_id = ? AND status = ?", uid, status).
+		Pluck("id", &ids).Error
+	return ids, err
 }
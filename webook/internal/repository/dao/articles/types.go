@@ -13,6 +13,12 @@ type ArticleDAO interface {
 	Sync(ctx context.Context, art Article) (int64, error)
 	SyncStatus(ctx context.Context, id, author int64, status uint8) error
 	FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]Article, error)
+	// FindAllByAuthor 作者的全部文章，导出数据和注销的时候用
+	FindAllByAuthor(ctx context.Context, uid int64) ([]Article, error)
 	GetById(ctx context.Context, id int64) (Article, error)
 	GetPubById(ctx context.Context, id int64) (Article, error)
+	// FindPubByAuthor 作者处于 status 状态的线上文章，按更新时间倒序
+	FindPubByAuthor(ctx context.Context, uid int64, status uint8, offset, limit int) ([]PublishedArticle, error)
+	// FindPubIdsByAuthor 作者处于 status 状态的全部线上文章 id，聚合阅读点赞数据的时候用
+	FindPubIdsByAuthor(ctx context.Context, uid int64, status uint8) ([]int64, error)
 }
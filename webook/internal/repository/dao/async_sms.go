@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// 异步短信的状态
+const (
+	AsyncSMSStatusWaiting uint8 = iota
+	AsyncSMSStatusSuccess
+	// AsyncSMSStatusFailed 重试次数用完了，不再发送
+	AsyncSMSStatusFailed
+)
+
+var (
+	// ErrNoAsyncSMS 没有到了重试时间的短信
+	ErrNoAsyncSMS = gorm.ErrRecordNotFound
+	// ErrAsyncSMSPreempted 抢占的租期过了，已经被别的实例抢走了
+	ErrAsyncSMSPreempted = errors.New("异步短信已经被别的实例抢占")
+)
+
+type AsyncSMSDAO interface {
+	Insert(ctx context.Context, s AsyncSMS) error
+	// Preempt 抢占一条到了重试时间的短信，同时把 next_retry_at 往后推 lease。
+	// 抢到的实例挂了的话，过了 lease 别的实例可以接着重试
+	Preempt(ctx context.Context, now int64, lease int64) (AsyncSMS, error)
+	MarkSuccess(ctx context.Context, id, version int64) error
+	// MarkRetry 发送失败，重试次数加一，nextRetryAt 为 0 的时候标记为彻底失败
+	MarkRetry(ctx context.Context, id, version int64, nextRetryAt int64, reason string) error
+}
+
+type asyncSMSDAO struct {
+	db *gorm.DB
+}
+
+func NewAsyncSMSDAO(db *gorm.DB) AsyncSMSDAO {
+	return &asyncSMSDAO{
+		db: db,
+	}
+}
+
+func (d *asyncSMSDAO) Insert(ctx context.Context, s AsyncSMS) error {
+	now := time.Now().UnixMilli()
+	s.Status = AsyncSMSStatusWaiting
+	s.NextRetryAt = now
+	s.Ctime = now
+	s.Utime = now
+	return d.db.WithContext(ctx).Create(&s).Error
+}
+
+func (d *asyncSMSDAO) Preempt(ctx context.Context, now int64, lease int64) (AsyncSMS, error) {
+	var s AsyncSMS
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED 跳过别的实例正在抢的行，多个实例之间不用互相等
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_retry_at <= ?", AsyncSMSStatusWaiting, now).
+			Order("next_retry_at").
+			First(&s).Error
+		if err != nil {
+			return err
+		}
+		s.Version++
+		s.NextRetryAt = now + lease
+		s.Utime = now
+		return tx.Model(&AsyncSMS{}).Where("id = ?", s.Id).
+			Updates(map[string]any{
+				"version":       s.Version,
+				"next_retry_at": s.NextRetryAt,
+				"utime":         now,
+			}).Error
+	})
+	return s, err
+}
+
+func (d *asyncSMSDAO) MarkSuccess(ctx context.Context, id, version int64) error {
+	return d.update(ctx, id, version, map[string]any{
+		"status": AsyncSMSStatusSuccess,
+		"utime":  time.Now().UnixMilli(),
+	})
+}
+
+func (d *asyncSMSDAO) MarkRetry(ctx context.Context, id, version int64, nextRetryAt int64, reason string) error {
+	updates := map[string]any{
+		"retry_cnt":     gorm.Expr("retry_cnt + 1"),
+		"next_retry_at": nextRetryAt,
+		"last_error":    reason,
+		"utime":         time.Now().UnixMilli(),
+	}
+	if nextRetryAt == 0 {
+		updates["status"] = AsyncSMSStatusFailed
+	}
+	return d.update(ctx, id, version, updates)
+}
+
+// update 只有 version 没变，也就是还是自己抢到的，才能更新
+func (d *asyncSMSDAO) update(ctx context.Context, id, version int64, updates map[string]any) error {
+	res := d.db.WithContext(ctx).Model(&AsyncSMS{}).
+		Where("id = ? AND version = ? AND status = ?", id, version, AsyncSMSStatusWaiting).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAsyncSMSPreempted
+	}
+	return nil
+}
+
+type AsyncSMS struct {
+	Id  int64  `gorm:"primaryKey;autoIncrement"`
+	Biz string `gorm:"type:varchar(128)"`
+	// 参数和手机号序列化之后加密存储，参数里面一般是验证码
+	Payload  string `gorm:"type:varchar(4096)"`
+	Status   uint8  `gorm:"index:status_next_retry_at"`
+	RetryCnt int32
+	MaxRetry int32
+	// 下一次可以重试的时间，被抢占之后也会往后推
+	NextRetryAt int64 `gorm:"index:status_next_retry_at"`
+	Version     int64
+	LastError   string `gorm:"type:varchar(512)"`
+	Ctime       int64
+	Utime       int64
+}
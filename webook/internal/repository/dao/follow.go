@@ -0,0 +1,155 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	// ErrFollowExists 已经关注过了，计数不会变
+	ErrFollowExists = errors.New("已经关注过了")
+	// ErrFollowNotFound 没有关注，取消关注的时候计数不会变
+	ErrFollowNotFound = gorm.ErrRecordNotFound
+)
+
+type FollowDAO interface {
+	// Follow 关注，已经关注过返回 ErrFollowExists
+	Follow(ctx context.Context, follower, followee int64) error
+	// Unfollow 取消关注，没有关注返回 ErrFollowNotFound
+	Unfollow(ctx context.Context, follower, followee int64) error
+	FindRelation(ctx context.Context, follower, followee int64) (FollowRelation, error)
+	// FindFollowees follower 关注的人，按关注时间倒序，cursor 是上一页最后一条的 id，0 代表第一页
+	FindFollowees(ctx context.Context, follower, cursor int64, limit int) ([]FollowRelation, error)
+	// FindFollowers 关注了 followee 的人，分页方式同 FindFollowees
+	FindFollowers(ctx context.Context, followee, cursor int64, limit int) ([]FollowRelation, error)
+	GetStatics(ctx context.Context, uid int64) (FollowStatics, error)
+}
+
+type followDAO struct {
+	db *gorm.DB
+}
+
+func NewFollowDAO(db *gorm.DB) FollowDAO {
+	return &followDAO{
+		db: db,
+	}
+}
+
+func (d *followDAO) Follow(ctx context.Context, follower, followee int64) error {
+	now := time.Now().UnixMilli()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&FollowRelation{
+			Follower: follower,
+			Followee: followee,
+			Ctime:    now,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		// 唯一索引冲突，说明已经关注过了，不能重复计数
+		if res.RowsAffected == 0 {
+			return ErrFollowExists
+		}
+		err := d.incrStatics(tx, follower, "followees", 1, now)
+		if err != nil {
+			return err
+		}
+		return d.incrStatics(tx, followee, "followers", 1, now)
+	})
+}
+
+func (d *followDAO) Unfollow(ctx context.Context, follower, followee int64) error {
+	now := time.Now().UnixMilli()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 直接删掉，重新关注的时候会生成新的 id，列表按 id 排序就是按关注时间排序
+		res := tx.Where("follower = ? AND followee = ?", follower, followee).
+			Delete(&FollowRelation{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrFollowNotFound
+		}
+		err := d.incrStatics(tx, follower, "followees", -1, now)
+		if err != nil {
+			return err
+		}
+		return d.incrStatics(tx, followee, "followers", -1, now)
+	})
+}
+
+// incrStatics 更新计数，field 只能是 followers 或者 followees
+func (d *followDAO) incrStatics(tx *gorm.DB, uid int64, field string, delta int64, now int64) error {
+	s := FollowStatics{
+		Uid:   uid,
+		Utime: now,
+		Ctime: now,
+	}
+	if field == "followers" {
+		s.Followers = delta
+	} else {
+		s.Followees = delta
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			field:   gorm.Expr(field+" + ?", delta),
+			"utime": now,
+		}),
+	}).Create(&s).Error
+}
+
+func (d *followDAO) FindRelation(ctx context.Context, follower, followee int64) (FollowRelation, error) {
+	var res FollowRelation
+	err := d.db.WithContext(ctx).
+		Where("follower = ? AND followee = ?", follower, followee).
+		First(&res).Error
+	return res, err
+}
+
+func (d *followDAO) FindFollowees(ctx context.Context, follower, cursor int64, limit int) ([]FollowRelation, error) {
+	return d.findPage(ctx, "follower", follower, cursor, limit)
+}
+
+func (d *followDAO) FindFollowers(ctx context.Context, followee, cursor int64, limit int) ([]FollowRelation, error) {
+	return d.findPage(ctx, "followee", followee, cursor, limit)
+}
+
+func (d *followDAO) findPage(ctx context.Context, col string, uid, cursor int64, limit int) ([]FollowRelation, error) {
+	var res []FollowRelation
+	query := d.db.WithContext(ctx).Where(col+" = ?", uid)
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (d *followDAO) GetStatics(ctx context.Context, uid int64) (FollowStatics, error) {
+	var res FollowStatics
+	err := d.db.WithContext(ctx).Where("uid = ?", uid).First(&res).Error
+	return res, err
+}
+
+// FollowRelation 关注关系，取消关注直接删除
+type FollowRelation struct {
+	Id       int64 `gorm:"primaryKey;autoIncrement"`
+	Follower int64 `gorm:"uniqueIndex:follower_followee"`
+	Followee int64 `gorm:"uniqueIndex:follower_followee;index"`
+	Ctime    int64
+}
+
+// FollowStatics 关注数和粉丝数，和 Interactive 一样单独存一张表
+type FollowStatics struct {
+	Id  int64 `gorm:"primaryKey;autoIncrement"`
+	Uid int64 `gorm:"unique"`
+	// 粉丝数
+	Followers int64
+	// 关注数
+	Followees int64
+	Utime     int64
+	Ctime     int64
+}
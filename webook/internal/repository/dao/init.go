@@ -6,6 +6,9 @@ import (
 )
 
 func InitTables(db *gorm.DB) error {
-	return db.AutoMigrate(&User{}, &articles.Article{}, &Interactive{},
-		&UserLikeBiz{}, &UserCollectionBiz{})
+	return db.AutoMigrate(&User{}, &articles.Article{}, &articles.PublishedArticle{}, &Interactive{},
+		&UserLikeBiz{}, &UserCollectionBiz{}, &UserRecoveryCode{}, &UserOAuth{},
+		&UserRole{}, &RolePermission{}, &AccessToken{},
+		&UserReadHistory{}, &FollowRelation{}, &FollowStatics{}, &LoginLog{},
+		&InviteCode{}, &Invitation{}, &AsyncSMS{})
 }
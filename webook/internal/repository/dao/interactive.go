@@ -18,7 +18,16 @@ type InteractiveDAO interface {
 	GetCollectInfo(ctx context.Context, biz string, bizId, uid int64) (UserCollectionBiz, error)
 	GetLikeInfo(ctx context.Context, biz string, bizId, uid int64) (UserLikeBiz, error)
 	Get(ctx context.Context, biz string, bizId int64) (Interactive, error)
+	// Sum 汇总一批资源的阅读、点赞、收藏数
+	Sum(ctx context.Context, biz string, bizIds []int64) (Interactive, error)
 	BatchIncrReadCnt(ctx context.Context, biz []string, bizId []int64) error
+	// InsertReadHistory 阅读记录，同一篇只保留一条，更新最后阅读时间
+	InsertReadHistory(ctx context.Context, h UserReadHistory) error
+	FindLikesByUid(ctx context.Context, uid int64) ([]UserLikeBiz, error)
+	FindCollectionsByUid(ctx context.Context, uid int64) ([]UserCollectionBiz, error)
+	FindReadHistoryByUid(ctx context.Context, uid int64) ([]UserReadHistory, error)
+	// DeleteByUid 删除用户所有的点赞、收藏和阅读记录，同时扣掉计数，返回计数有变化的资源
+	DeleteByUid(ctx context.Context, uid int64) ([]Interactive, error)
 }
 
 type interactiveDAO struct {
@@ -106,9 +115,10 @@ func (d *interactiveDAO) InsertCollectionBiz(ctx context.Context, cb UserCollect
 	now := time.Now().UnixMilli()
 	cb.Utime = now
 	cb.Ctime = now
+	cb.Status = 1
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 插入收藏项目
-		err := d.db.WithContext(ctx).Create(&cb).Error
+		err := tx.Create(&cb).Error
 		if err != nil {
 			return err
 		}
@@ -131,7 +141,7 @@ func (d *interactiveDAO) InsertCollectionBiz(ctx context.Context, cb UserCollect
 func (d *interactiveDAO) DecrCollectInfo(ctx context.Context, cb UserCollectionBiz) error {
 	now := time.Now().UnixMilli()
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		err := tx.Find(&UserLikeBiz{}).
+		err := tx.Model(&UserCollectionBiz{}).
 			Where("biz = ? AND biz_id = ? AND uid = ?", cb.Biz, cb.BizId, cb.Uid).
 			Updates(map[string]interface{}{
 				"utime":  now,
@@ -171,6 +181,20 @@ func (d *interactiveDAO) Get(ctx context.Context, biz string, bizId int64) (Inte
 	return res, err
 }
 
+func (d *interactiveDAO) Sum(ctx context.Context, biz string, bizIds []int64) (Interactive, error) {
+	var res Interactive
+	if len(bizIds) == 0 {
+		return res, nil
+	}
+	err := d.db.WithContext(ctx).Model(&Interactive{}).
+		Select("COALESCE(SUM(read_cnt), 0) AS read_cnt, "+
+			"COALESCE(SUM(like_cnt), 0) AS like_cnt, "+
+			"COALESCE(SUM(collect_cnt), 0) AS collect_cnt").
+		Where("biz = ? AND biz_id IN ?", biz, bizIds).
+		Scan(&res).Error
+	return res, err
+}
+
 func (d *interactiveDAO) BatchIncrReadCnt(ctx context.Context, biz []string, bizId []int64) error {
 	// 可以用 map 合并吗？
 	// 看情况。如果一批次里面，biz 和 bizId 都相等的占很多，那么就 map 合并，性能会更好
@@ -195,6 +219,96 @@ func (d *interactiveDAO) BatchIncrReadCnt(ctx context.Context, biz []string, biz
 	})
 }
 
+func (d *interactiveDAO) InsertReadHistory(ctx context.Context, h UserReadHistory) error {
+	now := time.Now().UnixMilli()
+	h.Ctime = now
+	h.Utime = now
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"utime": now,
+		}),
+	}).Create(&h).Error
+}
+
+func (d *interactiveDAO) FindLikesByUid(ctx context.Context, uid int64) ([]UserLikeBiz, error) {
+	var res []UserLikeBiz
+	err := d.db.WithContext(ctx).
+		Where("uid = ? AND status = ?", uid, 1).
+		Order("utime DESC").Find(&res).Error
+	return res, err
+}
+
+func (d *interactiveDAO) FindCollectionsByUid(ctx context.Context, uid int64) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	err := d.db.WithContext(ctx).
+		Where("uid = ? AND status = ?", uid, 1).
+		Order("utime DESC").Find(&res).Error
+	return res, err
+}
+
+func (d *interactiveDAO) FindReadHistoryByUid(ctx context.Context, uid int64) ([]UserReadHistory, error) {
+	var res []UserReadHistory
+	err := d.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("utime DESC").Find(&res).Error
+	return res, err
+}
+
+func (d *interactiveDAO) DeleteByUid(ctx context.Context, uid int64) ([]Interactive, error) {
+	var changed []Interactive
+	now := time.Now().UnixMilli()
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		changed = changed[:0]
+		var likes []UserLikeBiz
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("uid = ?", uid).Find(&likes).Error
+		if err != nil {
+			return err
+		}
+		for _, l := range likes {
+			if l.Status != 1 {
+				continue
+			}
+			err = tx.Model(&Interactive{}).Where("biz = ? AND biz_id = ?", l.Biz, l.BizId).
+				Updates(map[string]any{
+					"like_cnt": gorm.Expr("like_cnt - ?", 1),
+					"utime":    now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			changed = append(changed, Interactive{Biz: l.Biz, BizId: l.BizId})
+		}
+		var cbs []UserCollectionBiz
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("uid = ?", uid).Find(&cbs).Error
+		if err != nil {
+			return err
+		}
+		for _, cb := range cbs {
+			if cb.Status != 1 {
+				continue
+			}
+			err = tx.Model(&Interactive{}).Where("biz = ? AND biz_id = ?", cb.Biz, cb.BizId).
+				Updates(map[string]any{
+					"collect_cnt": gorm.Expr("collect_cnt - ?", 1),
+					"utime":       now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			changed = append(changed, Interactive{Biz: cb.Biz, BizId: cb.BizId})
+		}
+		for _, model := range []any{&UserLikeBiz{}, &UserCollectionBiz{}, &UserReadHistory{}} {
+			err = tx.Where("uid = ?", uid).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changed, err
+}
+
 type UserLikeBiz struct {
 	Id    int64  `gorm:"primaryKey;autoIncrement"`
 	Biz   string `gorm:"uniqueIndex:uid_biz_id_type; type:varchar(128)"`
@@ -228,3 +342,14 @@ type Interactive struct {
 	Utime      int64
 	Ctime      int64
 }
+
+// UserReadHistory 阅读记录
+type UserReadHistory struct {
+	Id    int64  `gorm:"primaryKey;autoIncrement"`
+	Uid   int64  `gorm:"uniqueIndex:uid_biz_id"`
+	Biz   string `gorm:"type:varchar(128);uniqueIndex:uid_biz_id"`
+	BizId int64  `gorm:"uniqueIndex:uid_biz_id"`
+	Ctime int64
+	// 最后一次阅读的时间
+	Utime int64
+}
@@ -0,0 +1,117 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ErrInviteCodeInvalid 邀请码不存在、已经用完或者过期了
+var ErrInviteCodeInvalid = errors.New("邀请码无效")
+
+type InviteDAO interface {
+	Insert(ctx context.Context, c InviteCode) error
+	// FindByInviter 按时间倒序
+	FindByInviter(ctx context.Context, inviter int64, offset, limit int) ([]InviteCode, error)
+	// CountUsable inviter 生成的还能用的邀请码有几个
+	CountUsable(ctx context.Context, inviter int64, now int64) (int64, error)
+	// Usable code 存在、没用完、也没过期。只是提前检查，真正用掉要在注册的事务里面
+	Usable(ctx context.Context, code string, now int64) (bool, error)
+	// FindInvitations inviter 为 0 的时候查所有的邀请关系，按时间倒序
+	FindInvitations(ctx context.Context, inviter int64, offset, limit int) ([]Invitation, error)
+}
+
+type inviteDAO struct {
+	db *gorm.DB
+}
+
+func NewInviteDAO(db *gorm.DB) InviteDAO {
+	return &inviteDAO{
+		db: db,
+	}
+}
+
+func (d *inviteDAO) Insert(ctx context.Context, c InviteCode) error {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	return d.db.WithContext(ctx).Create(&c).Error
+}
+
+func (d *inviteDAO) FindByInviter(ctx context.Context, inviter int64, offset, limit int) ([]InviteCode, error) {
+	var res []InviteCode
+	err := d.db.WithContext(ctx).Where("inviter = ?", inviter).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *inviteDAO) CountUsable(ctx context.Context, inviter int64, now int64) (int64, error) {
+	var cnt int64
+	err := d.db.WithContext(ctx).Model(&InviteCode{}).
+		Where("inviter = ? AND used < max_uses AND (expire_at = 0 OR expire_at > ?)", inviter, now).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+func (d *inviteDAO) Usable(ctx context.Context, code string, now int64) (bool, error) {
+	var cnt int64
+	err := d.db.WithContext(ctx).Model(&InviteCode{}).
+		Where("code = ? AND used < max_uses AND (expire_at = 0 OR expire_at > ?)", code, now).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+func (d *inviteDAO) FindInvitations(ctx context.Context, inviter int64, offset, limit int) ([]Invitation, error) {
+	var res []Invitation
+	query := d.db.WithContext(ctx)
+	if inviter > 0 {
+		query = query.Where("inviter = ?", inviter)
+	}
+	err := query.Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+// consumeInviteCode 在事务里面用掉一次邀请码，返回邀请人。
+// 用 used < max_uses 作为更新条件，并发注册的时候也不会超用
+func consumeInviteCode(tx *gorm.DB, code string, now int64) (int64, error) {
+	res := tx.Model(&InviteCode{}).
+		Where("code = ? AND used < max_uses AND (expire_at = 0 OR expire_at > ?)", code, now).
+		Updates(map[string]any{
+			"used":  gorm.Expr("used + 1"),
+			"utime": now,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, ErrInviteCodeInvalid
+	}
+	var c InviteCode
+	err := tx.Select("inviter").Where("code = ?", code).First(&c).Error
+	return c.Inviter, err
+}
+
+type InviteCode struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Code     string `gorm:"type:varchar(32);unique"`
+	Inviter  int64  `gorm:"index"`
+	MaxUses  int32
+	Used     int32
+	ExpireAt int64
+	Ctime    int64
+	Utime    int64
+}
+
+// Invitation 一个用户只会被邀请一次
+type Invitation struct {
+	Id      int64  `gorm:"primaryKey;autoIncrement"`
+	Inviter int64  `gorm:"index"`
+	Invitee int64  `gorm:"unique"`
+	Code    string `gorm:"type:varchar(32)"`
+	Ctime   int64
+}
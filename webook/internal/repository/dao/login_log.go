@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+type LoginLogDAO interface {
+	Insert(ctx context.Context, l LoginLog) error
+	// FindByUid 按时间倒序
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LoginLog, error)
+	// CountSuccess uid 从 userAgent 这个设备成功登录过几次，userAgent 为空就是所有设备
+	CountSuccess(ctx context.Context, uid int64, userAgent string) (int64, error)
+	// CountFailures 盲索引是 accountIdx 的账号在 since 之后失败了几次
+	CountFailures(ctx context.Context, accountIdx string, since int64) (int64, error)
+	// FindUnindexed id 大于 afterId 的，账号还是明文的旧记录，按 id 排序
+	FindUnindexed(ctx context.Context, afterId int64, limit int) ([]LoginLog, error)
+	// UpdateAccount 把旧记录的明文账号换成脱敏之后的账号和盲索引，已经换过了就不动
+	UpdateAccount(ctx context.Context, id int64, account, accountIdx string) error
+}
+
+type loginLogDAO struct {
+	db *gorm.DB
+}
+
+func NewLoginLogDAO(db *gorm.DB) LoginLogDAO {
+	return &loginLogDAO{
+		db: db,
+	}
+}
+
+func (d *loginLogDAO) Insert(ctx context.Context, l LoginLog) error {
+	return d.db.WithContext(ctx).Create(&l).Error
+}
+
+func (d *loginLogDAO) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LoginLog, error) {
+	var res []LoginLog
+	err := d.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *loginLogDAO) CountSuccess(ctx context.Context, uid int64, userAgent string) (int64, error) {
+	var cnt int64
+	query := d.db.WithContext(ctx).Model(&LoginLog{}).
+		Where("uid = ? AND success = ?", uid, true)
+	if userAgent != "" {
+		query = query.Where("user_agent = ?", userAgent)
+	}
+	err := query.Count(&cnt).Error
+	return cnt, err
+}
+
+func (d *loginLogDAO) CountFailures(ctx context.Context, accountIdx string, since int64) (int64, error) {
+	var cnt int64
+	err := d.db.WithContext(ctx).Model(&LoginLog{}).
+		Where("account_idx = ? AND success = ? AND ctime >= ?", accountIdx, false, since).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+func (d *loginLogDAO) FindUnindexed(ctx context.Context, afterId int64, limit int) ([]LoginLog, error) {
+	var res []LoginLog
+	err := d.db.WithContext(ctx).
+		Where("id > ? AND account_idx = ? AND account <> ?", afterId, "", "").
+		Order("id").Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *loginLogDAO) UpdateAccount(ctx context.Context, id int64, account, accountIdx string) error {
+	return d.db.WithContext(ctx).Model(&LoginLog{}).
+		Where("id = ? AND account_idx = ?", id, "").
+		Updates(map[string]any{
+			"account":     account,
+			"account_idx": accountIdx,
+		}).Error
+}
+
+// LoginLog 登录审计日志，只增不改
+type LoginLog struct {
+	Id     int64  `gorm:"primaryKey;autoIncrement"`
+	Uid    int64  `gorm:"index"`
+	Method string `gorm:"type:varchar(32)"`
+	// Account 脱敏之后的手机号或者邮箱，只用来展示
+	Account string `gorm:"type:varchar(128)"`
+	// AccountIdx 账号的盲索引，按账号统计失败次数用。旧数据补上之前是空字符串
+	AccountIdx string `gorm:"type:varchar(64);not null;default:'';index:account_idx_ctime"`
+	Ip         string `gorm:"type:varchar(64)"`
+	UserAgent  string `gorm:"type:varchar(512)"`
+	Success    bool
+	Reason     string `gorm:"type:varchar(128)"`
+	Ctime      int64  `gorm:"index:account_idx_ctime"`
+}
@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"time"
+)
+
+var ErrLastRoleMember = errors.New("这个角色只剩下最后一个用户")
+
+type RBACDAO interface {
+	// FindRolePermissions 用户拥有的所有角色以及角色下面的权限，一个权限一行
+	FindRolePermissions(ctx context.Context, uid int64) ([]RolePermission, error)
+	// FindPermissions 某个角色下面的所有权限，没有记录说明角色不存在
+	FindPermissions(ctx context.Context, role string) ([]RolePermission, error)
+	InsertUserRole(ctx context.Context, uid int64, role string) error
+	DeleteUserRole(ctx context.Context, uid int64, role string) error
+	// DeleteUserRoleKeepLast 同 DeleteUserRole，但是 uid 是这个角色最后一个用户的时候不删，返回 ErrLastRoleMember
+	DeleteUserRoleKeepLast(ctx context.Context, uid int64, role string) error
+	// UpsertRolePermissions 已经存在的不会重复插入
+	UpsertRolePermissions(ctx context.Context, rps []RolePermission) error
+}
+
+type rbacDAO struct {
+	db *gorm.DB
+}
+
+func NewRBACDAO(db *gorm.DB) RBACDAO {
+	return &rbacDAO{
+		db: db,
+	}
+}
+
+func (d *rbacDAO) FindRolePermissions(ctx context.Context, uid int64) ([]RolePermission, error) {
+	var res []RolePermission
+	err := d.db.WithContext(ctx).
+		Table("role_permissions AS rp").
+		Select("rp.*").
+		Joins("JOIN user_roles AS ur ON ur.role = rp.role").
+		Where("ur.uid = ?", uid).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *rbacDAO) FindPermissions(ctx context.Context, role string) ([]RolePermission, error) {
+	var res []RolePermission
+	err := d.db.WithContext(ctx).Where("role = ?", role).Find(&res).Error
+	return res, err
+}
+
+func (d *rbacDAO) InsertUserRole(ctx context.Context, uid int64, role string) error {
+	now := time.Now().UnixMilli()
+	// 重复授予同一个角色不算错
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserRole{
+			Uid:   uid,
+			Role:  role,
+			Ctime: now,
+			Utime: now,
+		}).Error
+}
+
+func (d *rbacDAO) DeleteUserRole(ctx context.Context, uid int64, role string) error {
+	return d.db.WithContext(ctx).
+		Where("uid = ? AND role = ?", uid, role).
+		Delete(&UserRole{}).Error
+}
+
+func (d *rbacDAO) DeleteUserRoleKeepLast(ctx context.Context, uid int64, role string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var uids []int64
+		// 锁住这个角色下面所有的用户，两个管理员同时收回对方的时候不会都成功
+		err := tx.Model(&UserRole{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ?", role).Pluck("uid", &uids).Error
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(uids, uid) {
+			return nil
+		}
+		if len(uids) <= 1 {
+			return ErrLastRoleMember
+		}
+		return tx.Where("uid = ? AND role = ?", uid, role).Delete(&UserRole{}).Error
+	})
+}
+
+func (d *rbacDAO) UpsertRolePermissions(ctx context.Context, rps []RolePermission) error {
+	if len(rps) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range rps {
+		rps[i].Ctime = now
+		rps[i].Utime = now
+	}
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rps).Error
+}
+
+// UserRole 用户拥有哪些角色
+type UserRole struct {
+	Id    int64  `gorm:"primaryKey;autoIncrement"`
+	Uid   int64  `gorm:"uniqueIndex:uid_role"`
+	Role  string `gorm:"type:varchar(64);uniqueIndex:uid_role"`
+	Ctime int64
+	Utime int64
+}
+
+// RolePermission 角色拥有哪些权限
+type RolePermission struct {
+	Id         int64  `gorm:"primaryKey;autoIncrement"`
+	Role       string `gorm:"type:varchar(64);uniqueIndex:role_permission"`
+	Permission string `gorm:"type:varchar(128);uniqueIndex:role_permission"`
+	Ctime      int64
+	Utime      int64
+}
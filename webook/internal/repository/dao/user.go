@@ -5,21 +5,76 @@ import (
 	"database/sql"
 	"errors"
 	mysql2 "github.com/go-sql-driver/mysql"
+	"github.com/zmsocc/practice/webook/internal/repository/dao/articles"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
 	"time"
 )
 
 var (
-	ErrUserDuplicate = gorm.ErrDuplicatedKey
-	ErrUserNotFound  = gorm.ErrRecordNotFound
+	ErrUserDuplicate        = gorm.ErrDuplicatedKey
+	ErrUserNotFound         = gorm.ErrRecordNotFound
+	ErrRecoveryCodeNotFound = errors.New("恢复码不存在或已使用")
 )
 
 type UserDAO interface {
 	Insert(ctx context.Context, u User) error
-	FindByEmail(ctx context.Context, email string) (User, error)
+	// FindByEmail 邮箱是加密存储的，按照盲索引查。
+	// 加密上线之前的老数据在 FieldEncryptionJob 补上盲索引之前还是明文，盲索引为空的按明文 email 查。
+	// email 传空字符串就只按盲索引查
+	FindByEmail(ctx context.Context, emailIdx, email string) (User, error)
 	FindById(ctx context.Context, id int64) (User, error)
 	Update(ctx context.Context, u User) error
-	FindByPhone(ctx context.Context, phone string) (User, error)
+	// FindByPhone 同 FindByEmail
+	FindByPhone(ctx context.Context, phoneIdx, phone string) (User, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	UpdateTotpSecret(ctx context.Context, id int64, secret string) error
+	// EnableTotp 开启两步验证，同时替换掉原来的恢复码
+	EnableTotp(ctx context.Context, id int64, codes []UserRecoveryCode) error
+	DisableTotp(ctx context.Context, id int64) error
+	// ConsumeRecoveryCode 恢复码只能用一次
+	ConsumeRecoveryCode(ctx context.Context, uid int64, codeHash string) error
+	FindByOAuth(ctx context.Context, provider, openId string) (User, error)
+	// FindByUnionId 同一个开放平台下面的其它应用已经绑定过的用户
+	FindByUnionId(ctx context.Context, provider, unionId string) (User, error)
+	// InsertWithOAuth 第三方登录第一次进来，创建用户的同时绑定第三方账号。
+	// code 不为空的时候同 InsertWithInvite，在同一个事务里面用掉邀请码
+	InsertWithOAuth(ctx context.Context, u User, o UserOAuth, code string) error
+	// InsertOAuth 给已有的用户绑定第三方账号，已经绑定过了返回 ErrUserDuplicate
+	InsertOAuth(ctx context.Context, o UserOAuth) error
+	// InsertWithInvite 用掉一次邀请码并且创建用户，记录邀请关系，
+	// 邀请码不能用返回 ErrInviteCodeInvalid，用户冲突的时候邀请码不会被用掉
+	InsertWithInvite(ctx context.Context, u User, code string) error
+	// UpdatePhone phone 是密文，phoneIdx 是盲索引
+	UpdatePhone(ctx context.Context, id int64, phone, phoneIdx string) error
+	UpdateEmail(ctx context.Context, id int64, email, emailIdx string) error
+	UpdateAvatar(ctx context.Context, id int64, avatar, thumb string) error
+	UpdateStatus(ctx context.Context, id int64, status uint8, suspendedUntil int64) error
+	// Merge 把 secondary 的文章、点赞、收藏、阅读记录、关注关系、第三方账号都转移到 primary 上面，
+	// secondary 的手机号和邮箱在 primary 没有的时候也一并转移，个人访问令牌直接删掉，之后 secondary 就废弃了。
+	// 返回计数被修正过的资源和用户，调用方要清掉它们的缓存
+	Merge(ctx context.Context, primary, secondary int64) (MergeResult, error)
+
+	// ScheduleDeletion 申请注销，deleteAt 之后才会真正删除
+	ScheduleDeletion(ctx context.Context, id int64, deleteAt int64) error
+	// CancelDeletion 冷静期内撤销注销，没有待注销的申请返回 ErrUserNotFound
+	CancelDeletion(ctx context.Context, id int64) error
+	// FindDueDeletion 冷静期已经过了的用户
+	FindDueDeletion(ctx context.Context, now int64, limit int) ([]int64, error)
+	// Erase 匿名化用户，删掉登录凭证和各种绑定关系。
+	// 只有冷静期已经过了的用户才会被处理，不然返回 ErrUserNotFound
+	Erase(ctx context.Context, id int64, now int64) error
+
+	// FindStaleEncrypted id 大于 afterId，手机号、邮箱或者 TOTP 密钥不是用 prefix 开头的密钥加密的用户，
+	// 包括还没有加密的旧数据，按 id 升序
+	FindStaleEncrypted(ctx context.Context, prefix string, afterId int64, limit int) ([]User, error)
+	// UpdateEncrypted 替换重新加密之后的手机号、邮箱和 TOTP 密钥。old 是读出来的时候的值，
+	// 中间被别人改过了就不更新，返回 ErrUserNotFound
+	UpdateEncrypted(ctx context.Context, old User, u User) error
+	// FindUnindexedPhones id 大于 afterId，有手机号但是还没有盲索引的用户，按 id 升序
+	FindUnindexedPhones(ctx context.Context, afterId int64, limit int) ([]User, error)
 }
 
 type userDAO struct {
@@ -48,9 +103,13 @@ func (d *userDAO) Insert(ctx context.Context, u User) error {
 	return err
 }
 
-func (d *userDAO) FindByEmail(ctx context.Context, email string) (User, error) {
+func (d *userDAO) FindByEmail(ctx context.Context, emailIdx, email string) (User, error) {
 	var u User
-	err := d.db.WithContext(ctx).Where("email = ?", email).First(&u).Error
+	query := d.db.WithContext(ctx).Where("email_idx = ?", emailIdx)
+	if email != "" {
+		query = query.Or("email_idx IS NULL AND email = ?", email)
+	}
+	err := query.First(&u).Error
 	return u, err
 }
 
@@ -60,9 +119,13 @@ func (d *userDAO) FindById(ctx context.Context, id int64) (User, error) {
 	return u, err
 }
 
-func (d *userDAO) FindByPhone(ctx context.Context, phone string) (User, error) {
+func (d *userDAO) FindByPhone(ctx context.Context, phoneIdx, phone string) (User, error) {
 	var u User
-	err := d.db.WithContext(ctx).Where("phone = ?", phone).First(&u).Error
+	query := d.db.WithContext(ctx).Where("phone_idx = ?", phoneIdx)
+	if phone != "" {
+		query = query.Or("phone_idx IS NULL AND phone = ?", phone)
+	}
+	err := query.First(&u).Error
 	return u, err
 }
 
@@ -78,16 +141,708 @@ func (d *userDAO) Update(ctx context.Context, u User) error {
 		}).Error
 }
 
+func (d *userDAO) UpdatePassword(ctx context.Context, id int64, password string) error {
+	res := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password": password,
+			"utime":    time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *userDAO) UpdateTotpSecret(ctx context.Context, id int64, secret string) error {
+	return d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND totp_enabled = ?", id, false).
+		Updates(map[string]interface{}{
+			"totp_secret": secret,
+			"utime":       time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *userDAO) EnableTotp(ctx context.Context, id int64, codes []UserRecoveryCode) error {
+	now := time.Now().UnixMilli()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"totp_enabled": true,
+				"utime":        now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", id).Delete(&UserRecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range codes {
+			codes[i].Uid = id
+			codes[i].Ctime = now
+			codes[i].Utime = now
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (d *userDAO) DisableTotp(ctx context.Context, id int64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"totp_enabled": false,
+				"totp_secret":  sql.NullString{},
+				"utime":        time.Now().UnixMilli(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", id).Delete(&UserRecoveryCode{}).Error
+	})
+}
+
+func (d *userDAO) ConsumeRecoveryCode(ctx context.Context, uid int64, codeHash string) error {
+	res := d.db.WithContext(ctx).Model(&UserRecoveryCode{}).
+		Where("uid = ? AND code_hash = ? AND used = ?", uid, codeHash, false).
+		Updates(map[string]interface{}{
+			"used":  true,
+			"utime": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecoveryCodeNotFound
+	}
+	return nil
+}
+
+func (d *userDAO) FindByOAuth(ctx context.Context, provider, openId string) (User, error) {
+	var o UserOAuth
+	err := d.db.WithContext(ctx).
+		Where("provider = ? AND open_id = ?", provider, openId).
+		First(&o).Error
+	if err != nil {
+		return User{}, err
+	}
+	return d.FindById(ctx, o.Uid)
+}
+
+func (d *userDAO) FindByUnionId(ctx context.Context, provider, unionId string) (User, error) {
+	var o UserOAuth
+	err := d.db.WithContext(ctx).
+		Where("provider = ? AND union_id = ?", provider, unionId).
+		First(&o).Error
+	if err != nil {
+		return User{}, err
+	}
+	return d.FindById(ctx, o.Uid)
+}
+
+func (d *userDAO) InsertOAuth(ctx context.Context, o UserOAuth) error {
+	now := time.Now().UnixMilli()
+	o.Ctime = now
+	o.Utime = now
+	err := d.db.WithContext(ctx).Create(&o).Error
+	var mysqlErr *mysql2.MySQLError
+	if errors.As(err, &mysqlErr) {
+		const uniqueIndexErrNo uint16 = 1062
+		if mysqlErr.Number == uniqueIndexErrNo {
+			return ErrUserDuplicate
+		}
+	}
+	return err
+}
+
+func (d *userDAO) InsertWithOAuth(ctx context.Context, u User, o UserOAuth, code string) error {
+	now := time.Now().UnixMilli()
+	u.Ctime = now
+	u.Utime = now
+	o.Ctime = now
+	o.Utime = now
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inviter int64
+		if code != "" {
+			var err error
+			inviter, err = consumeInviteCode(tx, code, now)
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Create(&u).Error
+		if err != nil {
+			return err
+		}
+		o.Uid = u.Id
+		if err = tx.Create(&o).Error; err != nil {
+			return err
+		}
+		if code == "" {
+			return nil
+		}
+		return tx.Create(&Invitation{
+			Inviter: inviter,
+			Invitee: u.Id,
+			Code:    code,
+			Ctime:   now,
+		}).Error
+	})
+	var mysqlErr *mysql2.MySQLError
+	if errors.As(err, &mysqlErr) {
+		const uniqueIndexErrNo uint16 = 1062
+		if mysqlErr.Number == uniqueIndexErrNo {
+			return ErrUserDuplicate
+		}
+	}
+	return err
+}
+
+func (d *userDAO) InsertWithInvite(ctx context.Context, u User, code string) error {
+	now := time.Now().UnixMilli()
+	u.Ctime = now
+	u.Utime = now
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		inviter, err := consumeInviteCode(tx, code, now)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&u).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&Invitation{
+			Inviter: inviter,
+			Invitee: u.Id,
+			Code:    code,
+			Ctime:   now,
+		}).Error
+	})
+	var mysqlErr *mysql2.MySQLError
+	if errors.As(err, &mysqlErr) {
+		const uniqueIndexErrNo uint16 = 1062
+		if mysqlErr.Number == uniqueIndexErrNo {
+			return ErrUserDuplicate
+		}
+	}
+	return err
+}
+
+func (d *userDAO) UpdatePhone(ctx context.Context, id int64, phone, phoneIdx string) error {
+	return d.updateIdentity(ctx, id, "phone", phone, phoneIdx)
+}
+
+func (d *userDAO) UpdateEmail(ctx context.Context, id int64, email, emailIdx string) error {
+	return d.updateIdentity(ctx, id, "email", email, emailIdx)
+}
+
+func (d *userDAO) UpdateAvatar(ctx context.Context, id int64, avatar, thumb string) error {
+	return d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"avatar":       avatar,
+			"avatar_thumb": thumb,
+			"utime":        time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *userDAO) UpdateStatus(ctx context.Context, id int64, status uint8, suspendedUntil int64) error {
+	res := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"suspended_until": suspendedUntil,
+			"utime":           time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *userDAO) updateIdentity(ctx context.Context, id int64, col, val, idx string) error {
+	err := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			col:          val,
+			col + "_idx": idx,
+			"utime":      time.Now().UnixMilli(),
+		}).Error
+	var mysqlErr *mysql2.MySQLError
+	if errors.As(err, &mysqlErr) {
+		const uniqueIndexErrNo uint16 = 1062
+		if mysqlErr.Number == uniqueIndexErrNo {
+			return ErrUserDuplicate
+		}
+	}
+	return err
+}
+
+func (d *userDAO) Merge(ctx context.Context, primary, secondary int64) (MergeResult, error) {
+	now := time.Now().UnixMilli()
+	var res MergeResult
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p, s User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", primary).First(&p).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND merged_into = ?", secondary, 0).First(&s).Error
+		if err != nil {
+			return err
+		}
+		// 文章，制作库和线上库都要改
+		err = tx.Model(&articles.Article{}).Where("author_id = ?", secondary).
+			Updates(map[string]any{"author_id": primary, "utime": now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&articles.PublishedArticle{}).Where("author_id = ?", secondary).
+			Updates(map[string]any{"author_id": primary, "utime": now}).Error
+		if err != nil {
+			return err
+		}
+		likes, err := mergeUserBiz(tx, &UserLikeBiz{}, "like_cnt", primary, secondary, now)
+		if err != nil {
+			return err
+		}
+		collects, err := mergeUserBiz(tx, &UserCollectionBiz{}, "collect_cnt", primary, secondary, now)
+		if err != nil {
+			return err
+		}
+		res.Biz = append(likes, collects...)
+		err = mergeReadHistory(tx, primary, secondary)
+		if err != nil {
+			return err
+		}
+		res.FollowUids, err = mergeFollows(tx, primary, secondary, now)
+		if err != nil {
+			return err
+		}
+		// 令牌是 secondary 自己签发的，权限范围也是按它来的，不能转给 primary
+		err = tx.Where("uid = ?", secondary).Delete(&AccessToken{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&UserOAuth{}).Where("uid = ?", secondary).
+			Updates(map[string]any{"uid": primary, "utime": now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", secondary).Delete(&UserRecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		// 先把 secondary 的手机号和邮箱清掉，不然唯一索引会冲突
+		err = tx.Model(&User{}).Where("id = ?", secondary).
+			Updates(map[string]any{
+				"email":       sql.NullString{},
+				"email_idx":   sql.NullString{},
+				"phone":       sql.NullString{},
+				"phone_idx":   sql.NullString{},
+				"merged_into": primary,
+				"utime":       now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		updates := map[string]any{"utime": now}
+		if !p.Email.Valid && s.Email.Valid {
+			updates["email"] = s.Email
+			updates["email_idx"] = s.EmailIdx
+			// 邮箱登录要用到密码
+			if p.Password == "" {
+				updates["password"] = s.Password
+			}
+		}
+		if !p.Phone.Valid && s.Phone.Valid {
+			updates["phone"] = s.Phone
+			updates["phone_idx"] = s.PhoneIdx
+		}
+		return tx.Model(&User{}).Where("id = ?", primary).Updates(updates).Error
+	})
+	if err != nil {
+		return MergeResult{}, err
+	}
+	return res, nil
+}
+
+func (d *userDAO) ScheduleDeletion(ctx context.Context, id int64, deleteAt int64) error {
+	res := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND erased_at = ?", id, 0).
+		Updates(map[string]any{
+			"delete_at": deleteAt,
+			"utime":     time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *userDAO) CancelDeletion(ctx context.Context, id int64) error {
+	res := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND delete_at > ?", id, 0).
+		Updates(map[string]any{
+			"delete_at": 0,
+			"utime":     time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *userDAO) FindDueDeletion(ctx context.Context, now int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := d.db.WithContext(ctx).Model(&User{}).
+		Where("delete_at > ? AND delete_at <= ?", 0, now).
+		Order("delete_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (d *userDAO) Erase(ctx context.Context, id int64, now int64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 带上 delete_at 的条件，用户刚好撤销了，或者别的实例已经处理了，这里就不会更新
+		res := tx.Model(&User{}).
+			Where("id = ? AND delete_at > ? AND delete_at <= ?", id, 0, now).
+			Updates(map[string]any{
+				"email":        sql.NullString{},
+				"email_idx":    sql.NullString{},
+				"phone":        sql.NullString{},
+				"phone_idx":    sql.NullString{},
+				"password":     "",
+				"nickname":     sql.NullString{String: "已注销用户", Valid: true},
+				"birthday":     sql.NullInt64{},
+				"about_me":     sql.NullString{},
+				"avatar":       "",
+				"avatar_thumb": "",
+				"totp_secret":  sql.NullString{},
+				"totp_enabled": false,
+				"delete_at":    0,
+				"erased_at":    now,
+				"utime":        now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		for _, model := range []any{&UserRecoveryCode{}, &UserOAuth{}, &UserRole{}, &AccessToken{}} {
+			err := tx.Where("uid = ?", id).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *userDAO) FindStaleEncrypted(ctx context.Context, prefix string, afterId int64, limit int) ([]User, error) {
+	var res []User
+	pattern := escapeLike(prefix) + "%"
+	err := d.db.WithContext(ctx).
+		Where("id > ?", afterId).
+		Where("(email IS NOT NULL AND email NOT LIKE ?) OR (phone IS NOT NULL AND phone NOT LIKE ?) "+
+			"OR (totp_secret IS NOT NULL AND totp_secret NOT LIKE ?)",
+			pattern, pattern, pattern).
+		Order("id").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *userDAO) FindUnindexedPhones(ctx context.Context, afterId int64, limit int) ([]User, error) {
+	var res []User
+	err := d.db.WithContext(ctx).
+		Where("id > ? AND phone_idx IS NULL AND phone IS NOT NULL AND phone <> ?", afterId, "").
+		Order("id").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *userDAO) UpdateEncrypted(ctx context.Context, old User, u User) error {
+	// <=> 是 MySQL 里面 NULL 也能比较的等于
+	res := d.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND email <=> ? AND phone <=> ? AND totp_secret <=> ?",
+			old.Id, old.Email, old.Phone, old.TotpSecret).
+		Updates(map[string]any{
+			"email":       u.Email,
+			"email_idx":   u.EmailIdx,
+			"phone":       u.Phone,
+			"phone_idx":   u.PhoneIdx,
+			"totp_secret": u.TotpSecret,
+			"utime":       time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// escapeLike 转义 LIKE 里面的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// userBizRow 点赞和收藏表共有的字段
+type userBizRow struct {
+	Id     int64
+	Biz    string
+	BizId  int64
+	Status uint8
+}
+
+// MergeResult 合并账号的时候计数被修正过的东西
+type MergeResult struct {
+	// 点赞、收藏计数变了的资源
+	Biz []BizKey
+	// 关注数或者粉丝数变了的用户
+	FollowUids []int64
+}
+
+// BizKey 某一个资源，比如 article 的 1 号
+type BizKey struct {
+	Biz   string
+	BizId int64
+}
+
+// mergeUserBiz 转移点赞或者收藏记录。两个账号都点过的，只保留一条，计数也要减掉多出来的那一次，
+// 返回计数被减掉过的资源
+func mergeUserBiz(tx *gorm.DB, model any, cntCol string, primary, secondary, now int64) ([]BizKey, error) {
+	var rows []userBizRow
+	err := tx.Model(model).Where("uid = ?", secondary).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	var changed []BizKey
+	for _, row := range rows {
+		var existing userBizRow
+		err = tx.Model(model).
+			Where("biz = ? AND biz_id = ? AND uid = ?", row.Biz, row.BizId, primary).
+			First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = tx.Model(model).Where("id = ?", row.Id).
+				Updates(map[string]any{"uid": primary, "utime": now}).Error
+		case err == nil:
+			var decr bool
+			decr, err = mergeConflictBiz(tx, model, cntCol, row, existing, now)
+			if decr {
+				changed = append(changed, BizKey{Biz: row.Biz, BizId: row.BizId})
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// mergeConflictBiz 两个账号对同一个资源都有记录，decr 为 true 代表计数减掉了一次
+func mergeConflictBiz(tx *gorm.DB, model any, cntCol string, s, p userBizRow, now int64) (decr bool, err error) {
+	switch {
+	case s.Status == 1 && p.Status == 1:
+		// 同一个人点了两次，计数多算了一次
+		err = tx.Model(&Interactive{}).Where("biz = ? AND biz_id = ?", s.Biz, s.BizId).
+			Updates(map[string]any{
+				cntCol:  gorm.Expr(cntCol+" - ?", 1),
+				"utime": now,
+			}).Error
+		decr = true
+	case s.Status == 1:
+		err = tx.Model(model).Where("id = ?", p.Id).
+			Updates(map[string]any{"status": 1, "utime": now}).Error
+	}
+	if err != nil {
+		return false, err
+	}
+	return decr, tx.Where("id = ?", s.Id).Delete(model).Error
+}
+
+// mergeReadHistory 转移阅读记录，两个账号都读过的保留一条，最后阅读时间取晚的那个
+func mergeReadHistory(tx *gorm.DB, primary, secondary int64) error {
+	var rows []UserReadHistory
+	err := tx.Where("uid = ?", secondary).Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		var existing UserReadHistory
+		err = tx.Where("uid = ? AND biz = ? AND biz_id = ?", primary, row.Biz, row.BizId).
+			First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = tx.Model(&UserReadHistory{}).Where("id = ?", row.Id).
+				Update("uid", primary).Error
+		case err == nil:
+			if row.Utime > existing.Utime {
+				err = tx.Model(&UserReadHistory{}).Where("id = ?", existing.Id).
+					Update("utime", row.Utime).Error
+			}
+			if err == nil {
+				err = tx.Where("id = ?", row.Id).Delete(&UserReadHistory{}).Error
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeFollows 把 secondary 关注的人和粉丝转给 primary。primary 已经有的关系直接删掉，
+// 两个人之间互相关注的变成了自己关注自己，也删掉。最后按关系表重新算受影响的用户的计数
+func mergeFollows(tx *gorm.DB, primary, secondary, now int64) ([]int64, error) {
+	var rows []FollowRelation
+	err := tx.Where("follower = ? OR followee = ?", secondary, secondary).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	uids := []int64{primary, secondary}
+	for _, row := range rows {
+		follower, followee := row.Follower, row.Followee
+		if follower == secondary {
+			follower = primary
+		}
+		if followee == secondary {
+			followee = primary
+		}
+		var exists bool
+		if follower != followee {
+			var cnt int64
+			err = tx.Model(&FollowRelation{}).
+				Where("follower = ? AND followee = ?", follower, followee).Count(&cnt).Error
+			if err != nil {
+				return nil, err
+			}
+			exists = cnt > 0
+		}
+		if follower == followee || exists {
+			err = tx.Where("id = ?", row.Id).Delete(&FollowRelation{}).Error
+		} else {
+			err = tx.Model(&FollowRelation{}).Where("id = ?", row.Id).
+				Updates(map[string]any{"follower": follower, "followee": followee}).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 对方的计数只有在关系被删掉的时候才会变，这里简单起见都重新算
+		if row.Follower == secondary {
+			uids = append(uids, row.Followee)
+		} else {
+			uids = append(uids, row.Follower)
+		}
+	}
+	slices.Sort(uids)
+	uids = slices.Compact(uids)
+	for _, uid := range uids {
+		if err = recountFollowStatics(tx, uid, now); err != nil {
+			return nil, err
+		}
+	}
+	return uids, nil
+}
+
+func recountFollowStatics(tx *gorm.DB, uid, now int64) error {
+	var followers, followees int64
+	err := tx.Model(&FollowRelation{}).Where("followee = ?", uid).Count(&followers).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&FollowRelation{}).Where("follower = ?", uid).Count(&followees).Error
+	if err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"followers": followers,
+			"followees": followees,
+			"utime":     now,
+		}),
+	}).Create(&FollowStatics{
+		Uid:       uid,
+		Followers: followers,
+		Followees: followees,
+		Utime:     now,
+		Ctime:     now,
+	}).Error
+}
+
 type User struct {
-	Id       int64          `gorm:"primaryKey;autoIncrement"`
-	Email    sql.NullString `gorm:"unique"`
+	Id int64 `gorm:"primaryKey;autoIncrement"`
+	// 邮箱和手机号都是 AES-GCM 加密之后的密文，查询和唯一索引靠 HMAC 算出来的盲索引
+	Email    sql.NullString `gorm:"type:varchar(512)"`
+	EmailIdx sql.NullString `gorm:"type:varchar(64);unique"`
 	Password string
-	Phone    sql.NullString
+	Phone    sql.NullString `gorm:"type:varchar(512)"`
+	PhoneIdx sql.NullString `gorm:"type:varchar(64);unique"`
 	Birthday sql.NullInt64
 	Nickname sql.NullString
 	AboutMe  sql.NullString `gorm:"type:varchar(1024)"`
+	// 头像的 URL，AvatarThumb 是小图
+	Avatar      string `gorm:"type:varchar(512)"`
+	AvatarThumb string `gorm:"type:varchar(512)"`
+	// TOTP 两步验证的密钥，加密存储，TotpEnabled 为 true 才算真正开启
+	TotpSecret  sql.NullString `gorm:"type:varchar(255)"`
+	TotpEnabled bool
+	// 被合并到了哪个账号，0 代表没有被合并
+	MergedInto int64
+	// 申请注销之后，到了这个时间就真正删除，0 代表没有申请
+	DeleteAt int64 `gorm:"index"`
+	// 0 正常，1 禁言，2 暂停到 SuspendedUntil，3 永久封禁
+	Status         uint8
+	SuspendedUntil int64
+	// 已经注销的时间，注销之后只保留 id 和一个匿名的昵称
+	ErasedAt int64
 	// 创建时间
 	Ctime int64
 	// 更新时间
 	Utime int64
 }
+
+// UserRecoveryCode 两步验证的恢复码，只存哈希
+type UserRecoveryCode struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Uid      int64  `gorm:"index"`
+	CodeHash string `gorm:"type:varchar(64)"`
+	Used     bool
+	Ctime    int64
+	Utime    int64
+}
+
+// UserOAuth 第三方账号和用户的绑定关系，一个用户可以绑定多个平台
+type UserOAuth struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Uid      int64  `gorm:"index"`
+	Provider string `gorm:"type:varchar(32);uniqueIndex:provider_open_id"`
+	OpenId   string `gorm:"type:varchar(128);uniqueIndex:provider_open_id"`
+	UnionId  string `gorm:"type:varchar(128);index"`
+	Ctime    int64
+	Utime    int64
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+type FollowRepository interface {
+	// Follow 关注，重复关注不会报错
+	Follow(ctx context.Context, follower, followee int64) error
+	// Unfollow 取消关注，没关注过也不会报错
+	Unfollow(ctx context.Context, follower, followee int64) error
+	Followed(ctx context.Context, follower, followee int64) (bool, error)
+	Followees(ctx context.Context, follower, cursor int64, limit int) ([]domain.FollowRelation, error)
+	Followers(ctx context.Context, followee, cursor int64, limit int) ([]domain.FollowRelation, error)
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+}
+
+type followRepository struct {
+	dao   dao.FollowDAO
+	cache cache.FollowCache
+	l     logger.Logger
+}
+
+func NewFollowRepository(dao dao.FollowDAO, cache cache.FollowCache, l logger.Logger) FollowRepository {
+	return &followRepository{
+		dao:   dao,
+		cache: cache,
+		l:     l,
+	}
+}
+
+func (r *followRepository) Follow(ctx context.Context, follower, followee int64) error {
+	err := r.dao.Follow(ctx, follower, followee)
+	if errors.Is(err, dao.ErrFollowExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if er := r.cache.IncrFolloweeCntIfPresent(ctx, follower); er != nil {
+		r.l.Error("更新关注数缓存失败", logger.Int64("uid", follower), logger.Error(er))
+	}
+	if er := r.cache.IncrFollowerCntIfPresent(ctx, followee); er != nil {
+		r.l.Error("更新粉丝数缓存失败", logger.Int64("uid", followee), logger.Error(er))
+	}
+	return nil
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, follower, followee int64) error {
+	err := r.dao.Unfollow(ctx, follower, followee)
+	if errors.Is(err, dao.ErrFollowNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if er := r.cache.DecrFolloweeCntIfPresent(ctx, follower); er != nil {
+		r.l.Error("更新关注数缓存失败", logger.Int64("uid", follower), logger.Error(er))
+	}
+	if er := r.cache.DecrFollowerCntIfPresent(ctx, followee); er != nil {
+		r.l.Error("更新粉丝数缓存失败", logger.Int64("uid", followee), logger.Error(er))
+	}
+	return nil
+}
+
+func (r *followRepository) Followed(ctx context.Context, follower, followee int64) (bool, error) {
+	_, err := r.dao.FindRelation(ctx, follower, followee)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, dao.ErrFollowNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (r *followRepository) Followees(ctx context.Context, follower, cursor int64, limit int) ([]domain.FollowRelation, error) {
+	res, err := r.dao.FindFollowees(ctx, follower, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.FollowRelation, domain.FollowRelation](res, r.toDomain), nil
+}
+
+func (r *followRepository) Followers(ctx context.Context, followee, cursor int64, limit int) ([]domain.FollowRelation, error) {
+	res, err := r.dao.FindFollowers(ctx, followee, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.FollowRelation, domain.FollowRelation](res, r.toDomain), nil
+}
+
+func (r *followRepository) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	s, err := r.cache.GetStatics(ctx, uid)
+	if err == nil {
+		return s, nil
+	}
+	daoS, err := r.dao.GetStatics(ctx, uid)
+	switch {
+	case err == nil:
+		s = domain.FollowStatics{
+			Uid:       uid,
+			Followers: daoS.Followers,
+			Followees: daoS.Followees,
+		}
+	case errors.Is(err, dao.ErrFollowNotFound):
+		// 没人关注，也没关注别人
+		s = domain.FollowStatics{Uid: uid}
+	default:
+		return domain.FollowStatics{}, err
+	}
+	go func() {
+		er := r.cache.SetStatics(context.Background(), s)
+		if er != nil {
+			r.l.Error("回写关注计数缓存失败", logger.Int64("uid", uid), logger.Error(er))
+		}
+	}()
+	return s, nil
+}
+
+func (r *followRepository) toDomain(idx int, src dao.FollowRelation) domain.FollowRelation {
+	return domain.FollowRelation{
+		Id:       src.Id,
+		Follower: src.Follower,
+		Followee: src.Followee,
+		Ctime:    time.UnixMilli(src.Ctime),
+	}
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	cachemocks "github.com/zmsocc/practice/webook/internal/repository/cache/mocks"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	daomocks "github.com/zmsocc/practice/webook/internal/repository/dao/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestFollowRepository_Follow(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache)
+
+		wantErr error
+	}{
+		{
+			name: "关注之后两边的计数都加一",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				d.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(nil)
+				c.EXPECT().IncrFolloweeCntIfPresent(gomock.Any(), int64(1)).Return(nil)
+				c.EXPECT().IncrFollowerCntIfPresent(gomock.Any(), int64(2)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "重复关注不加计数",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(dao.ErrFollowExists)
+				return d, cachemocks.NewMockFollowCache(ctrl)
+			},
+		},
+		{
+			name: "更新缓存失败不影响关注",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				d.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(nil)
+				c.EXPECT().IncrFolloweeCntIfPresent(gomock.Any(), int64(1)).Return(errors.New("mock error"))
+				c.EXPECT().IncrFollowerCntIfPresent(gomock.Any(), int64(2)).Return(errors.New("mock error"))
+				return d, c
+			},
+		},
+		{
+			name: "关注失败",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock error"))
+				return d, cachemocks.NewMockFollowCache(ctrl)
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewFollowRepository(d, c, logger.NewNopLogger())
+			err := repo.Follow(context.Background(), 1, 2)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestFollowRepository_Unfollow(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache)
+
+		wantErr error
+	}{
+		{
+			name: "取消关注之后两边的计数都减一",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				d.EXPECT().Unfollow(gomock.Any(), int64(1), int64(2)).Return(nil)
+				c.EXPECT().DecrFolloweeCntIfPresent(gomock.Any(), int64(1)).Return(nil)
+				c.EXPECT().DecrFollowerCntIfPresent(gomock.Any(), int64(2)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "没关注过不减计数",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().Unfollow(gomock.Any(), int64(1), int64(2)).Return(dao.ErrFollowNotFound)
+				return d, cachemocks.NewMockFollowCache(ctrl)
+			},
+		},
+		{
+			name: "取消关注失败",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().Unfollow(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock error"))
+				return d, cachemocks.NewMockFollowCache(ctrl)
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewFollowRepository(d, c, logger.NewNopLogger())
+			err := repo.Unfollow(context.Background(), 1, 2)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestFollowRepository_GetStatics(t *testing.T) {
+	testCases := []struct {
+		name string
+		// 回写缓存是异步的，写完了关闭 done
+		mock func(ctrl *gomock.Controller, done chan struct{}) (dao.FollowDAO, cache.FollowCache)
+
+		wantStatics   domain.FollowStatics
+		wantErr       error
+		wantWriteBack bool
+	}{
+		{
+			name: "缓存命中",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.FollowDAO, cache.FollowCache) {
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{Uid: 1, Followers: 10, Followees: 3}, nil)
+				return daomocks.NewMockFollowDAO(ctrl), c
+			},
+			wantStatics: domain.FollowStatics{Uid: 1, Followers: 10, Followees: 3},
+		},
+		{
+			name: "缓存没有，查数据库再回写",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(dao.FollowStatics{Uid: 1, Followers: 10, Followees: 3}, nil)
+				c.EXPECT().SetStatics(gomock.Any(), domain.FollowStatics{Uid: 1, Followers: 10, Followees: 3}).
+					DoAndReturn(func(ctx context.Context, s domain.FollowStatics) error {
+						close(done)
+						return nil
+					})
+				return d, c
+			},
+			wantStatics:   domain.FollowStatics{Uid: 1, Followers: 10, Followees: 3},
+			wantWriteBack: true,
+		},
+		{
+			name: "数据库里面也没有，计数都是 0",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(dao.FollowStatics{}, dao.ErrFollowNotFound)
+				c.EXPECT().SetStatics(gomock.Any(), domain.FollowStatics{Uid: 1}).
+					DoAndReturn(func(ctx context.Context, s domain.FollowStatics) error {
+						close(done)
+						return nil
+					})
+				return d, c
+			},
+			wantStatics:   domain.FollowStatics{Uid: 1},
+			wantWriteBack: true,
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.FollowDAO, cache.FollowCache) {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(dao.FollowStatics{}, errors.New("mock error"))
+				return d, c
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			done := make(chan struct{})
+			d, c := tc.mock(ctrl, done)
+			repo := NewFollowRepository(d, c, logger.NewNopLogger())
+			s, err := repo.GetStatics(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantStatics, s)
+			if !tc.wantWriteBack {
+				return
+			}
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("没有回写缓存")
+			}
+		})
+	}
+}
+
+func TestFollowRepository_Followees(t *testing.T) {
+	ctime := time.UnixMilli(time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local).UnixMilli())
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) dao.FollowDAO
+		cursor int64
+
+		wantRes []domain.FollowRelation
+		wantErr error
+	}{
+		{
+			name: "第一页",
+			mock: func(ctrl *gomock.Controller) dao.FollowDAO {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().FindFollowees(gomock.Any(), int64(1), int64(0), 2).Return([]dao.FollowRelation{
+					{Id: 9, Follower: 1, Followee: 3, Ctime: ctime.UnixMilli()},
+					{Id: 7, Follower: 1, Followee: 2, Ctime: ctime.UnixMilli()},
+				}, nil)
+				return d
+			},
+			wantRes: []domain.FollowRelation{
+				{Id: 9, Follower: 1, Followee: 3, Ctime: ctime},
+				{Id: 7, Follower: 1, Followee: 2, Ctime: ctime},
+			},
+		},
+		{
+			name: "游标原样传下去",
+			mock: func(ctrl *gomock.Controller) dao.FollowDAO {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().FindFollowees(gomock.Any(), int64(1), int64(7), 2).Return([]dao.FollowRelation{}, nil)
+				return d
+			},
+			cursor:  7,
+			wantRes: []domain.FollowRelation{},
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) dao.FollowDAO {
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().FindFollowees(gomock.Any(), int64(1), int64(0), 2).Return(nil, errors.New("mock error"))
+				return d
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := NewFollowRepository(tc.mock(ctrl), cachemocks.NewMockFollowCache(ctrl), logger.NewNopLogger())
+			res, err := repo.Followees(context.Background(), 1, tc.cursor, 2)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"errors"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/repository/cache"
 	"github.com/zmsocc/practice/webook/internal/repository/dao"
 	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
 )
 
 type InteractiveRepository interface {
@@ -19,7 +21,14 @@ type InteractiveRepository interface {
 	Liked(ctx context.Context, biz string, bizId, uid int64) (bool, error)
 	Collected(ctx context.Context, biz string, bizId, uid int64) (bool, error)
 	Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error)
+	// Sum 汇总一批资源的计数，直接查数据库
+	Sum(ctx context.Context, biz string, bizIds []int64) (domain.Interactive, error)
 	AddRecord(ctx context.Context, aid int64, uid int64) error
+	Likes(ctx context.Context, uid int64) ([]domain.BizRecord, error)
+	Collections(ctx context.Context, uid int64) ([]domain.BizRecord, error)
+	ReadHistory(ctx context.Context, uid int64) ([]domain.BizRecord, error)
+	// DeleteByUid 删除用户所有的点赞、收藏和阅读记录
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 type interactiveRepository struct {
@@ -38,8 +47,58 @@ func NewInteractiveRepository(dao dao.InteractiveDAO, cache cache.InteractiveCac
 }
 
 func (i *interactiveRepository) AddRecord(ctx context.Context, aid int64, uid int64) error {
-	//TODO implement me
-	panic("implement me")
+	return i.dao.InsertReadHistory(ctx, dao.UserReadHistory{
+		Uid:   uid,
+		Biz:   "article",
+		BizId: aid,
+	})
+}
+
+func (i *interactiveRepository) Likes(ctx context.Context, uid int64) ([]domain.BizRecord, error) {
+	likes, err := i.dao.FindLikesByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserLikeBiz, domain.BizRecord](likes, func(idx int, src dao.UserLikeBiz) domain.BizRecord {
+		return i.toRecord(src.Biz, src.BizId, src.Ctime, src.Utime)
+	}), nil
+}
+
+func (i *interactiveRepository) Collections(ctx context.Context, uid int64) ([]domain.BizRecord, error) {
+	cbs, err := i.dao.FindCollectionsByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserCollectionBiz, domain.BizRecord](cbs, func(idx int, src dao.UserCollectionBiz) domain.BizRecord {
+		return i.toRecord(src.Biz, src.BizId, src.Ctime, src.Utime)
+	}), nil
+}
+
+func (i *interactiveRepository) ReadHistory(ctx context.Context, uid int64) ([]domain.BizRecord, error) {
+	hs, err := i.dao.FindReadHistoryByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserReadHistory, domain.BizRecord](hs, func(idx int, src dao.UserReadHistory) domain.BizRecord {
+		return i.toRecord(src.Biz, src.BizId, src.Ctime, src.Utime)
+	}), nil
+}
+
+func (i *interactiveRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	changed, err := i.dao.DeleteByUid(ctx, uid)
+	if err != nil {
+		return err
+	}
+	// 计数变了，缓存直接删掉，下次读的时候再从数据库加载
+	for _, intr := range changed {
+		if er := i.cache.Del(ctx, intr.Biz, intr.BizId); er != nil {
+			i.l.Error("删除计数缓存失败",
+				logger.String("biz", intr.Biz),
+				logger.Int64("bizId", intr.BizId),
+				logger.Error(er))
+		}
+	}
+	return nil
 }
 
 func (i *interactiveRepository) IncrReadCnt(ctx context.Context, biz string, bizId int64) error {
@@ -147,6 +206,23 @@ func (i *interactiveRepository) Get(ctx context.Context, biz string, bizId int64
 	return intr, nil
 }
 
+func (i *interactiveRepository) Sum(ctx context.Context, biz string, bizIds []int64) (domain.Interactive, error) {
+	res, err := i.dao.Sum(ctx, biz, bizIds)
+	if err != nil {
+		return domain.Interactive{}, err
+	}
+	return i.toDomain(res), nil
+}
+
+func (i *interactiveRepository) toRecord(biz string, bizId int64, ctime, utime int64) domain.BizRecord {
+	return domain.BizRecord{
+		Biz:   biz,
+		BizId: bizId,
+		Ctime: time.UnixMilli(ctime),
+		Utime: time.UnixMilli(utime),
+	}
+}
+
 func (i *interactiveRepository) toDomain(dao dao.Interactive) domain.Interactive {
 	return domain.Interactive{
 		ReadCnt:    dao.ReadCnt,
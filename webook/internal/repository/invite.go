@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"time"
+)
+
+var ErrInviteCodeInvalid = dao.ErrInviteCodeInvalid
+
+type InviteRepository interface {
+	Create(ctx context.Context, c domain.InviteCode) error
+	FindByInviter(ctx context.Context, inviter int64, offset, limit int) ([]domain.InviteCode, error)
+	CountUsable(ctx context.Context, inviter int64, now time.Time) (int64, error)
+	Usable(ctx context.Context, code string, now time.Time) (bool, error)
+	FindInvitations(ctx context.Context, inviter int64, offset, limit int) ([]domain.Invitation, error)
+}
+
+type inviteRepository struct {
+	dao dao.InviteDAO
+}
+
+func NewInviteRepository(dao dao.InviteDAO) InviteRepository {
+	return &inviteRepository{
+		dao: dao,
+	}
+}
+
+func (r *inviteRepository) Create(ctx context.Context, c domain.InviteCode) error {
+	entity := dao.InviteCode{
+		Code:    c.Code,
+		Inviter: c.Inviter,
+		MaxUses: c.MaxUses,
+	}
+	if !c.ExpireAt.IsZero() {
+		entity.ExpireAt = c.ExpireAt.UnixMilli()
+	}
+	return r.dao.Insert(ctx, entity)
+}
+
+func (r *inviteRepository) FindByInviter(ctx context.Context, inviter int64, offset, limit int) ([]domain.InviteCode, error) {
+	res, err := r.dao.FindByInviter(ctx, inviter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.InviteCode, domain.InviteCode](res, func(idx int, src dao.InviteCode) domain.InviteCode {
+		c := domain.InviteCode{
+			Id:      src.Id,
+			Code:    src.Code,
+			Inviter: src.Inviter,
+			MaxUses: src.MaxUses,
+			Used:    src.Used,
+			Ctime:   time.UnixMilli(src.Ctime),
+		}
+		if src.ExpireAt > 0 {
+			c.ExpireAt = time.UnixMilli(src.ExpireAt)
+		}
+		return c
+	}), nil
+}
+
+func (r *inviteRepository) CountUsable(ctx context.Context, inviter int64, now time.Time) (int64, error) {
+	return r.dao.CountUsable(ctx, inviter, now.UnixMilli())
+}
+
+func (r *inviteRepository) Usable(ctx context.Context, code string, now time.Time) (bool, error) {
+	return r.dao.Usable(ctx, code, now.UnixMilli())
+}
+
+func (r *inviteRepository) FindInvitations(ctx context.Context, inviter int64, offset, limit int) ([]domain.Invitation, error) {
+	res, err := r.dao.FindInvitations(ctx, inviter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.Invitation, domain.Invitation](res, func(idx int, src dao.Invitation) domain.Invitation {
+		return domain.Invitation{
+			Inviter: src.Inviter,
+			Invitee: src.Invitee,
+			Code:    src.Code,
+			Ctime:   time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	"time"
+)
+
+type LoginAttemptPolicy = cache.LoginAttemptPolicy
+
+type LoginAttemptRepository interface {
+	Fail(ctx context.Context, key string, policy LoginAttemptPolicy) (time.Duration, error)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, keys ...string) error
+}
+
+type loginAttemptRepository struct {
+	cache cache.LoginAttemptCache
+}
+
+func NewLoginAttemptRepository(c cache.LoginAttemptCache) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		cache: c,
+	}
+}
+
+func (repo *loginAttemptRepository) Fail(ctx context.Context, key string, policy LoginAttemptPolicy) (time.Duration, error) {
+	return repo.cache.Fail(ctx, key, policy)
+}
+
+func (repo *loginAttemptRepository) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	return repo.cache.LockedFor(ctx, key)
+}
+
+func (repo *loginAttemptRepository) Reset(ctx context.Context, keys ...string) error {
+	return repo.cache.Reset(ctx, keys...)
+}
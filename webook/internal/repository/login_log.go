@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"github.com/zmsocc/practice/webook/pkg/mask"
+	"strings"
+	"time"
+)
+
+type LoginLogRepository interface {
+	// Create 账号不保存明文，只保存脱敏之后的账号和盲索引
+	Create(ctx context.Context, l domain.LoginLog) error
+	// FindByUid 返回的 Account 是脱敏之后的
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error)
+	CountSuccess(ctx context.Context, uid int64, userAgent string) (int64, error)
+	// CountFailures account 是明文，按照盲索引统计
+	CountFailures(ctx context.Context, account string, since time.Time) (int64, error)
+	// MaskLegacy 把 id 大于 afterId 的旧记录里面的明文账号换成脱敏之后的账号和盲索引，最多处理 limit 条。
+	// 返回值同 UserRepository.ReEncrypt
+	MaskLegacy(ctx context.Context, afterId int64, limit int) (int64, int, error)
+}
+
+type loginLogRepository struct {
+	dao    dao.LoginLogDAO
+	cipher *cryptox.FieldCipher
+}
+
+func NewLoginLogRepository(dao dao.LoginLogDAO, cipher *cryptox.FieldCipher) LoginLogRepository {
+	return &loginLogRepository{
+		dao:    dao,
+		cipher: cipher,
+	}
+}
+
+func (r *loginLogRepository) Create(ctx context.Context, l domain.LoginLog) error {
+	return r.dao.Insert(ctx, dao.LoginLog{
+		Uid:        l.Uid,
+		Method:     l.Method,
+		Account:    mask.Account(strings.TrimSpace(l.Account)),
+		AccountIdx: r.accountIdx(l.Account),
+		Ip:         l.Ip,
+		UserAgent:  l.UserAgent,
+		Success:    l.Success,
+		Reason:     l.Reason,
+		Ctime:      l.Ctime.UnixMilli(),
+	})
+}
+
+func (r *loginLogRepository) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	res, err := r.dao.FindByUid(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.LoginLog, domain.LoginLog](res, func(idx int, src dao.LoginLog) domain.LoginLog {
+		return domain.LoginLog{
+			Id:        src.Id,
+			Uid:       src.Uid,
+			Method:    src.Method,
+			Account:   src.Account,
+			Ip:        src.Ip,
+			UserAgent: src.UserAgent,
+			Success:   src.Success,
+			Reason:    src.Reason,
+			Ctime:     time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
+
+func (r *loginLogRepository) CountSuccess(ctx context.Context, uid int64, userAgent string) (int64, error) {
+	return r.dao.CountSuccess(ctx, uid, userAgent)
+}
+
+func (r *loginLogRepository) CountFailures(ctx context.Context, account string, since time.Time) (int64, error) {
+	return r.dao.CountFailures(ctx, r.accountIdx(account), since.UnixMilli())
+}
+
+func (r *loginLogRepository) MaskLegacy(ctx context.Context, afterId int64, limit int) (int64, int, error) {
+	logs, err := r.dao.FindUnindexed(ctx, afterId, limit)
+	if err != nil {
+		return afterId, 0, err
+	}
+	for i, l := range logs {
+		err = r.dao.UpdateAccount(ctx, l.Id, mask.Account(strings.TrimSpace(l.Account)), r.accountIdx(l.Account))
+		if err != nil {
+			return l.Id, i, err
+		}
+		afterId = l.Id
+	}
+	return afterId, len(logs), nil
+}
+
+// accountIdx 和 UserRepository 里面手机号、邮箱的盲索引用同一套规则
+func (r *loginLogRepository) accountIdx(account string) string {
+	account = strings.TrimSpace(account)
+	if account == "" {
+		return ""
+	}
+	if strings.Contains(account, "@") {
+		account = strings.ToLower(account)
+	}
+	return r.cipher.BlindIndex(account)
+}
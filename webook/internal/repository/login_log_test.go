@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	daomocks "github.com/zmsocc/practice/webook/internal/repository/dao/mocks"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestLoginLogRepository_Create(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	now := time.UnixMilli(1700000000000)
+	testCases := []struct {
+		name    string
+		account string
+
+		wantAccount    string
+		wantAccountIdx string
+	}{
+		{
+			name:           "手机号",
+			account:        "15212345678",
+			wantAccount:    "152****5678",
+			wantAccountIdx: cipher.BlindIndex("15212345678"),
+		},
+		{
+			// 和按邮箱查用户的时候一样，大小写不敏感
+			name:           "邮箱",
+			account:        " Foo@Example.com ",
+			wantAccount:    "F***@Example.com",
+			wantAccountIdx: cipher.BlindIndex("foo@example.com"),
+		},
+		{
+			name: "刷新 token 没有账号",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d := daomocks.NewMockLoginLogDAO(ctrl)
+			d.EXPECT().Insert(gomock.Any(), dao.LoginLog{
+				Uid:        1,
+				Method:     domain.LoginMethodPassword,
+				Account:    tc.wantAccount,
+				AccountIdx: tc.wantAccountIdx,
+				Ctime:      now.UnixMilli(),
+			}).Return(nil)
+			repo := NewLoginLogRepository(d, cipher)
+			err := repo.Create(context.Background(), domain.LoginLog{
+				Uid:     1,
+				Method:  domain.LoginMethodPassword,
+				Account: tc.account,
+				Ctime:   now,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoginLogRepository_CountFailures(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.UnixMilli(1700000000000)
+	d := daomocks.NewMockLoginLogDAO(ctrl)
+	d.EXPECT().CountFailures(gomock.Any(), cipher.BlindIndex("foo@example.com"), now.UnixMilli()).
+		Return(int64(3), nil)
+	repo := NewLoginLogRepository(d, cipher)
+	cnt, err := repo.CountFailures(context.Background(), "FOO@example.com", now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cnt)
+}
+
+func TestLoginLogRepository_MaskLegacy(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) dao.LoginLogDAO
+
+		wantLastId int64
+		wantCnt    int
+		wantErr    error
+	}{
+		{
+			name: "脱敏并补上盲索引",
+			mock: func(ctrl *gomock.Controller) dao.LoginLogDAO {
+				d := daomocks.NewMockLoginLogDAO(ctrl)
+				d.EXPECT().FindUnindexed(gomock.Any(), int64(0), 100).Return([]dao.LoginLog{
+					{Id: 3, Account: "15212345678"},
+					{Id: 5, Account: "foo@example.com"},
+				}, nil)
+				d.EXPECT().UpdateAccount(gomock.Any(), int64(3), "152****5678",
+					cipher.BlindIndex("15212345678")).Return(nil)
+				d.EXPECT().UpdateAccount(gomock.Any(), int64(5), "f***@example.com",
+					cipher.BlindIndex("foo@example.com")).Return(nil)
+				return d
+			},
+			wantLastId: 5,
+			wantCnt:    2,
+		},
+		{
+			name: "没有旧数据了",
+			mock: func(ctrl *gomock.Controller) dao.LoginLogDAO {
+				d := daomocks.NewMockLoginLogDAO(ctrl)
+				d.EXPECT().FindUnindexed(gomock.Any(), int64(0), 100).Return(nil, nil)
+				return d
+			},
+		},
+		{
+			// 出错的那一条跳过，下一批从它后面开始
+			name: "更新失败",
+			mock: func(ctrl *gomock.Controller) dao.LoginLogDAO {
+				d := daomocks.NewMockLoginLogDAO(ctrl)
+				d.EXPECT().FindUnindexed(gomock.Any(), int64(0), 100).Return([]dao.LoginLog{
+					{Id: 3, Account: "15212345678"},
+					{Id: 5, Account: "foo@example.com"},
+				}, nil)
+				d.EXPECT().UpdateAccount(gomock.Any(), int64(3), gomock.Any(), gomock.Any()).Return(nil)
+				d.EXPECT().UpdateAccount(gomock.Any(), int64(5), gomock.Any(), gomock.Any()).
+					Return(errors.New("mock error"))
+				return d
+			},
+			wantLastId: 5,
+			wantCnt:    1,
+			wantErr:    errors.New("mock error"),
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) dao.LoginLogDAO {
+				d := daomocks.NewMockLoginLogDAO(ctrl)
+				d.EXPECT().FindUnindexed(gomock.Any(), int64(0), 100).Return(nil, errors.New("mock error"))
+				return d
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := NewLoginLogRepository(tc.mock(ctrl), cipher)
+			lastId, cnt, err := repo.MaskLegacy(context.Background(), 0, 100)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantLastId, lastId)
+			assert.Equal(t, tc.wantCnt, cnt)
+		})
+	}
+}
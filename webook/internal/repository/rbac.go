@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+)
+
+var (
+	ErrRoleNotFound   = errors.New("角色不存在")
+	ErrLastRoleMember = dao.ErrLastRoleMember
+)
+
+type RBACRepository interface {
+	FindRoles(ctx context.Context, uid int64) ([]domain.Role, error)
+	GrantRole(ctx context.Context, uid int64, role string) error
+	RevokeRole(ctx context.Context, uid int64, role string) error
+	// RevokeRoleKeepLast uid 是这个角色最后一个用户的时候不收回，返回 ErrLastRoleMember
+	RevokeRoleKeepLast(ctx context.Context, uid int64, role string) error
+	// InitRoles 把角色和权限的对应关系写进去，已经存在的保持不变
+	InitRoles(ctx context.Context, roles []domain.Role) error
+}
+
+type rbacRepository struct {
+	dao dao.RBACDAO
+}
+
+func NewRBACRepository(d dao.RBACDAO) RBACRepository {
+	return &rbacRepository{
+		dao: d,
+	}
+}
+
+func (r *rbacRepository) FindRoles(ctx context.Context, uid int64) ([]domain.Role, error) {
+	rps, err := r.dao.FindRolePermissions(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	// 按照角色聚合起来，保持查出来的顺序
+	res := make([]domain.Role, 0, 2)
+	idx := make(map[string]int, 2)
+	for _, rp := range rps {
+		i, ok := idx[rp.Role]
+		if !ok {
+			i = len(res)
+			idx[rp.Role] = i
+			res = append(res, domain.Role{Name: rp.Role})
+		}
+		res[i].Permissions = append(res[i].Permissions, rp.Permission)
+	}
+	return res, nil
+}
+
+func (r *rbacRepository) GrantRole(ctx context.Context, uid int64, role string) error {
+	rps, err := r.dao.FindPermissions(ctx, role)
+	if err != nil {
+		return err
+	}
+	if len(rps) == 0 {
+		return ErrRoleNotFound
+	}
+	return r.dao.InsertUserRole(ctx, uid, role)
+}
+
+func (r *rbacRepository) RevokeRole(ctx context.Context, uid int64, role string) error {
+	return r.dao.DeleteUserRole(ctx, uid, role)
+}
+
+func (r *rbacRepository) RevokeRoleKeepLast(ctx context.Context, uid int64, role string) error {
+	return r.dao.DeleteUserRoleKeepLast(ctx, uid, role)
+}
+
+func (r *rbacRepository) InitRoles(ctx context.Context, roles []domain.Role) error {
+	rps := make([]dao.RolePermission, 0, len(roles)*2)
+	for _, role := range roles {
+		for _, perm := range role.Permissions {
+			rps = append(rps, dao.RolePermission{
+				Role:       role.Name,
+				Permission: perm,
+			})
+		}
+	}
+	return r.dao.UpsertRolePermissions(ctx, rps)
+}
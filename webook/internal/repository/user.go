@@ -4,15 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/repository/cache"
 	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"slices"
+	"strings"
 	"time"
 )
 
 var (
-	ErrUserDuplicateEmail = dao.ErrUserDuplicate
-	ErrUserNotFound       = dao.ErrUserNotFound
+	ErrUserDuplicateEmail   = dao.ErrUserDuplicate
+	ErrUserNotFound         = dao.ErrUserNotFound
+	ErrRecoveryCodeNotFound = dao.ErrRecoveryCodeNotFound
 )
 
 type UserRepository interface {
@@ -21,30 +26,76 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id int64) (domain.User, error)
 	Update(ctx context.Context, u domain.User) error
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	UpdateTotpSecret(ctx context.Context, id int64, secret string) error
+	// FindTotpSecret 直接查数据库，密钥不进缓存
+	FindTotpSecret(ctx context.Context, id int64) (string, error)
+	// MarkTotpUsed 同一个周期的验证码只能用一次，已经用过了返回 false
+	MarkTotpUsed(ctx context.Context, id, step int64, expiration time.Duration) (bool, error)
+	EnableTotp(ctx context.Context, id int64, codeHashes []string) error
+	DisableTotp(ctx context.Context, id int64) error
+	ConsumeRecoveryCode(ctx context.Context, uid int64, codeHash string) error
+	FindByOAuth(ctx context.Context, provider, openId string) (domain.User, error)
+	FindByUnionId(ctx context.Context, provider, unionId string) (domain.User, error)
+	// CreateWithOAuth code 为空表示不用邀请码，不为空的时候同 CreateWithInvite
+	CreateWithOAuth(ctx context.Context, u domain.User, info domain.OAuthInfo, code string) error
+	// BindOAuth 给已有的用户绑定第三方账号，已经绑定过了返回 ErrUserDuplicateEmail
+	BindOAuth(ctx context.Context, uid int64, info domain.OAuthInfo) error
+	// CreateWithInvite 用邀请码注册，邀请码不能用返回 ErrInviteCodeInvalid
+	CreateWithInvite(ctx context.Context, u domain.User, code string) error
+	UpdatePhone(ctx context.Context, id int64, phone string) error
+	UpdateEmail(ctx context.Context, id int64, email string) error
+	UpdateAvatar(ctx context.Context, id int64, avatar, thumb string) error
+	UpdateStatus(ctx context.Context, id int64, status domain.UserStatus, suspendedUntil time.Time) error
+	Merge(ctx context.Context, primary, secondary int64) error
+	ScheduleDeletion(ctx context.Context, id int64, deleteAt time.Time) error
+	CancelDeletion(ctx context.Context, id int64) error
+	FindDueDeletion(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// Erase 匿名化用户，只处理冷静期已经过了的用户，不然返回 ErrUserNotFound
+	Erase(ctx context.Context, id int64, now time.Time) error
+	// ReEncrypt 把 id 大于 afterId 的用户里面，不是用当前密钥加密的手机号、邮箱和 TOTP 密钥重新加密，
+	// 最多处理 limit 个。返回处理到的最后一个 id 和真正更新了的个数，没有需要处理的了返回的 id 是 afterId
+	ReEncrypt(ctx context.Context, afterId int64, limit int) (int64, int, error)
+	// FindPhoneConflicts 找出同一个手机号注册的多个账号。只有还没补上盲索引的旧数据会有这种情况，
+	// 补盲索引的时候唯一索引冲突了，后面的账号会一直留在明文状态
+	FindPhoneConflicts(ctx context.Context) ([]domain.PhoneConflict, error)
 }
 
+// userRepository 手机号、邮箱和 TOTP 密钥在这里加解密，上面的 service 看到的都是明文
 type userRepository struct {
 	dao   dao.UserDAO
 	cache cache.UserCache
+	// 合并账号会修正点赞、收藏计数，以及关注数和粉丝数
+	intrCache   cache.InteractiveCache
+	followCache cache.FollowCache
+	cipher      *cryptox.FieldCipher
 }
 
-func NewUserRepository(d dao.UserDAO, c cache.UserCache) UserRepository {
+func NewUserRepository(d dao.UserDAO, c cache.UserCache, intrCache cache.InteractiveCache,
+	followCache cache.FollowCache, cipher *cryptox.FieldCipher) UserRepository {
 	return &userRepository{
-		dao:   d,
-		cache: c,
+		dao:         d,
+		cache:       c,
+		intrCache:   intrCache,
+		followCache: followCache,
+		cipher:      cipher,
 	}
 }
 
 func (r *userRepository) Create(ctx context.Context, u domain.User) error {
-	return r.dao.Insert(ctx, r.domainToEntity(u))
+	entity, err := r.domainToEntity(u)
+	if err != nil {
+		return err
+	}
+	return r.dao.Insert(ctx, entity)
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
-	u, err := r.dao.FindByEmail(ctx, email)
+	u, err := r.dao.FindByEmail(ctx, r.emailIdx(email), strings.TrimSpace(email))
 	if err != nil {
 		return domain.User{}, err
 	}
-	return r.entityToDomain(u), nil
+	return r.entityToDomain(u)
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id int64) (domain.User, error) {
@@ -61,7 +112,10 @@ func (r *userRepository) FindByID(ctx context.Context, id int64) (domain.User, e
 	if err != nil {
 		return domain.User{}, err
 	}
-	user := r.entityToDomain(ue)
+	user, err := r.entityToDomain(ue)
+	if err != nil {
+		return domain.User{}, err
+	}
 	err = r.cache.Set(ctx, user)
 	if err != nil {
 		return domain.User{}, err
@@ -70,41 +124,418 @@ func (r *userRepository) FindByID(ctx context.Context, id int64) (domain.User, e
 }
 
 func (r *userRepository) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
-	u, err := r.dao.FindByPhone(ctx, phone)
+	u, err := r.dao.FindByPhone(ctx, r.phoneIdx(phone), strings.TrimSpace(phone))
 	if err != nil {
 		return domain.User{}, err
 	}
-	return r.entityToDomain(u), nil
+	return r.entityToDomain(u)
 }
 
 func (r *userRepository) Update(ctx context.Context, u domain.User) error {
-	entity := r.domainToEntity(u)
+	entity, err := r.domainToEntity(u)
+	if err != nil {
+		return err
+	}
 	// 只更新指定字段
-	return r.dao.Update(ctx, entity)
+	err = r.dao.Update(ctx, entity)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, u.Id)
 }
 
-func (r *userRepository) domainToEntity(u domain.User) dao.User {
-	return dao.User{
-		Id: u.Id,
-		Email: sql.NullString{
-			String: u.Email,
-			Valid:  u.Email != "",
-		},
-		Phone: sql.NullString{
-			String: u.Phone,
-			Valid:  u.Phone != "",
-		},
-		Password: u.Password,
-		Ctime:    u.Ctime.UnixMilli(),
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	err := r.dao.UpdatePassword(ctx, id, password)
+	if err != nil {
+		return err
+	}
+	// 缓存里面也有密码，直接删掉
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) UpdateTotpSecret(ctx context.Context, id int64, secret string) error {
+	enc, err := r.cipher.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	err = r.dao.UpdateTotpSecret(ctx, id, enc)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) FindTotpSecret(ctx context.Context, id int64) (string, error) {
+	u, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return r.decrypt(u.TotpSecret.String)
+}
+
+func (r *userRepository) MarkTotpUsed(ctx context.Context, id, step int64, expiration time.Duration) (bool, error) {
+	return r.cache.MarkTotpUsed(ctx, id, step, expiration)
+}
+
+func (r *userRepository) EnableTotp(ctx context.Context, id int64, codeHashes []string) error {
+	codes := slice.Map[string, dao.UserRecoveryCode](codeHashes, func(idx int, src string) dao.UserRecoveryCode {
+		return dao.UserRecoveryCode{CodeHash: src}
+	})
+	err := r.dao.EnableTotp(ctx, id, codes)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) DisableTotp(ctx context.Context, id int64) error {
+	err := r.dao.DisableTotp(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, uid int64, codeHash string) error {
+	return r.dao.ConsumeRecoveryCode(ctx, uid, codeHash)
+}
+
+func (r *userRepository) FindByOAuth(ctx context.Context, provider, openId string) (domain.User, error) {
+	u, err := r.dao.FindByOAuth(ctx, provider, openId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return r.entityToDomain(u)
+}
+
+func (r *userRepository) FindByUnionId(ctx context.Context, provider, unionId string) (domain.User, error) {
+	u, err := r.dao.FindByUnionId(ctx, provider, unionId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return r.entityToDomain(u)
+}
+
+func (r *userRepository) BindOAuth(ctx context.Context, uid int64, info domain.OAuthInfo) error {
+	return r.dao.InsertOAuth(ctx, dao.UserOAuth{
+		Uid:      uid,
+		Provider: info.Provider,
+		OpenId:   info.OpenId,
+		UnionId:  info.UnionId,
+	})
+}
+
+func (r *userRepository) CreateWithOAuth(ctx context.Context, u domain.User, info domain.OAuthInfo, code string) error {
+	entity, err := r.domainToEntity(u)
+	if err != nil {
+		return err
+	}
+	return r.dao.InsertWithOAuth(ctx, entity, dao.UserOAuth{
+		Provider: info.Provider,
+		OpenId:   info.OpenId,
+		UnionId:  info.UnionId,
+	}, code)
+}
+
+func (r *userRepository) CreateWithInvite(ctx context.Context, u domain.User, code string) error {
+	entity, err := r.domainToEntity(u)
+	if err != nil {
+		return err
+	}
+	return r.dao.InsertWithInvite(ctx, entity, code)
+}
+
+func (r *userRepository) UpdatePhone(ctx context.Context, id int64, phone string) error {
+	enc, err := r.cipher.Encrypt(phone)
+	if err != nil {
+		return err
+	}
+	err = r.dao.UpdatePhone(ctx, id, enc, r.phoneIdx(phone))
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) UpdateAvatar(ctx context.Context, id int64, avatar, thumb string) error {
+	err := r.dao.UpdateAvatar(ctx, id, avatar, thumb)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id int64, status domain.UserStatus, suspendedUntil time.Time) error {
+	var until int64
+	if !suspendedUntil.IsZero() {
+		until = suspendedUntil.UnixMilli()
+	}
+	err := r.dao.UpdateStatus(ctx, id, status.ToUint8(), until)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	enc, err := r.cipher.Encrypt(email)
+	if err != nil {
+		return err
+	}
+	err = r.dao.UpdateEmail(ctx, id, enc, r.emailIdx(email))
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) Merge(ctx context.Context, primary, secondary int64) error {
+	res, err := r.dao.Merge(ctx, primary, secondary)
+	if err != nil {
+		return err
+	}
+	// 重复的点赞、收藏扣掉了，缓存里面的计数也就不对了
+	for _, bk := range res.Biz {
+		if err = r.intrCache.Del(ctx, bk.Biz, bk.BizId); err != nil {
+			return err
+		}
+	}
+	for _, uid := range res.FollowUids {
+		if err = r.followCache.Del(ctx, uid); err != nil {
+			return err
+		}
+	}
+	err = r.cache.Del(ctx, primary)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, secondary)
+}
+
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id int64, deleteAt time.Time) error {
+	err := r.dao.ScheduleDeletion(ctx, id, deleteAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) CancelDeletion(ctx context.Context, id int64) error {
+	err := r.dao.CancelDeletion(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) FindDueDeletion(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	return r.dao.FindDueDeletion(ctx, now.UnixMilli(), limit)
+}
+
+func (r *userRepository) Erase(ctx context.Context, id int64, now time.Time) error {
+	err := r.dao.Erase(ctx, id, now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	return r.cache.Del(ctx, id)
+}
+
+func (r *userRepository) FindPhoneConflicts(ctx context.Context) ([]domain.PhoneConflict, error) {
+	const batchSize = 100
+	groups := make(map[string][]int64)
+	// 按第一次出现的顺序输出，结果稳定一些
+	var phones []string
+	var afterId int64
+	for {
+		users, err := r.dao.FindUnindexedPhones(ctx, afterId, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			break
+		}
+		for _, u := range users {
+			phone, err := r.decrypt(u.Phone.String)
+			if err != nil {
+				return nil, err
+			}
+			phone = strings.TrimSpace(phone)
+			if _, ok := groups[phone]; !ok {
+				phones = append(phones, phone)
+				// 已经补上盲索引的那个账号是手机号现在的主人
+				// 只找已经补上盲索引的，不然会查到自己
+				owner, err := r.dao.FindByPhone(ctx, r.phoneIdx(phone), "")
+				switch {
+				case err == nil:
+					groups[phone] = []int64{owner.Id}
+				case errors.Is(err, dao.ErrUserNotFound):
+					groups[phone] = []int64{}
+				default:
+					return nil, err
+				}
+			}
+			if !slices.Contains(groups[phone], u.Id) {
+				groups[phone] = append(groups[phone], u.Id)
+			}
+		}
+		afterId = users[len(users)-1].Id
+	}
+	var res []domain.PhoneConflict
+	for _, phone := range phones {
+		if uids := groups[phone]; len(uids) > 1 {
+			res = append(res, domain.PhoneConflict{Phone: phone, Uids: uids})
+		}
+	}
+	return res, nil
+}
+
+func (r *userRepository) ReEncrypt(ctx context.Context, afterId int64, limit int) (int64, int, error) {
+	users, err := r.dao.FindStaleEncrypted(ctx, r.cipher.Prefix(), afterId, limit)
+	if err != nil {
+		return afterId, 0, err
+	}
+	cnt := 0
+	for _, old := range users {
+		afterId = old.Id
+		u := old
+		u.Email, u.EmailIdx, err = r.reEncrypt(old.Email, r.emailIdx)
+		if err != nil {
+			return afterId, cnt, err
+		}
+		u.Phone, u.PhoneIdx, err = r.reEncrypt(old.Phone, r.phoneIdx)
+		if err != nil {
+			return afterId, cnt, err
+		}
+		// 密钥不需要盲索引
+		u.TotpSecret, _, err = r.reEncrypt(old.TotpSecret, r.cipher.BlindIndex)
+		if err != nil {
+			return afterId, cnt, err
+		}
+		err = r.dao.UpdateEncrypted(ctx, old, u)
+		// 中间用户自己改了手机号或者邮箱，改的时候已经用了新的密钥，跳过就可以
+		if errors.Is(err, dao.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return afterId, cnt, err
+		}
+		cnt++
+		if err = r.cache.Del(ctx, old.Id); err != nil {
+			return afterId, cnt, err
+		}
 	}
+	return afterId, cnt, nil
 }
 
-func (r *userRepository) entityToDomain(u dao.User) domain.User {
-	return domain.User{
+func (r *userRepository) reEncrypt(val sql.NullString,
+	index func(string) string) (sql.NullString, sql.NullString, error) {
+	if !val.Valid {
+		return sql.NullString{}, sql.NullString{}, nil
+	}
+	plain, err := r.decrypt(val.String)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	return r.encrypt(plain, index)
+}
+
+// encrypt 返回密文和盲索引，空字符串对应数据库里面的 NULL
+func (r *userRepository) encrypt(plain string,
+	index func(string) string) (sql.NullString, sql.NullString, error) {
+	if plain == "" {
+		return sql.NullString{}, sql.NullString{}, nil
+	}
+	enc, err := r.cipher.Encrypt(plain)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	return sql.NullString{String: enc, Valid: true},
+		sql.NullString{String: index(plain), Valid: true}, nil
+}
+
+// decrypt 加密上线之前的旧数据还是明文，原样返回，等 ReEncrypt 把它们加密
+func (r *userRepository) decrypt(val string) (string, error) {
+	if val == "" || !cryptox.IsEncrypted(val) {
+		return val, nil
+	}
+	return r.cipher.Decrypt(val)
+}
+
+// emailIdx 以前的唯一索引是大小写不敏感的，算盲索引之前统一转成小写
+func (r *userRepository) emailIdx(email string) string {
+	return r.cipher.BlindIndex(strings.ToLower(strings.TrimSpace(email)))
+}
+
+func (r *userRepository) phoneIdx(phone string) string {
+	return r.cipher.BlindIndex(strings.TrimSpace(phone))
+}
+
+func (r *userRepository) domainToEntity(u domain.User) (dao.User, error) {
+	email, emailIdx, err := r.encrypt(u.Email, r.emailIdx)
+	if err != nil {
+		return dao.User{}, err
+	}
+	phone, phoneIdx, err := r.encrypt(u.Phone, r.phoneIdx)
+	if err != nil {
+		return dao.User{}, err
+	}
+	return dao.User{
 		Id:       u.Id,
-		Email:    u.Email.String,
-		Phone:    u.Phone.String,
+		Email:    email,
+		EmailIdx: emailIdx,
+		Phone:    phone,
+		PhoneIdx: phoneIdx,
 		Password: u.Password,
-		Ctime:    time.UnixMilli(u.Ctime),
+		Nickname: sql.NullString{
+			String: u.Nickname,
+			Valid:  u.Nickname != "",
+		},
+		AboutMe: sql.NullString{
+			String: u.AboutMe,
+			Valid:  u.AboutMe != "",
+		},
+		Birthday: sql.NullInt64{
+			Int64: u.Birthday.UnixMilli(),
+			Valid: !u.Birthday.IsZero(),
+		},
+		Avatar:      u.Avatar,
+		AvatarThumb: u.AvatarThumb,
+		TotpEnabled: u.TotpEnabled,
+		Status:      u.Status.ToUint8(),
+		Ctime:       u.Ctime.UnixMilli(),
+	}, nil
+}
+
+func (r *userRepository) entityToDomain(u dao.User) (domain.User, error) {
+	email, err := r.decrypt(u.Email.String)
+	if err != nil {
+		return domain.User{}, err
+	}
+	phone, err := r.decrypt(u.Phone.String)
+	if err != nil {
+		return domain.User{}, err
+	}
+	res := domain.User{
+		Id:          u.Id,
+		Email:       email,
+		Phone:       phone,
+		Password:    u.Password,
+		Nickname:    u.Nickname.String,
+		AboutMe:     u.AboutMe.String,
+		Avatar:      u.Avatar,
+		AvatarThumb: u.AvatarThumb,
+		TotpEnabled: u.TotpEnabled,
+		Status:      domain.UserStatus(u.Status),
+		Ctime:       time.UnixMilli(u.Ctime),
+	}
+	if u.SuspendedUntil > 0 {
+		res.SuspendedUntil = time.UnixMilli(u.SuspendedUntil)
+	}
+	if u.Birthday.Valid {
+		res.Birthday = time.UnixMilli(u.Birthday.Int64)
+	}
+	if u.DeleteAt > 0 {
+		res.DeleteAt = time.UnixMilli(u.DeleteAt)
 	}
+	return res, nil
 }
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	"time"
+)
+
+var ErrExportTaskNotFound = cache.ErrKeyNotExist
+
+type UserExportRepository interface {
+	GetTask(ctx context.Context, uid int64) (domain.ExportTask, error)
+	SaveTask(ctx context.Context, task domain.ExportTask) error
+	Lock(ctx context.Context, uid int64, expiration time.Duration) (bool, error)
+	Unlock(ctx context.Context, uid int64) error
+}
+
+type userExportRepository struct {
+	cache cache.UserExportCache
+}
+
+func NewUserExportRepository(c cache.UserExportCache) UserExportRepository {
+	return &userExportRepository{
+		cache: c,
+	}
+}
+
+func (r *userExportRepository) GetTask(ctx context.Context, uid int64) (domain.ExportTask, error) {
+	return r.cache.Get(ctx, uid)
+}
+
+func (r *userExportRepository) SaveTask(ctx context.Context, task domain.ExportTask) error {
+	return r.cache.Set(ctx, task)
+}
+
+func (r *userExportRepository) Lock(ctx context.Context, uid int64, expiration time.Duration) (bool, error) {
+	return r.cache.Lock(ctx, uid, expiration)
+}
+
+func (r *userExportRepository) Unlock(ctx context.Context, uid int64) error {
+	return r.cache.Unlock(ctx, uid)
+}
@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	cachemocks "github.com/zmsocc/practice/webook/internal/repository/cache/mocks"
+	"github.com/zmsocc/practice/webook/internal/repository/dao"
+	daomocks "github.com/zmsocc/practice/webook/internal/repository/dao/mocks"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestUserRepository_Merge(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache)
+
+		wantErr error
+	}{
+		{
+			name: "合并成功，清掉计数被修正过的缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				uc := cachemocks.NewMockUserCache(ctrl)
+				ic := cachemocks.NewMockInteractiveCache(ctrl)
+				fc := cachemocks.NewMockFollowCache(ctrl)
+				d.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).Return(dao.MergeResult{
+					Biz: []dao.BizKey{
+						{Biz: "article", BizId: 10},
+						{Biz: "article", BizId: 11},
+					},
+					FollowUids: []int64{1, 2, 3},
+				}, nil)
+				ic.EXPECT().Del(gomock.Any(), "article", int64(10)).Return(nil)
+				ic.EXPECT().Del(gomock.Any(), "article", int64(11)).Return(nil)
+				fc.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				fc.EXPECT().Del(gomock.Any(), int64(2)).Return(nil)
+				fc.EXPECT().Del(gomock.Any(), int64(3)).Return(nil)
+				uc.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				uc.EXPECT().Del(gomock.Any(), int64(2)).Return(nil)
+				return d, uc, ic, fc
+			},
+		},
+		{
+			name: "没有重复的点赞收藏，也没有关注关系",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				uc := cachemocks.NewMockUserCache(ctrl)
+				d.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).Return(dao.MergeResult{}, nil)
+				uc.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				uc.EXPECT().Del(gomock.Any(), int64(2)).Return(nil)
+				return d, uc, cachemocks.NewMockInteractiveCache(ctrl), cachemocks.NewMockFollowCache(ctrl)
+			},
+		},
+		{
+			name: "清计数缓存失败",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				ic := cachemocks.NewMockInteractiveCache(ctrl)
+				d.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).
+					Return(dao.MergeResult{Biz: []dao.BizKey{{Biz: "article", BizId: 10}}}, nil)
+				ic.EXPECT().Del(gomock.Any(), "article", int64(10)).Return(errors.New("mock error"))
+				return d, cachemocks.NewMockUserCache(ctrl), ic, cachemocks.NewMockFollowCache(ctrl)
+			},
+			wantErr: errors.New("mock error"),
+		},
+		{
+			name: "清关注计数缓存失败",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				fc := cachemocks.NewMockFollowCache(ctrl)
+				d.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).
+					Return(dao.MergeResult{FollowUids: []int64{1}}, nil)
+				fc.EXPECT().Del(gomock.Any(), int64(1)).Return(errors.New("mock error"))
+				return d, cachemocks.NewMockUserCache(ctrl), cachemocks.NewMockInteractiveCache(ctrl), fc
+			},
+			wantErr: errors.New("mock error"),
+		},
+		{
+			name: "合并失败，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, cache.InteractiveCache, cache.FollowCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).Return(dao.MergeResult{}, errors.New("mock error"))
+				return d, cachemocks.NewMockUserCache(ctrl), cachemocks.NewMockInteractiveCache(ctrl),
+					cachemocks.NewMockFollowCache(ctrl)
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, uc, ic, fc := tc.mock(ctrl)
+			repo := NewUserRepository(d, uc, ic, fc, nil)
+			err := repo.Merge(context.Background(), 1, 2)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	birthday := time.Date(2000, 1, 2, 0, 0, 0, 0, time.Local)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		u    domain.User
+
+		wantErr error
+	}{
+		{
+			name: "更新资料之后删掉缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+				d.EXPECT().Update(gomock.Any(), gomock.Cond(func(u dao.User) bool {
+					return u.Id == 1 &&
+						u.Nickname == sql.NullString{String: "大明", Valid: true} &&
+						u.AboutMe == sql.NullString{String: "写代码的", Valid: true} &&
+						u.Birthday == sql.NullInt64{Int64: birthday.UnixMilli(), Valid: true}
+				})).Return(nil)
+				c.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				return d, c
+			},
+			u: domain.User{Id: 1, Nickname: "大明", AboutMe: "写代码的", Birthday: birthday},
+		},
+		{
+			name: "没填的字段是 NULL",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+				d.EXPECT().Update(gomock.Any(), gomock.Cond(func(u dao.User) bool {
+					return u.Id == 1 && u.Nickname.Valid && !u.AboutMe.Valid && !u.Birthday.Valid
+				})).Return(nil)
+				c.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				return d, c
+			},
+			u: domain.User{Id: 1, Nickname: "大明"},
+		},
+		{
+			name: "更新失败，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("mock error"))
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			u:       domain.User{Id: 1, Nickname: "大明"},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewUserRepository(d, c, cachemocks.NewMockInteractiveCache(ctrl), nil, nil)
+			err := repo.Update(context.Background(), tc.u)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserRepository_FindByID(t *testing.T) {
+	ctime := time.UnixMilli(time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local).UnixMilli())
+	birthday := time.UnixMilli(time.Date(2000, 1, 2, 0, 0, 0, 0, time.Local).UnixMilli())
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "缓存命中",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(1)).Return(domain.User{Id: 1, Nickname: "大明"}, nil)
+				return daomocks.NewMockUserDAO(ctrl), c
+			},
+			wantUser: domain.User{Id: 1, Nickname: "大明"},
+		},
+		{
+			name: "缓存没有，资料字段都要带上",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(1)).Return(domain.User{}, cache.ErrKeyNotExist)
+				d.EXPECT().FindById(gomock.Any(), int64(1)).Return(dao.User{
+					Id:       1,
+					Nickname: sql.NullString{String: "大明", Valid: true},
+					AboutMe:  sql.NullString{String: "写代码的", Valid: true},
+					Birthday: sql.NullInt64{Int64: birthday.UnixMilli(), Valid: true},
+					Ctime:    ctime.UnixMilli(),
+				}, nil)
+				c.EXPECT().Set(gomock.Any(), domain.User{
+					Id:       1,
+					Nickname: "大明",
+					AboutMe:  "写代码的",
+					Birthday: birthday,
+					Ctime:    ctime,
+				}).Return(nil)
+				return d, c
+			},
+			wantUser: domain.User{
+				Id:       1,
+				Nickname: "大明",
+				AboutMe:  "写代码的",
+				Birthday: birthday,
+				Ctime:    ctime,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewUserRepository(d, c, cachemocks.NewMockInteractiveCache(ctrl), nil, nil)
+			u, err := repo.FindByID(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestUserRepository_FindByPhone(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt("15212345678")
+	require.NoError(t, err)
+	idx := cipher.BlindIndex("15212345678")
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) dao.UserDAO
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "按盲索引查到",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindByPhone(gomock.Any(), idx, "15212345678").Return(dao.User{
+					Id:       1,
+					Phone:    sql.NullString{String: encrypted, Valid: true},
+					PhoneIdx: sql.NullString{String: idx, Valid: true},
+				}, nil)
+				return d
+			},
+			wantUser: domain.User{Id: 1, Phone: "15212345678", Ctime: time.UnixMilli(0)},
+		},
+		{
+			// 盲索引还没补上的老数据也要能查到，不然短信登录会再注册一个账号
+			name: "还没加密的老数据",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindByPhone(gomock.Any(), idx, "15212345678").Return(dao.User{
+					Id:    1,
+					Phone: sql.NullString{String: "15212345678", Valid: true},
+				}, nil)
+				return d
+			},
+			wantUser: domain.User{Id: 1, Phone: "15212345678", Ctime: time.UnixMilli(0)},
+		},
+		{
+			name: "没有这个用户",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindByPhone(gomock.Any(), idx, "15212345678").Return(dao.User{}, dao.ErrUserNotFound)
+				return d
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := NewUserRepository(tc.mock(ctrl), cachemocks.NewMockUserCache(ctrl),
+				cachemocks.NewMockInteractiveCache(ctrl), cachemocks.NewMockFollowCache(ctrl), cipher)
+			u, err := repo.FindByPhone(context.Background(), " 15212345678 ")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestUserRepository_FindPhoneConflicts(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	legacy := func(id int64, phone string) dao.User {
+		return dao.User{Id: id, Phone: sql.NullString{String: phone, Valid: true}}
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) dao.UserDAO
+
+		wantConflicts []domain.PhoneConflict
+		wantErr       error
+	}{
+		{
+			name: "都还是明文，按注册先后排",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(0), 100).Return([]dao.User{
+					legacy(3, "15212345678"), legacy(4, "15200000000"), legacy(7, "15212345678"),
+				}, nil)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(7), 100).Return(nil, nil)
+				d.EXPECT().FindByPhone(gomock.Any(), cipher.BlindIndex("15212345678"), "").
+					Return(dao.User{}, dao.ErrUserNotFound)
+				d.EXPECT().FindByPhone(gomock.Any(), cipher.BlindIndex("15200000000"), "").
+					Return(dao.User{}, dao.ErrUserNotFound)
+				return d
+			},
+			wantConflicts: []domain.PhoneConflict{{Phone: "15212345678", Uids: []int64{3, 7}}},
+		},
+		{
+			// 补盲索引的时候冲突了，留下来的账号要和已经加密好的那个放在一起
+			name: "已经补上盲索引的账号排在最前面",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(0), 100).Return([]dao.User{
+					legacy(7, "15212345678"),
+				}, nil)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(7), 100).Return(nil, nil)
+				d.EXPECT().FindByPhone(gomock.Any(), cipher.BlindIndex("15212345678"), "").
+					Return(dao.User{Id: 3}, nil)
+				return d
+			},
+			wantConflicts: []domain.PhoneConflict{{Phone: "15212345678", Uids: []int64{3, 7}}},
+		},
+		{
+			name: "没有冲突",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(0), 100).Return([]dao.User{
+					legacy(3, "15212345678"),
+				}, nil)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(3), 100).Return(nil, nil)
+				d.EXPECT().FindByPhone(gomock.Any(), cipher.BlindIndex("15212345678"), "").
+					Return(dao.User{}, dao.ErrUserNotFound)
+				return d
+			},
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) dao.UserDAO {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindUnindexedPhones(gomock.Any(), int64(0), 100).Return(nil, errors.New("mock error"))
+				return d
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := NewUserRepository(tc.mock(ctrl), cachemocks.NewMockUserCache(ctrl),
+				cachemocks.NewMockInteractiveCache(ctrl), cachemocks.NewMockFollowCache(ctrl), cipher)
+			conflicts, err := repo.FindPhoneConflicts(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantConflicts, conflicts)
+		})
+	}
+}
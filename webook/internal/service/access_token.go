@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix 个人访问令牌的前缀，登录校验的时候靠它和 JWT 区分开
+const AccessTokenPrefix = "wbk_"
+
+const maxAccessTokenCnt = 20
+
+var (
+	ErrAccessTokenNotFound    = repository.ErrAccessTokenNotFound
+	ErrInvalidAccessToken     = errors.New("无效的访问令牌")
+	ErrInvalidScope           = errors.New("无效的权限范围")
+	ErrTooManyAccessTokens    = errors.New("访问令牌数量太多")
+	ErrInvalidAccessTokenName = errors.New("令牌名字不能为空，并且不能超过 64 个字符")
+)
+
+// AccessTokenService 个人访问令牌。令牌只在创建的时候返回一次明文，数据库里面只有哈希
+type AccessTokenService interface {
+	// Create t 里面的 Uid、Name、Scopes、ExpiresAt 由调用者填好，返回明文令牌
+	Create(ctx context.Context, t domain.AccessToken) (domain.AccessToken, string, error)
+	List(ctx context.Context, uid int64) ([]domain.AccessToken, error)
+	Revoke(ctx context.Context, uid, id int64) error
+	// Verify 校验令牌，不存在或者过期了都返回 ErrInvalidAccessToken
+	Verify(ctx context.Context, token string) (domain.AccessToken, error)
+}
+
+type accessTokenService struct {
+	repo repository.AccessTokenRepository
+}
+
+func NewAccessTokenService(repo repository.AccessTokenRepository) AccessTokenService {
+	return &accessTokenService{
+		repo: repo,
+	}
+}
+
+func (svc *accessTokenService) Create(ctx context.Context, t domain.AccessToken) (domain.AccessToken, string, error) {
+	if t.Name == "" || len(t.Name) > 64 {
+		return domain.AccessToken{}, "", ErrInvalidAccessTokenName
+	}
+	if len(t.Scopes) == 0 {
+		return domain.AccessToken{}, "", ErrInvalidScope
+	}
+	for _, scope := range t.Scopes {
+		if !validScope(scope) {
+			return domain.AccessToken{}, "", ErrInvalidScope
+		}
+	}
+	ts, err := svc.repo.FindByUid(ctx, t.Uid)
+	if err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	if len(ts) >= maxAccessTokenCnt {
+		return domain.AccessToken{}, "", ErrTooManyAccessTokens
+	}
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	token := AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	t.Prefix = token[:len(AccessTokenPrefix)+6]
+	t.Ctime = time.Now()
+	t.Id, err = svc.repo.Create(ctx, t, hashAccessToken(token))
+	if err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	return t, token, nil
+}
+
+func (svc *accessTokenService) List(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	return svc.repo.FindByUid(ctx, uid)
+}
+
+func (svc *accessTokenService) Revoke(ctx context.Context, uid, id int64) error {
+	return svc.repo.Delete(ctx, uid, id)
+}
+
+func (svc *accessTokenService) Verify(ctx context.Context, token string) (domain.AccessToken, error) {
+	if !strings.HasPrefix(token, AccessTokenPrefix) {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	t, err := svc.repo.FindByHash(ctx, hashAccessToken(token))
+	if errors.Is(err, repository.ErrAccessTokenNotFound) {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	now := time.Now()
+	if t.Expired(now) {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	// 脚本可能一秒钟调很多次，一分钟内只更新一次
+	if now.Sub(t.LastUsedAt) > time.Minute {
+		// 更新失败不影响这次请求
+		_ = svc.repo.UpdateLastUsed(ctx, t.Id, now)
+		t.LastUsedAt = now
+	}
+	return t, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range domain.AccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAccessToken 令牌本身是 256 位的随机数，不怕暴力破解，直接 sha256 就可以
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenService_Create(t *testing.T) {
+	// 存进数据库的哈希
+	var savedHash string
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.AccessTokenRepository
+		t    domain.AccessToken
+
+		wantErr error
+	}{
+		{
+			name: "创建成功",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, at domain.AccessToken, hash string) (int64, error) {
+						savedHash = hash
+						return 10, nil
+					})
+				return repo
+			},
+			t: domain.AccessToken{Uid: 1, Name: "备份脚本", Scopes: []string{domain.ScopeArticleRead}},
+		},
+		{
+			name: "名字为空",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomocks.NewMockAccessTokenRepository(ctrl)
+			},
+			t:       domain.AccessToken{Uid: 1, Scopes: []string{domain.ScopeArticleRead}},
+			wantErr: ErrInvalidAccessTokenName,
+		},
+		{
+			name: "没有权限范围",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomocks.NewMockAccessTokenRepository(ctrl)
+			},
+			t:       domain.AccessToken{Uid: 1, Name: "备份脚本"},
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name: "不认识的权限范围",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomocks.NewMockAccessTokenRepository(ctrl)
+			},
+			t:       domain.AccessToken{Uid: 1, Name: "备份脚本", Scopes: []string{domain.ScopeArticleRead, "user:role"}},
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name: "令牌太多",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(make([]domain.AccessToken, maxAccessTokenCnt), nil)
+				return repo
+			},
+			t:       domain.AccessToken{Uid: 1, Name: "备份脚本", Scopes: []string{domain.ScopeArticleRead}},
+			wantErr: ErrTooManyAccessTokens,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewAccessTokenService(tc.mock(ctrl))
+			at, token, err := svc.Create(context.Background(), tc.t)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, int64(10), at.Id)
+			assert.True(t, strings.HasPrefix(token, AccessTokenPrefix))
+			assert.True(t, strings.HasPrefix(token, at.Prefix))
+			// 数据库里面只有哈希，没有明文
+			assert.Equal(t, hashAccessToken(token), savedHash)
+		})
+	}
+}
+
+func TestAccessTokenService_Verify(t *testing.T) {
+	const token = AccessTokenPrefix + "abcdef"
+	hash := hashAccessToken(token)
+	now := time.Now()
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) repository.AccessTokenRepository
+		token string
+
+		wantUid int64
+		wantErr error
+	}{
+		{
+			name: "校验通过，顺便更新最后使用时间",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(domain.AccessToken{
+					Id: 10, Uid: 1, LastUsedAt: now.Add(-time.Hour),
+				}, nil)
+				repo.EXPECT().UpdateLastUsed(gomock.Any(), int64(10), gomock.Any()).Return(nil)
+				return repo
+			},
+			token:   token,
+			wantUid: 1,
+		},
+		{
+			name: "刚用过，不更新",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(domain.AccessToken{
+					Id: 10, Uid: 1, LastUsedAt: now.Add(-time.Second),
+				}, nil)
+				return repo
+			},
+			token:   token,
+			wantUid: 1,
+		},
+		{
+			name: "更新最后使用时间失败不影响",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(domain.AccessToken{Id: 10, Uid: 1}, nil)
+				repo.EXPECT().UpdateLastUsed(gomock.Any(), int64(10), gomock.Any()).
+					Return(errors.New("mock error"))
+				return repo
+			},
+			token:   token,
+			wantUid: 1,
+		},
+		{
+			name: "不是访问令牌",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomocks.NewMockAccessTokenRepository(ctrl)
+			},
+			token:   "eyJhbGciOi",
+			wantErr: ErrInvalidAccessToken,
+		},
+		{
+			name: "令牌不存在",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).
+					Return(domain.AccessToken{}, repository.ErrAccessTokenNotFound)
+				return repo
+			},
+			token:   token,
+			wantErr: ErrInvalidAccessToken,
+		},
+		{
+			name: "过期了",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(domain.AccessToken{
+					Id: 10, Uid: 1, ExpiresAt: now.Add(-time.Minute),
+				}, nil)
+				return repo
+			},
+			token:   token,
+			wantErr: ErrInvalidAccessToken,
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomocks.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).
+					Return(domain.AccessToken{}, errors.New("mock error"))
+				return repo
+			},
+			token:   token,
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewAccessTokenService(tc.mock(ctrl))
+			at, err := svc.Verify(context.Background(), tc.token)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			require.Equal(t, tc.wantUid, at.Uid)
+			assert.WithinDuration(t, now, at.LastUsedAt, time.Minute)
+		})
+	}
+}
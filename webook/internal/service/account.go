@@ -0,0 +1,392 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/repository/articles"
+	"github.com/zmsocc/practice/webook/internal/service/storage"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"io"
+	"time"
+)
+
+var (
+	ErrExportInProgress   = errors.New("数据正在导出")
+	ErrExportTooFrequent  = errors.New("导出太频繁")
+	ErrExportTaskNotFound = repository.ErrExportTaskNotFound
+	ErrExportNotReady     = errors.New("导出文件还没有准备好")
+	ErrNoPendingDeletion  = errors.New("没有待注销的申请")
+)
+
+// SessionRevoker 注销之后要让用户所有的登录状态失效
+type SessionRevoker interface {
+	RevokeAllSessions(ctx context.Context, uid int64, except ...string) error
+}
+
+// AccountService 导出个人数据和注销账号
+type AccountService interface {
+	// Export 开始异步打包个人数据，返回的任务状态是 ExportStatusPending
+	Export(ctx context.Context, uid int64) (domain.ExportTask, error)
+	ExportTask(ctx context.Context, uid int64) (domain.ExportTask, error)
+	// ExportFile 读取打包好的 zip，还在打包或者打包失败了返回 ErrExportNotReady
+	ExportFile(ctx context.Context, uid int64) ([]byte, error)
+	// RequestDeletion 申请注销，返回真正删除的时间，在这之前都可以撤销
+	RequestDeletion(ctx context.Context, uid int64) (time.Time, error)
+	CancelDeletion(ctx context.Context, uid int64) error
+	// EraseDue 处理冷静期已经过了的注销申请，返回处理了多少个
+	EraseDue(ctx context.Context, now time.Time) (int, error)
+	// CleanExports 删掉已经过期的导出文件，返回删了多少个
+	CleanExports(ctx context.Context, now time.Time) (int, error)
+}
+
+// exportExpiration 导出的文件只保留这么久，和 UserExportCache 里面任务的过期时间一样。
+// 任务过期之后就不知道文件的 key 了，只能按照文件的时间清理
+const exportExpiration = time.Hour * 24
+
+type accountService struct {
+	userRepo   repository.UserRepository
+	artRepo    articles.ArticleRepository
+	intrRepo   repository.InteractiveRepository
+	exportRepo repository.UserExportRepository
+	sessions   SessionRevoker
+	// 导出的 zip 放在这里，多个实例都要能访问到，并且不能公开访问
+	store storage.Service
+	// 注销的冷静期
+	grace time.Duration
+	l     logger.Logger
+}
+
+func NewAccountService(userRepo repository.UserRepository, artRepo articles.ArticleRepository,
+	intrRepo repository.InteractiveRepository, exportRepo repository.UserExportRepository,
+	sessions SessionRevoker, store storage.Service, grace time.Duration, l logger.Logger) AccountService {
+	return &accountService{
+		userRepo:   userRepo,
+		artRepo:    artRepo,
+		intrRepo:   intrRepo,
+		exportRepo: exportRepo,
+		sessions:   sessions,
+		store:      store,
+		grace:      grace,
+		l:          l,
+	}
+}
+
+func (svc *accountService) Export(ctx context.Context, uid int64) (domain.ExportTask, error) {
+	now := time.Now()
+	old, err := svc.exportRepo.GetTask(ctx, uid)
+	switch {
+	case errors.Is(err, repository.ErrExportTaskNotFound):
+	case err != nil:
+		return domain.ExportTask{}, err
+	case old.Status == domain.ExportStatusDone && now.Sub(old.Ctime) < time.Hour:
+		return domain.ExportTask{}, ErrExportTooFrequent
+	}
+	// 打包卡住了的话，十分钟之后锁过期了允许重新来
+	ok, err := svc.exportRepo.Lock(ctx, uid, time.Minute*10)
+	if err != nil {
+		return domain.ExportTask{}, err
+	}
+	if !ok {
+		return domain.ExportTask{}, ErrExportInProgress
+	}
+	task := domain.ExportTask{
+		Uid:    uid,
+		Status: domain.ExportStatusPending,
+		Ctime:  now,
+		Utime:  now,
+	}
+	if err = svc.exportRepo.SaveTask(ctx, task); err != nil {
+		_ = svc.exportRepo.Unlock(ctx, uid)
+		return domain.ExportTask{}, err
+	}
+	go svc.runExport(task, old.Key)
+	return task, nil
+}
+
+// runExport 在后台打包，oldKey 是上一次导出的文件
+func (svc *accountService) runExport(task domain.ExportTask, oldKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+	svc.removeExportFile(ctx, oldKey)
+	key, err := svc.buildExport(ctx, task.Uid)
+	task.Utime = time.Now()
+	if err != nil {
+		svc.l.Error("导出个人数据失败", logger.Int64("uid", task.Uid), logger.Error(err))
+		task.Status = domain.ExportStatusFailed
+	} else {
+		task.Status = domain.ExportStatusDone
+		task.Key = key
+	}
+	if err = svc.exportRepo.SaveTask(ctx, task); err != nil {
+		svc.l.Error("保存导出任务失败", logger.Int64("uid", task.Uid), logger.Error(err))
+	}
+	if err = svc.exportRepo.Unlock(ctx, task.Uid); err != nil {
+		// 等锁自己过期
+		svc.l.Warn("释放导出锁失败", logger.Int64("uid", task.Uid), logger.Error(err))
+	}
+}
+
+func (svc *accountService) buildExport(ctx context.Context, uid int64) (string, error) {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	arts, err := svc.artRepo.ListAll(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	likes, err := svc.intrRepo.Likes(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	collections, err := svc.intrRepo.Collections(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	history, err := svc.intrRepo.ReadHistory(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = writeExportZip(&buf, []exportFile{
+		{Name: "profile.json", Data: newExportProfile(u)},
+		{Name: "articles.json", Data: newExportArticles(arts)},
+		{Name: "likes.json", Data: newExportRecords(likes)},
+		{Name: "collections.json", Data: newExportRecords(collections)},
+		{Name: "read_history.json", Data: newExportRecords(history)},
+	})
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s%d/%d.zip", exportPrefix, uid, time.Now().UnixMilli())
+	_, err = svc.store.Put(ctx, key, buf.Bytes(), "application/zip")
+	return key, err
+}
+
+const exportPrefix = "exports/"
+
+func (svc *accountService) CleanExports(ctx context.Context, now time.Time) (int, error) {
+	objs, err := svc.store.List(ctx, exportPrefix)
+	if err != nil {
+		return 0, err
+	}
+	cnt := 0
+	for _, obj := range objs {
+		if now.Sub(obj.Mtime) < exportExpiration {
+			continue
+		}
+		if err = svc.store.Delete(ctx, obj.Key); err != nil {
+			return cnt, err
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+func (svc *accountService) ExportTask(ctx context.Context, uid int64) (domain.ExportTask, error) {
+	return svc.exportRepo.GetTask(ctx, uid)
+}
+
+func (svc *accountService) ExportFile(ctx context.Context, uid int64) ([]byte, error) {
+	task, err := svc.exportRepo.GetTask(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != domain.ExportStatusDone {
+		return nil, ErrExportNotReady
+	}
+	data, err := svc.store.Get(ctx, task.Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrExportTaskNotFound
+	}
+	return data, err
+}
+
+func (svc *accountService) RequestDeletion(ctx context.Context, uid int64) (time.Time, error) {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// 已经申请过了，冷静期不重新计算
+	if !u.DeleteAt.IsZero() {
+		return u.DeleteAt, nil
+	}
+	deleteAt := time.Now().Add(svc.grace)
+	return deleteAt, svc.userRepo.ScheduleDeletion(ctx, uid, deleteAt)
+}
+
+func (svc *accountService) CancelDeletion(ctx context.Context, uid int64) error {
+	err := svc.userRepo.CancelDeletion(ctx, uid)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrNoPendingDeletion
+	}
+	return err
+}
+
+func (svc *accountService) EraseDue(ctx context.Context, now time.Time) (int, error) {
+	ids, err := svc.userRepo.FindDueDeletion(ctx, now, 100)
+	if err != nil {
+		return 0, err
+	}
+	cnt := 0
+	for _, uid := range ids {
+		// 一个失败了不影响其它的，下一轮还会再处理它
+		err = svc.erase(ctx, uid, now)
+		if err != nil {
+			svc.l.Error("注销账号失败", logger.Int64("uid", uid), logger.Error(err))
+			continue
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+// erase 真正删除账号。中间任何一步失败了，用户还是待注销的状态，下一轮会重试，
+// 所以每一步都要能重复执行
+func (svc *accountService) erase(ctx context.Context, uid int64, now time.Time) error {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.DeleteAt.IsZero() || u.DeleteAt.After(now) {
+		// 刚刚撤销了
+		return nil
+	}
+	arts, err := svc.artRepo.ListAll(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, art := range arts {
+		if art.Status != domain.ArticleStatusPublished {
+			continue
+		}
+		err = svc.artRepo.SyncStatus(ctx, art.Id, uid, domain.ArticleStatusPrivate)
+		if err != nil {
+			return err
+		}
+	}
+	if err = svc.intrRepo.DeleteByUid(ctx, uid); err != nil {
+		return err
+	}
+	err = svc.userRepo.Erase(ctx, uid, now)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// 别的实例已经处理完了
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = svc.sessions.RevokeAllSessions(ctx, uid); err != nil {
+		svc.l.Error("注销之后退出所有设备失败", logger.Int64("uid", uid), logger.Error(err))
+	}
+	if task, er := svc.exportRepo.GetTask(ctx, uid); er == nil {
+		svc.removeExportFile(ctx, task.Key)
+	}
+	return nil
+}
+
+func (svc *accountService) removeExportFile(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	err := svc.store.Delete(ctx, key)
+	if err != nil {
+		svc.l.Warn("删除导出文件失败", logger.String("key", key), logger.Error(err))
+	}
+}
+
+type exportFile struct {
+	Name string
+	Data any
+}
+
+func writeExportZip(out io.Writer, files []exportFile) error {
+	zw := zip.NewWriter(out)
+	for _, file := range files {
+		w, err := zw.Create(file.Name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(file.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+type exportProfile struct {
+	Id       int64  `json:"id"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Nickname string `json:"nickname"`
+	Birthday string `json:"birthday"`
+	AboutMe  string `json:"about_me"`
+	Ctime    string `json:"ctime"`
+}
+
+func newExportProfile(u domain.User) exportProfile {
+	res := exportProfile{
+		Id:       u.Id,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Nickname: u.Nickname,
+		AboutMe:  u.AboutMe,
+		Ctime:    u.Ctime.Format(time.DateTime),
+	}
+	if !u.Birthday.IsZero() {
+		res.Birthday = u.Birthday.Format(time.DateOnly)
+	}
+	return res
+}
+
+type exportArticle struct {
+	Id      int64  `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Status  uint8  `json:"status"`
+	Ctime   string `json:"ctime"`
+	Utime   string `json:"utime"`
+}
+
+func newExportArticles(arts []domain.Article) []exportArticle {
+	res := make([]exportArticle, 0, len(arts))
+	for _, art := range arts {
+		res = append(res, exportArticle{
+			Id:      art.Id,
+			Title:   art.Title,
+			Content: art.Content,
+			Status:  art.Status.ToUint8(),
+			Ctime:   art.Ctime.Format(time.DateTime),
+			Utime:   art.Utime.Format(time.DateTime),
+		})
+	}
+	return res
+}
+
+type exportRecord struct {
+	Biz   string `json:"biz"`
+	BizId int64  `json:"biz_id"`
+	Ctime string `json:"ctime"`
+	Utime string `json:"utime"`
+}
+
+func newExportRecords(records []domain.BizRecord) []exportRecord {
+	res := make([]exportRecord, 0, len(records))
+	for _, r := range records {
+		res = append(res, exportRecord{
+			Biz:   r.Biz,
+			BizId: r.BizId,
+			Ctime: r.Ctime.Format(time.DateTime),
+			Utime: r.Utime.Format(time.DateTime),
+		})
+	}
+	return res
+}
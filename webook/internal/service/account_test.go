@@ -0,0 +1,328 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	artrepomocks "github.com/zmsocc/practice/webook/internal/repository/articles/mocks"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"github.com/zmsocc/practice/webook/internal/service/storage"
+	storagemocks "github.com/zmsocc/practice/webook/internal/service/storage/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+type accountServiceMocks struct {
+	userRepo   *repomocks.MockUserRepository
+	artRepo    *artrepomocks.MockArticleRepository
+	intrRepo   *repomocks.MockInteractiveRepository
+	exportRepo *repomocks.MockUserExportRepository
+	store      *storagemocks.MockService
+}
+
+func newAccountServiceMocks(ctrl *gomock.Controller) accountServiceMocks {
+	return accountServiceMocks{
+		userRepo:   repomocks.NewMockUserRepository(ctrl),
+		artRepo:    artrepomocks.NewMockArticleRepository(ctrl),
+		intrRepo:   repomocks.NewMockInteractiveRepository(ctrl),
+		exportRepo: repomocks.NewMockUserExportRepository(ctrl),
+		store:      storagemocks.NewMockService(ctrl),
+	}
+}
+
+func (m accountServiceMocks) newService() AccountService {
+	return NewAccountService(m.userRepo, m.artRepo, m.intrRepo, m.exportRepo, nil, m.store,
+		time.Hour*24*7, logger.NewNopLogger())
+}
+
+func exportTaskWithStatus(status domain.ExportStatus) gomock.Matcher {
+	return gomock.Cond(func(task domain.ExportTask) bool {
+		return task.Uid == 1 && task.Status == status
+	})
+}
+
+func TestAccountService_Export(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name string
+		// 后台打包结束的时候关闭 done
+		mock func(m accountServiceMocks, done chan struct{})
+
+		wantErr error
+		// 是不是开始在后台打包了
+		wantRun bool
+	}{
+		{
+			name: "第一次导出",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).
+					Return(domain.ExportTask{}, repository.ErrExportTaskNotFound)
+				m.exportRepo.EXPECT().Lock(gomock.Any(), int64(1), time.Minute*10).Return(true, nil)
+				m.exportRepo.EXPECT().SaveTask(gomock.Any(), exportTaskWithStatus(domain.ExportStatusPending)).
+					Return(nil)
+
+				m.userRepo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Email: "123@qq.com"}, nil)
+				m.artRepo.EXPECT().ListAll(gomock.Any(), int64(1)).
+					Return([]domain.Article{{Id: 2, Title: "我的标题"}}, nil)
+				m.intrRepo.EXPECT().Likes(gomock.Any(), int64(1)).Return(nil, nil)
+				m.intrRepo.EXPECT().Collections(gomock.Any(), int64(1)).Return(nil, nil)
+				m.intrRepo.EXPECT().ReadHistory(gomock.Any(), int64(1)).Return(nil, nil)
+				var key string
+				m.store.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), "application/zip").
+					DoAndReturn(func(ctx context.Context, k string, data []byte, contentType string) (string, error) {
+						key = k
+						zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+						if err != nil || len(zr.File) != 5 {
+							return "", errors.New("zip 不对")
+						}
+						return "", nil
+					})
+				m.exportRepo.EXPECT().SaveTask(gomock.Any(), exportTaskWithStatus(domain.ExportStatusDone)).
+					DoAndReturn(func(ctx context.Context, task domain.ExportTask) error {
+						if !strings.HasPrefix(task.Key, "exports/1/") || task.Key != key {
+							return errors.New("key 不对")
+						}
+						return nil
+					})
+				m.exportRepo.EXPECT().Unlock(gomock.Any(), int64(1)).
+					DoAndReturn(func(ctx context.Context, uid int64) error {
+						close(done)
+						return nil
+					})
+			},
+			wantRun: true,
+		},
+		{
+			name: "删除上一次的文件，打包失败",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid:    1,
+					Status: domain.ExportStatusDone,
+					Key:    "exports/1/old.zip",
+					Ctime:  now.Add(-time.Hour * 2),
+				}, nil)
+				m.exportRepo.EXPECT().Lock(gomock.Any(), int64(1), time.Minute*10).Return(true, nil)
+				m.exportRepo.EXPECT().SaveTask(gomock.Any(), exportTaskWithStatus(domain.ExportStatusPending)).
+					Return(nil)
+
+				m.store.EXPECT().Delete(gomock.Any(), "exports/1/old.zip").Return(nil)
+				m.userRepo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{}, errors.New("mock error"))
+				m.exportRepo.EXPECT().SaveTask(gomock.Any(), exportTaskWithStatus(domain.ExportStatusFailed)).
+					Return(nil)
+				m.exportRepo.EXPECT().Unlock(gomock.Any(), int64(1)).
+					DoAndReturn(func(ctx context.Context, uid int64) error {
+						close(done)
+						return nil
+					})
+			},
+			wantRun: true,
+		},
+		{
+			name: "正在打包",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid:    1,
+					Status: domain.ExportStatusPending,
+					Ctime:  now,
+				}, nil)
+				m.exportRepo.EXPECT().Lock(gomock.Any(), int64(1), time.Minute*10).Return(false, nil)
+			},
+			wantErr: ErrExportInProgress,
+		},
+		{
+			name: "一小时内导出过",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid:    1,
+					Status: domain.ExportStatusDone,
+					Ctime:  now.Add(-time.Minute * 10),
+				}, nil)
+			},
+			wantErr: ErrExportTooFrequent,
+		},
+		{
+			name: "加锁失败",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).
+					Return(domain.ExportTask{}, repository.ErrExportTaskNotFound)
+				m.exportRepo.EXPECT().Lock(gomock.Any(), int64(1), time.Minute*10).
+					Return(false, errors.New("mock error"))
+			},
+			wantErr: errors.New("mock error"),
+		},
+		{
+			name: "保存任务失败要释放锁",
+			mock: func(m accountServiceMocks, done chan struct{}) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).
+					Return(domain.ExportTask{}, repository.ErrExportTaskNotFound)
+				m.exportRepo.EXPECT().Lock(gomock.Any(), int64(1), time.Minute*10).Return(true, nil)
+				m.exportRepo.EXPECT().SaveTask(gomock.Any(), gomock.Any()).Return(errors.New("mock error"))
+				m.exportRepo.EXPECT().Unlock(gomock.Any(), int64(1)).Return(nil)
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := newAccountServiceMocks(ctrl)
+			done := make(chan struct{})
+			tc.mock(m, done)
+
+			task, err := m.newService().Export(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			if !tc.wantRun {
+				return
+			}
+			assert.Equal(t, domain.ExportStatusPending, task.Status)
+			select {
+			case <-done:
+			case <-time.After(time.Second * 5):
+				t.Fatal("后台打包没有结束")
+			}
+		})
+	}
+}
+
+func TestAccountService_ExportFile(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(m accountServiceMocks)
+
+		wantData []byte
+		wantErr  error
+	}{
+		{
+			name: "打包好了",
+			mock: func(m accountServiceMocks) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid: 1, Status: domain.ExportStatusDone, Key: "exports/1/123.zip",
+				}, nil)
+				m.store.EXPECT().Get(gomock.Any(), "exports/1/123.zip").Return([]byte("zip"), nil)
+			},
+			wantData: []byte("zip"),
+		},
+		{
+			name: "还在打包",
+			mock: func(m accountServiceMocks) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid: 1, Status: domain.ExportStatusPending,
+				}, nil)
+			},
+			wantErr: ErrExportNotReady,
+		},
+		{
+			name: "打包失败了",
+			mock: func(m accountServiceMocks) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid: 1, Status: domain.ExportStatusFailed,
+				}, nil)
+			},
+			wantErr: ErrExportNotReady,
+		},
+		{
+			name: "没有导出任务",
+			mock: func(m accountServiceMocks) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).
+					Return(domain.ExportTask{}, repository.ErrExportTaskNotFound)
+			},
+			wantErr: ErrExportTaskNotFound,
+		},
+		{
+			name: "文件已经被删了",
+			mock: func(m accountServiceMocks) {
+				m.exportRepo.EXPECT().GetTask(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid: 1, Status: domain.ExportStatusDone, Key: "exports/1/123.zip",
+				}, nil)
+				m.store.EXPECT().Get(gomock.Any(), "exports/1/123.zip").Return(nil, storage.ErrObjectNotFound)
+			},
+			wantErr: ErrExportTaskNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := newAccountServiceMocks(ctrl)
+			tc.mock(m)
+
+			data, err := m.newService().ExportFile(context.Background(), 1)
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantData, data)
+		})
+	}
+}
+
+func TestAccountService_CleanExports(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	testCases := []struct {
+		name string
+		mock func(m accountServiceMocks)
+
+		wantCnt int
+		wantErr error
+	}{
+		{
+			name: "只删过期的",
+			mock: func(m accountServiceMocks) {
+				m.store.EXPECT().List(gomock.Any(), "exports/").Return([]storage.Object{
+					{Key: "exports/1/1.zip", Mtime: now.Add(-time.Hour * 25)},
+					{Key: "exports/2/2.zip", Mtime: now.Add(-time.Hour)},
+					{Key: "exports/3/3.zip", Mtime: now.Add(-time.Hour * 24)},
+				}, nil)
+				m.store.EXPECT().Delete(gomock.Any(), "exports/1/1.zip").Return(nil)
+				m.store.EXPECT().Delete(gomock.Any(), "exports/3/3.zip").Return(nil)
+			},
+			wantCnt: 2,
+		},
+		{
+			name: "没有文件",
+			mock: func(m accountServiceMocks) {
+				m.store.EXPECT().List(gomock.Any(), "exports/").Return(nil, nil)
+			},
+		},
+		{
+			name: "列出文件失败",
+			mock: func(m accountServiceMocks) {
+				m.store.EXPECT().List(gomock.Any(), "exports/").Return(nil, errors.New("list 失败"))
+			},
+			wantErr: errors.New("list 失败"),
+		},
+		{
+			name: "删除失败",
+			mock: func(m accountServiceMocks) {
+				m.store.EXPECT().List(gomock.Any(), "exports/").Return([]storage.Object{
+					{Key: "exports/1/1.zip", Mtime: now.Add(-time.Hour * 25)},
+					{Key: "exports/2/2.zip", Mtime: now.Add(-time.Hour * 25)},
+				}, nil)
+				m.store.EXPECT().Delete(gomock.Any(), "exports/1/1.zip").Return(nil)
+				m.store.EXPECT().Delete(gomock.Any(), "exports/2/2.zip").Return(errors.New("delete 失败"))
+			},
+			wantCnt: 1,
+			wantErr: errors.New("delete 失败"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := newAccountServiceMocks(ctrl)
+			tc.mock(m)
+
+			cnt, err := m.newService().CleanExports(context.Background(), now)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCnt, cnt)
+		})
+	}
+}
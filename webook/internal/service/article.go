@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/event/article"
+	"github.com/zmsocc/practice/webook/internal/repository"
 	"github.com/zmsocc/practice/webook/internal/repository/articles"
 	"github.com/zmsocc/practice/webook/pkg/logger"
 )
@@ -12,6 +13,8 @@ type ArticleService interface {
 	Save(ctx context.Context, art domain.Article) (int64, error)
 	Publish(ctx context.Context, art domain.Article) (int64, error)
 	Withdraw(ctx context.Context, art domain.Article) error
+	// TakeDown 管理员下架违规文章，和作者自己撤回一样，设置为仅作者可见
+	TakeDown(ctx context.Context, id int64) error
 	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
 	GetById(ctx context.Context, id int64) (domain.Article, error)
 	GetPubById(ctx context.Context, id, uid int64) (domain.Article, error)
@@ -23,12 +26,14 @@ type articleService struct {
 	reader   articles.ArticleReaderRepository
 	l        logger.Logger
 	producer article.Producer
+	userRepo repository.UserRepository
 }
 
-func NewArticleService(repo articles.ArticleRepository, l logger.Logger,
-	producer article.Producer) ArticleService {
+func NewArticleService(repo articles.ArticleRepository, userRepo repository.UserRepository,
+	l logger.Logger, producer article.Producer) ArticleService {
 	return &articleService{
 		repo:     repo,
+		userRepo: userRepo,
 		l:        l,
 		producer: producer,
 	}
@@ -44,6 +49,9 @@ func (svc *articleService) Save(ctx context.Context, art domain.Article) (int64,
 }
 
 func (svc *articleService) Publish(ctx context.Context, art domain.Article) (int64, error) {
+	if err := checkWritable(ctx, svc.userRepo, art.Author.Id); err != nil {
+		return 0, err
+	}
 	art.Status = domain.ArticleStatusPublished
 	return svc.repo.Sync(ctx, art)
 }
@@ -52,6 +60,14 @@ func (svc *articleService) Withdraw(ctx context.Context, art domain.Article) err
 	return svc.repo.SyncStatus(ctx, art.Id, art.Author.Id, domain.ArticleStatusPrivate)
 }
 
+func (svc *articleService) TakeDown(ctx context.Context, id int64) error {
+	art, err := svc.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return svc.repo.SyncStatus(ctx, id, art.Author.Id, domain.ArticleStatusPrivate)
+}
+
 func (svc *articleService) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error) {
 	return svc.repo.List(ctx, uid, offset, limit)
 }
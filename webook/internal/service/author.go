@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/repository/articles"
+)
+
+// AuthorService 作者公开主页
+type AuthorService interface {
+	Profile(ctx context.Context, uid int64) (domain.AuthorProfile, error)
+	// ListPublished 作者已发表的文章，分页
+	ListPublished(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
+}
+
+type authorService struct {
+	userRepo   repository.UserRepository
+	artRepo    articles.ArticleRepository
+	intrRepo   repository.InteractiveRepository
+	followRepo repository.FollowRepository
+}
+
+func NewAuthorService(userRepo repository.UserRepository, artRepo articles.ArticleRepository,
+	intrRepo repository.InteractiveRepository, followRepo repository.FollowRepository) AuthorService {
+	return &authorService{
+		userRepo:   userRepo,
+		artRepo:    artRepo,
+		intrRepo:   intrRepo,
+		followRepo: followRepo,
+	}
+}
+
+func (svc *authorService) Profile(ctx context.Context, uid int64) (domain.AuthorProfile, error) {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return domain.AuthorProfile{}, ErrUserNotFound
+	}
+	if err != nil {
+		return domain.AuthorProfile{}, err
+	}
+	ids, err := svc.artRepo.PubIds(ctx, uid)
+	if err != nil {
+		return domain.AuthorProfile{}, err
+	}
+	intr, err := svc.intrRepo.Sum(ctx, "article", ids)
+	if err != nil {
+		return domain.AuthorProfile{}, err
+	}
+	fs, err := svc.followRepo.GetStatics(ctx, uid)
+	if err != nil {
+		return domain.AuthorProfile{}, err
+	}
+	return domain.AuthorProfile{
+		Id:         u.Id,
+		Nickname:   u.Nickname,
+		AboutMe:    u.AboutMe,
+		Avatar:     u.Avatar,
+		Ctime:      u.Ctime,
+		ArticleCnt: int64(len(ids)),
+		ReadCnt:    intr.ReadCnt,
+		LikeCnt:    intr.LikeCnt,
+		CollectCnt: intr.CollectCnt,
+		Followers:  fs.Followers,
+		Followees:  fs.Followees,
+	}, nil
+}
+
+func (svc *authorService) ListPublished(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error) {
+	return svc.artRepo.ListPub(ctx, uid, offset, limit)
+}
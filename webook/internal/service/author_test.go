@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	artrepomocks "github.com/zmsocc/practice/webook/internal/repository/articles/mocks"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type authorServiceMocks struct {
+	userRepo   *repomocks.MockUserRepository
+	artRepo    *artrepomocks.MockArticleRepository
+	intrRepo   *repomocks.MockInteractiveRepository
+	followRepo *repomocks.MockFollowRepository
+}
+
+func TestAuthorService_Profile(t *testing.T) {
+	ctime := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+	testCases := []struct {
+		name string
+		mock func(m authorServiceMocks)
+
+		wantProfile domain.AuthorProfile
+		wantErr     error
+	}{
+		{
+			name: "汇总已发表文章的计数",
+			mock: func(m authorServiceMocks) {
+				m.userRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Nickname: "大明", AboutMe: "写代码的", Avatar: "/uploads/avatars/1.jpg", Ctime: ctime,
+				}, nil)
+				m.artRepo.EXPECT().PubIds(gomock.Any(), int64(1)).Return([]int64{2, 3}, nil)
+				m.intrRepo.EXPECT().Sum(gomock.Any(), "article", []int64{2, 3}).Return(domain.Interactive{
+					ReadCnt: 100, LikeCnt: 10, CollectCnt: 5,
+				}, nil)
+				m.followRepo.EXPECT().GetStatics(gomock.Any(), int64(1)).Return(domain.FollowStatics{
+					Uid: 1, Followers: 7, Followees: 3,
+				}, nil)
+			},
+			wantProfile: domain.AuthorProfile{
+				Id:         1,
+				Nickname:   "大明",
+				AboutMe:    "写代码的",
+				Avatar:     "/uploads/avatars/1.jpg",
+				Ctime:      ctime,
+				ArticleCnt: 2,
+				ReadCnt:    100,
+				LikeCnt:    10,
+				CollectCnt: 5,
+				Followers:  7,
+				Followees:  3,
+			},
+		},
+		{
+			name: "作者不存在",
+			mock: func(m authorServiceMocks) {
+				m.userRepo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{}, repository.ErrUserNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "查询计数失败",
+			mock: func(m authorServiceMocks) {
+				m.userRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				m.artRepo.EXPECT().PubIds(gomock.Any(), int64(1)).Return([]int64{2}, nil)
+				m.intrRepo.EXPECT().Sum(gomock.Any(), "article", []int64{2}).
+					Return(domain.Interactive{}, errors.New("mock error"))
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := authorServiceMocks{
+				userRepo:   repomocks.NewMockUserRepository(ctrl),
+				artRepo:    artrepomocks.NewMockArticleRepository(ctrl),
+				intrRepo:   repomocks.NewMockInteractiveRepository(ctrl),
+				followRepo: repomocks.NewMockFollowRepository(ctrl),
+			}
+			tc.mock(m)
+			svc := NewAuthorService(m.userRepo, m.artRepo, m.intrRepo, m.followRepo)
+			p, err := svc.Profile(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantProfile, p)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/service/storage"
+	"github.com/zmsocc/practice/webook/pkg/imagex"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxAvatarSize 上传的原图最大 5M
+	MaxAvatarSize = 5 << 20
+	// 原图的宽高限制，防止很小的文件解压出来一张巨大的图
+	maxAvatarPixels = 4096
+
+	avatarSize      = 256
+	avatarThumbSize = 64
+)
+
+var (
+	ErrAvatarTooLarge    = errors.New("头像文件太大")
+	ErrInvalidAvatarType = errors.New("头像只支持 JPEG、PNG、GIF")
+)
+
+// AvatarService 上传头像，原图不保存，只保存裁剪缩放之后的大小两张图
+type AvatarService interface {
+	Upload(ctx context.Context, uid int64, data []byte) (domain.User, error)
+}
+
+type avatarService struct {
+	repo    repository.UserRepository
+	storage storage.Service
+}
+
+func NewAvatarService(repo repository.UserRepository, storage storage.Service) AvatarService {
+	return &avatarService{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+func (svc *avatarService) Upload(ctx context.Context, uid int64, data []byte) (domain.User, error) {
+	if len(data) > MaxAvatarSize {
+		return domain.User{}, ErrAvatarTooLarge
+	}
+	// 不相信前端传的 Content-Type，按文件内容判断
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return domain.User{}, ErrInvalidAvatarType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return domain.User{}, ErrInvalidAvatarType
+	}
+	if cfg.Width > maxAvatarPixels || cfg.Height > maxAvatarPixels {
+		return domain.User{}, ErrAvatarTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return domain.User{}, ErrInvalidAvatarType
+	}
+
+	// 同一个用户的头像放在固定的位置，覆盖旧的，URL 带上版本号让浏览器和 CDN 的缓存失效
+	version := time.Now().UnixMilli()
+	avatar, err := svc.put(ctx, fmt.Sprintf("avatars/%d_%d.jpg", uid, avatarSize), img, avatarSize, version)
+	if err != nil {
+		return domain.User{}, err
+	}
+	thumb, err := svc.put(ctx, fmt.Sprintf("avatars/%d_%d.jpg", uid, avatarThumbSize), img, avatarThumbSize, version)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if err = svc.repo.UpdateAvatar(ctx, uid, avatar, thumb); err != nil {
+		return domain.User{}, err
+	}
+	return domain.User{
+		Id:          uid,
+		Avatar:      avatar,
+		AvatarThumb: thumb,
+	}, nil
+}
+
+func (svc *avatarService) put(ctx context.Context, key string, img image.Image, size int, version int64) (string, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, imagex.Thumbnail(img, size), &jpeg.Options{Quality: 85})
+	if err != nil {
+		return "", err
+	}
+	url, err := svc.storage.Put(ctx, key, buf.Bytes(), "image/jpeg")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s?v=%d", url, version), nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	storagemocks "github.com/zmsocc/practice/webook/internal/service/storage/mocks"
+	"go.uber.org/mock/gomock"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAvatarService_Upload(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService)
+		data []byte
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "上传成功",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				store := storagemocks.NewMockService(ctrl)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_256.jpg", jpegOfSize(256), "image/jpeg").
+					Return("/static/avatars/1_256.jpg", nil)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_64.jpg", jpegOfSize(64), "image/jpeg").
+					Return("/static/avatars/1_64.jpg", nil)
+				repo.EXPECT().UpdateAvatar(gomock.Any(), int64(1),
+					withVersion("/static/avatars/1_256.jpg"), withVersion("/static/avatars/1_64.jpg")).
+					Return(nil)
+				return repo, store
+			},
+			data: encodePNG(t, 300, 200),
+			wantUser: domain.User{
+				Id:          1,
+				Avatar:      "/static/avatars/1_256.jpg",
+				AvatarThumb: "/static/avatars/1_64.jpg",
+			},
+		},
+		{
+			name: "文件太大",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				return repomocks.NewMockUserRepository(ctrl), storagemocks.NewMockService(ctrl)
+			},
+			data:    append(encodePNG(t, 10, 10), make([]byte, MaxAvatarSize)...),
+			wantErr: ErrAvatarTooLarge,
+		},
+		{
+			name: "图片尺寸太大",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				return repomocks.NewMockUserRepository(ctrl), storagemocks.NewMockService(ctrl)
+			},
+			data:    encodePNG(t, maxAvatarPixels+1, 1),
+			wantErr: ErrAvatarTooLarge,
+		},
+		{
+			name: "不是图片",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				return repomocks.NewMockUserRepository(ctrl), storagemocks.NewMockService(ctrl)
+			},
+			data:    []byte("<html><body>hello</body></html>"),
+			wantErr: ErrInvalidAvatarType,
+		},
+		{
+			name: "文件头是 PNG 但是内容坏了",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				return repomocks.NewMockUserRepository(ctrl), storagemocks.NewMockService(ctrl)
+			},
+			data:    append([]byte("\x89PNG\r\n\x1a\n"), []byte("broken")...),
+			wantErr: ErrInvalidAvatarType,
+		},
+		{
+			name: "保存大图失败",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				store := storagemocks.NewMockService(ctrl)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_256.jpg", gomock.Any(), "image/jpeg").
+					Return("", errors.New("存储出错"))
+				return repomocks.NewMockUserRepository(ctrl), store
+			},
+			data:    encodePNG(t, 100, 100),
+			wantErr: errors.New("存储出错"),
+		},
+		{
+			name: "保存小图失败",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				store := storagemocks.NewMockService(ctrl)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_256.jpg", gomock.Any(), "image/jpeg").
+					Return("/static/avatars/1_256.jpg", nil)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_64.jpg", gomock.Any(), "image/jpeg").
+					Return("", errors.New("存储出错"))
+				return repomocks.NewMockUserRepository(ctrl), store
+			},
+			data:    encodePNG(t, 100, 100),
+			wantErr: errors.New("存储出错"),
+		},
+		{
+			name: "更新数据库失败",
+			mock: func(ctrl *gomock.Controller) (*repomocks.MockUserRepository, *storagemocks.MockService) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				store := storagemocks.NewMockService(ctrl)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_256.jpg", gomock.Any(), "image/jpeg").
+					Return("/static/avatars/1_256.jpg", nil)
+				store.EXPECT().Put(gomock.Any(), "avatars/1_64.jpg", gomock.Any(), "image/jpeg").
+					Return("/static/avatars/1_64.jpg", nil)
+				repo.EXPECT().UpdateAvatar(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+					Return(errors.New("数据库出错"))
+				return repo, store
+			},
+			data:    encodePNG(t, 100, 100),
+			wantErr: errors.New("数据库出错"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, store := tc.mock(ctrl)
+			svc := NewAvatarService(repo, store)
+
+			u, err := svc.Upload(context.Background(), 1, tc.data)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			// URL 后面的版本号是当前时间，只比较前面的部分
+			assert.Equal(t, tc.wantUser.Id, u.Id)
+			assert.True(t, strings.HasPrefix(u.Avatar, tc.wantUser.Avatar+"?v="))
+			assert.True(t, strings.HasPrefix(u.AvatarThumb, tc.wantUser.AvatarThumb+"?v="))
+		})
+	}
+}
+
+// 换头像的时候覆盖同一个 key 上的旧图，靠 URL 上的版本号让缓存失效
+func TestAvatarService_UploadReplace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockUserRepository(ctrl)
+	store := storagemocks.NewMockService(ctrl)
+	store.EXPECT().Put(gomock.Any(), "avatars/1_256.jpg", gomock.Any(), "image/jpeg").
+		Return("/static/avatars/1_256.jpg", nil).Times(2)
+	store.EXPECT().Put(gomock.Any(), "avatars/1_64.jpg", gomock.Any(), "image/jpeg").
+		Return("/static/avatars/1_64.jpg", nil).Times(2)
+	repo.EXPECT().UpdateAvatar(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	svc := NewAvatarService(repo, store)
+
+	old, err := svc.Upload(context.Background(), 1, encodePNG(t, 100, 100))
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 2)
+	u, err := svc.Upload(context.Background(), 1, encodePNG(t, 120, 80))
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Avatar, u.Avatar)
+	assert.NotEqual(t, old.AvatarThumb, u.AvatarThumb)
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// jpegOfSize 存进去的是 size x size 的 JPEG
+func jpegOfSize(size int) gomock.Matcher {
+	return gomock.Cond(func(data []byte) bool {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		return err == nil && cfg.Width == size && cfg.Height == size
+	})
+}
+
+func withVersion(url string) gomock.Matcher {
+	return gomock.Cond(func(u string) bool {
+		return strings.HasPrefix(u, url+"?v=")
+	})
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/pkg/captcha"
+	"time"
+)
+
+// 需要图形验证码的场景
+const (
+	CaptchaSceneSMSLogin = "sms_login"
+	CaptchaSceneSignup   = "signup"
+)
+
+const (
+	captchaLength     = 4
+	captchaExpiration = time.Minute * 5
+)
+
+var (
+	ErrCaptchaRequired = errors.New("需要图形验证码")
+	ErrInvalidCaptcha  = errors.New("图形验证码错误")
+)
+
+// CaptchaPolicy 同一个 IP 在 Window 内请求超过 Threshold 次之后，就要求输入图形验证码
+type CaptchaPolicy struct {
+	Threshold int64
+	Window    time.Duration
+}
+
+// CaptchaService 图形验证码。平时不打扰正常用户，某个 IP 请求太多了才要求输入
+type CaptchaService interface {
+	// Generate 生成一个验证码，返回 id 和 PNG 图片，答案存在 redis 里面
+	Generate(ctx context.Context) (string, []byte, error)
+	// Check 记录一次 scene 场景下 ip 的请求，超过阈值之后必须带上正确的验证码，
+	// 没带返回 ErrCaptchaRequired，不对返回 ErrInvalidCaptcha。验证码不管对不对都只能用一次
+	Check(ctx context.Context, scene, ip, id, answer string) error
+}
+
+type captchaService struct {
+	repo     repository.CaptchaRepository
+	policies map[string]CaptchaPolicy
+}
+
+func NewCaptchaService(repo repository.CaptchaRepository) CaptchaService {
+	return &captchaService{
+		repo: repo,
+		policies: map[string]CaptchaPolicy{
+			// 短信是要花钱的，阈值低一点
+			CaptchaSceneSMSLogin: {Threshold: 5, Window: time.Hour},
+			CaptchaSceneSignup:   {Threshold: 3, Window: time.Hour},
+		},
+	}
+}
+
+func (svc *captchaService) Generate(ctx context.Context) (string, []byte, error) {
+	answer, err := captcha.RandomDigits(captchaLength)
+	if err != nil {
+		return "", nil, err
+	}
+	img, err := captcha.PNG(answer)
+	if err != nil {
+		return "", nil, err
+	}
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(buf)
+	if err = svc.repo.Store(ctx, id, answer, captchaExpiration); err != nil {
+		return "", nil, err
+	}
+	return id, img, nil
+}
+
+func (svc *captchaService) Check(ctx context.Context, scene, ip, id, answer string) error {
+	// 没有配置过的场景每次都要验证码
+	p := svc.policies[scene]
+	cnt, err := svc.repo.IncrRequest(ctx, scene, ip, p.Window)
+	if err != nil {
+		return err
+	}
+	if cnt <= p.Threshold {
+		return nil
+	}
+	if id == "" || answer == "" {
+		return ErrCaptchaRequired
+	}
+	want, err := svc.repo.Take(ctx, id)
+	if errors.Is(err, repository.ErrCaptchaNotFound) {
+		return ErrInvalidCaptcha
+	}
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(want), []byte(answer)) != 1 {
+		return ErrInvalidCaptcha
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/repository/cache"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestCaptchaService_Check(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) repository.CaptchaRepository
+		scene  string
+		id     string
+		answer string
+
+		wantErr error
+	}{
+		{
+			name: "没有超过阈值，不需要验证码",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSMSLogin, "127.0.0.1", time.Hour).
+					Return(int64(5), nil)
+				return repo
+			},
+			scene: CaptchaSceneSMSLogin,
+		},
+		{
+			name: "超过阈值，没带验证码",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSMSLogin, "127.0.0.1", time.Hour).
+					Return(int64(6), nil)
+				return repo
+			},
+			scene:   CaptchaSceneSMSLogin,
+			wantErr: ErrCaptchaRequired,
+		},
+		{
+			name: "验证码正确",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSignup, "127.0.0.1", time.Hour).
+					Return(int64(4), nil)
+				repo.EXPECT().Take(gomock.Any(), "id1").Return("1234", nil)
+				return repo
+			},
+			scene:  CaptchaSceneSignup,
+			id:     "id1",
+			answer: "1234",
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSignup, "127.0.0.1", time.Hour).
+					Return(int64(4), nil)
+				repo.EXPECT().Take(gomock.Any(), "id1").Return("1234", nil)
+				return repo
+			},
+			scene:   CaptchaSceneSignup,
+			id:      "id1",
+			answer:  "4321",
+			wantErr: ErrInvalidCaptcha,
+		},
+		{
+			name: "验证码过期或者已经用过了",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSignup, "127.0.0.1", time.Hour).
+					Return(int64(4), nil)
+				repo.EXPECT().Take(gomock.Any(), "id1").Return("", repository.ErrCaptchaNotFound)
+				return repo
+			},
+			scene:   CaptchaSceneSignup,
+			id:      "id1",
+			answer:  "1234",
+			wantErr: ErrInvalidCaptcha,
+		},
+		{
+			name: "没配置的场景每次都要验证码",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), "unknown", "127.0.0.1", time.Duration(0)).
+					Return(int64(1), nil)
+				return repo
+			},
+			scene:   "unknown",
+			wantErr: ErrCaptchaRequired,
+		},
+		{
+			name: "计数出错",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSMSLogin, "127.0.0.1", time.Hour).
+					Return(int64(0), errors.New("redis 出错"))
+				return repo
+			},
+			scene:   CaptchaSceneSMSLogin,
+			wantErr: errors.New("redis 出错"),
+		},
+		{
+			name: "取验证码出错",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().IncrRequest(gomock.Any(), CaptchaSceneSMSLogin, "127.0.0.1", time.Hour).
+					Return(int64(6), nil)
+				repo.EXPECT().Take(gomock.Any(), "id1").Return("", errors.New("redis 出错"))
+				return repo
+			},
+			scene:   CaptchaSceneSMSLogin,
+			id:      "id1",
+			answer:  "1234",
+			wantErr: errors.New("redis 出错"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewCaptchaService(tc.mock(ctrl))
+
+			err := svc.Check(context.Background(), tc.scene, "127.0.0.1", tc.id, tc.answer)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+// 下面用 miniredis 跑真实的过期、删除和计数
+func newRedisCaptchaService(t *testing.T) (CaptchaService, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	c := cache.NewCaptchaCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	return NewCaptchaService(repository.NewCaptchaRepository(c)), mr
+}
+
+// exhaust 用掉 scene 下 ip 不需要验证码的次数
+func exhaust(t *testing.T, svc CaptchaService, scene, ip string, threshold int) {
+	for i := 0; i < threshold; i++ {
+		require.NoError(t, svc.Check(context.Background(), scene, ip, "", ""))
+	}
+}
+
+func answerOf(t *testing.T, mr *miniredis.Miniredis, id string) string {
+	answer, err := mr.Get("captcha:" + id)
+	require.NoError(t, err)
+	return answer
+}
+
+func TestCaptchaService_CheckOnce(t *testing.T) {
+	svc, mr := newRedisCaptchaService(t)
+	ctx := context.Background()
+	exhaust(t, svc, CaptchaSceneSignup, "127.0.0.1", 3)
+
+	id, img, err := svc.Generate(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, img)
+	answer := answerOf(t, mr, id)
+	assert.Len(t, answer, captchaLength)
+
+	require.NoError(t, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", id, answer))
+	// 同一个验证码不能再用
+	assert.Equal(t, ErrInvalidCaptcha, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", id, answer))
+
+	// 答错了也作废，不能一直猜
+	id, _, err = svc.Generate(ctx)
+	require.NoError(t, err)
+	answer = answerOf(t, mr, id)
+	assert.Equal(t, ErrInvalidCaptcha, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", id, "wrong"))
+	assert.Equal(t, ErrInvalidCaptcha, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", id, answer))
+}
+
+func TestCaptchaService_CheckExpired(t *testing.T) {
+	svc, mr := newRedisCaptchaService(t)
+	ctx := context.Background()
+	exhaust(t, svc, CaptchaSceneSignup, "127.0.0.1", 3)
+
+	id, _, err := svc.Generate(ctx)
+	require.NoError(t, err)
+	answer := answerOf(t, mr, id)
+	mr.FastForward(captchaExpiration)
+	assert.Equal(t, ErrInvalidCaptcha, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", id, answer))
+}
+
+func TestCaptchaService_CheckPerScene(t *testing.T) {
+	svc, mr := newRedisCaptchaService(t)
+	ctx := context.Background()
+
+	// 注册的次数用完了，不影响短信登录，也不影响别的 IP
+	exhaust(t, svc, CaptchaSceneSignup, "127.0.0.1", 3)
+	assert.Equal(t, ErrCaptchaRequired, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", "", ""))
+	assert.NoError(t, svc.Check(ctx, CaptchaSceneSMSLogin, "127.0.0.1", "", ""))
+	assert.NoError(t, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.2", "", ""))
+
+	// 窗口过了重新计数
+	mr.FastForward(time.Hour)
+	assert.NoError(t, svc.Check(ctx, CaptchaSceneSignup, "127.0.0.1", "", ""))
+}
@@ -2,49 +2,78 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/service/email"
 	"github.com/zmsocc/practice/webook/internal/service/sms"
-	"go.uber.org/atomic"
-	"log"
 	"math/rand"
 )
 
 var (
 	ErrCodeSendTooMany   = repository.ErrCodeSendTooMany
-	codeTplId            = atomic.String{}
 	ErrCodeVerifyTooMany = repository.ErrCodeVerifyTooMany
+	// ErrCodeSendTooManyToday 这个手机号今天收到的短信太多了
+	ErrCodeSendTooManyToday = errors.New("今天发送验证码的次数太多了")
+	// ErrSMSBusy 短信整体的发送速率超过限制了
+	ErrSMSBusy = errors.New("短信服务繁忙")
 )
 
 type CodeService interface {
 	Send(ctx context.Context, biz, phone string) error
+	// SendEmail 验证码发到邮箱里面，验证的时候一样用 Verify
+	SendEmail(ctx context.Context, biz, email string) error
 	Verify(ctx context.Context, biz, phone, code string) (bool, error)
 }
 
 type codeService struct {
-	repo   repository.CodeRepository
-	smsSvc sms.Service
+	repo     repository.CodeRepository
+	smsSvc   sms.Service
+	emailSvc email.Service
 }
 
-func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service) CodeService {
-	codeTplId.Store("1877556")
+func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service,
+	emailSvc email.Service) CodeService {
 	return &codeService{
-		repo:   repo,
-		smsSvc: smsSvc,
+		repo:     repo,
+		smsSvc:   smsSvc,
+		emailSvc: emailSvc,
 	}
 }
 
-// Send -biz 区别业务场景
+// Send -biz 区别业务场景，短信模板按照 biz 在模板注册表里面找
 func (svc *codeService) Send(ctx context.Context, biz string, phone string) error {
 	code := svc.generateCode()
+	// 存不进去的话发出去的验证码也没法验证，发送太频繁也是在这里拦下来的
 	if err := svc.repo.Store(ctx, biz, phone, code); err != nil {
-		log.Printf("验证码存储失败：biz=%s, phone=%s, err=%v", biz, phone, err)
+		return err
+	}
+	err := svc.smsSvc.Send(ctx, biz, []string{code}, phone)
+	var lerr *sms.LimitedError
+	if errors.As(err, &lerr) {
+		if lerr.Scope == sms.LimitScopeNumber {
+			return ErrCodeSendTooManyToday
+		}
+		return ErrSMSBusy
 	}
-	err := svc.smsSvc.Send(ctx, codeTplId.Load(), []string{code}, phone)
 	if err != nil {
 		return fmt.Errorf("发送短信出现异常 %w", err)
 	}
-	return err
+	return nil
+}
+
+func (svc *codeService) SendEmail(ctx context.Context, biz string, email string) error {
+	code := svc.generateCode()
+	// 邮箱和手机号共用一套存储，发送频率和验证次数的限制也一样
+	if err := svc.repo.Store(ctx, biz, email, code); err != nil {
+		return err
+	}
+	err := svc.emailSvc.Send(ctx, "webook 验证码",
+		fmt.Sprintf("您的验证码是 %s，10 分钟内有效，请勿泄露给他人。", code), email)
+	if err != nil {
+		return fmt.Errorf("发送邮件出现异常 %w", err)
+	}
+	return nil
 }
 
 func (svc *codeService) Verify(ctx context.Context, biz, phone, code string) (bool, error) {
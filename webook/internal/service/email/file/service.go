@@ -0,0 +1,35 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service 把邮件追加写到一个本地文件里面，本地开发的时候代替真正的邮件服务
+type Service struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewService(path string) *Service {
+	return &Service{
+		path: path,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, subject, content string, to ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开邮件文件失败 %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), strings.Join(to, ", "), subject, content)
+	return err
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+)
+
+// Service 本地开发用的，不真的发邮件，只写日志。
+// 邮件内容里面有验证码，不输出，要看验证码的话配置 email.file
+type Service struct {
+	l logger.Logger
+}
+
+func NewService(l logger.Logger) *Service {
+	return &Service{
+		l: l,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, subject, content string, to ...string) error {
+	for _, addr := range to {
+		s.l.Debug("模拟发送邮件",
+			logger.Email("to", addr),
+			logger.String("subject", subject))
+	}
+	return nil
+}
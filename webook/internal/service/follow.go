@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+)
+
+var ErrFollowSelf = errors.New("不能关注自己")
+
+type FollowService interface {
+	Follow(ctx context.Context, follower, followee int64) error
+	Unfollow(ctx context.Context, follower, followee int64) error
+	// Followed follower 有没有关注 followee，没登录（follower 为 0）或者是自己都返回 false
+	Followed(ctx context.Context, follower, followee int64) (bool, error)
+	Followees(ctx context.Context, follower, cursor int64, limit int) ([]domain.FollowRelation, error)
+	Followers(ctx context.Context, followee, cursor int64, limit int) ([]domain.FollowRelation, error)
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+}
+
+type followService struct {
+	repo     repository.FollowRepository
+	userRepo repository.UserRepository
+}
+
+func NewFollowService(repo repository.FollowRepository, userRepo repository.UserRepository) FollowService {
+	return &followService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+func (svc *followService) Follow(ctx context.Context, follower, followee int64) error {
+	if follower == followee {
+		return ErrFollowSelf
+	}
+	_, err := svc.userRepo.FindByID(ctx, followee)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return svc.repo.Follow(ctx, follower, followee)
+}
+
+func (svc *followService) Unfollow(ctx context.Context, follower, followee int64) error {
+	return svc.repo.Unfollow(ctx, follower, followee)
+}
+
+func (svc *followService) Followed(ctx context.Context, follower, followee int64) (bool, error) {
+	if follower == 0 || follower == followee {
+		return false, nil
+	}
+	return svc.repo.Followed(ctx, follower, followee)
+}
+
+func (svc *followService) Followees(ctx context.Context, follower, cursor int64, limit int) ([]domain.FollowRelation, error) {
+	return svc.repo.Followees(ctx, follower, cursor, limit)
+}
+
+func (svc *followService) Followers(ctx context.Context, followee, cursor int64, limit int) ([]domain.FollowRelation, error) {
+	return svc.repo.Followers(ctx, followee, cursor, limit)
+}
+
+func (svc *followService) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	return svc.repo.GetStatics(ctx, uid)
+}
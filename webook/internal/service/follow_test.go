@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestFollowService_Follow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository)
+		followee int64
+
+		wantErr error
+	}{
+		{
+			name: "关注成功",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(2)).Return(domain.User{Id: 2}, nil)
+				repo.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(nil)
+				return repo, userRepo
+			},
+			followee: 2,
+		},
+		{
+			name: "不能关注自己",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository) {
+				return repomocks.NewMockFollowRepository(ctrl), repomocks.NewMockUserRepository(ctrl)
+			},
+			followee: 1,
+			wantErr:  ErrFollowSelf,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(2)).Return(domain.User{}, repository.ErrUserNotFound)
+				return repomocks.NewMockFollowRepository(ctrl), userRepo
+			},
+			followee: 2,
+			wantErr:  ErrUserNotFound,
+		},
+		{
+			name: "关注失败",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(2)).Return(domain.User{Id: 2}, nil)
+				repo.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock error"))
+				return repo, userRepo
+			},
+			followee: 2,
+			wantErr:  errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewFollowService(tc.mock(ctrl))
+			err := svc.Follow(context.Background(), 1, tc.followee)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestFollowService_Followed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.FollowRepository
+		follower int64
+		followee int64
+
+		wantFollowed bool
+		wantErr      error
+	}{
+		{
+			name: "关注了",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().Followed(gomock.Any(), int64(1), int64(2)).Return(true, nil)
+				return repo
+			},
+			follower:     1,
+			followee:     2,
+			wantFollowed: true,
+		},
+		{
+			name: "没登录",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				return repomocks.NewMockFollowRepository(ctrl)
+			},
+			followee: 2,
+		},
+		{
+			name: "看自己",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				return repomocks.NewMockFollowRepository(ctrl)
+			},
+			follower: 2,
+			followee: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewFollowService(tc.mock(ctrl), repomocks.NewMockUserRepository(ctrl))
+			followed, err := svc.Followed(context.Background(), tc.follower, tc.followee)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantFollowed, followed)
+		})
+	}
+}
@@ -17,12 +17,15 @@ type InteractiveService interface {
 }
 
 type interactiveService struct {
-	repo repository.InteractiveRepository
+	repo     repository.InteractiveRepository
+	userRepo repository.UserRepository
 }
 
-func NewInteractiveService(repo repository.InteractiveRepository) InteractiveService {
+func NewInteractiveService(repo repository.InteractiveRepository,
+	userRepo repository.UserRepository) InteractiveService {
 	return &interactiveService{
-		repo: repo,
+		repo:     repo,
+		userRepo: userRepo,
 	}
 }
 
@@ -30,7 +33,11 @@ func (i *interactiveService) IncrReadCnt(ctx context.Context, biz string, bizId
 	return i.repo.IncrReadCnt(ctx, biz, bizId)
 }
 
+// Like 禁言的用户不能点赞，取消点赞不限制
 func (i *interactiveService) Like(ctx context.Context, biz string, bizId, uid int64) error {
+	if err := checkWritable(ctx, i.userRepo, uid); err != nil {
+		return err
+	}
 	return i.repo.IncrLike(ctx, biz, bizId, uid)
 }
 
@@ -38,7 +45,11 @@ func (i *interactiveService) CancelLike(ctx context.Context, biz string, bizId,
 	return i.repo.DecrLike(ctx, biz, bizId, uid)
 }
 
+// Collect 同 Like
 func (i *interactiveService) Collect(ctx context.Context, biz string, bizId, uid int64) error {
+	if err := checkWritable(ctx, i.userRepo, uid); err != nil {
+		return err
+	}
 	return i.repo.AddCollectionItem(ctx, biz, bizId, uid)
 }
 
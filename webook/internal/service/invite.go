@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 去掉了 0 O 1 I 这种容易看错的字符
+const (
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	inviteCodeLength   = 8
+)
+
+var (
+	ErrInviteCodeRequired  = errors.New("需要邀请码")
+	ErrInvalidInviteCode   = errors.New("邀请码无效")
+	ErrInviteQuotaExceeded = errors.New("可用的邀请码太多了")
+	ErrInvalidInviteUses   = errors.New("邀请码的可用次数必须大于 0")
+)
+
+// InviteConfig 对应配置文件里面的 invite
+type InviteConfig struct {
+	// Required 为 true 的时候注册必须要邀请码，关掉之后填了邀请码也一样会记录邀请关系
+	Required bool `yaml:"required"`
+	// 普通用户生成的邀请码可以用几次，多久过期
+	UserMaxUses    int32         `yaml:"userMaxUses"`
+	UserExpiration time.Duration `yaml:"userExpiration"`
+	// 普通用户同时最多有几个还能用的邀请码
+	UserQuota int64 `yaml:"userQuota"`
+}
+
+type InviteService interface {
+	// Required 注册是不是必须要邀请码
+	Required() bool
+	// Validate 注册之前先检查邀请码，语义同 UserService.Signup，但是不会用掉邀请码
+	Validate(ctx context.Context, code string) error
+	// Reload 配置文件改了之后替换掉原来的配置
+	Reload(cfg InviteConfig)
+	// Generate 普通用户生成邀请码，次数和有效期按照配置来
+	Generate(ctx context.Context, inviter int64) (domain.InviteCode, error)
+	// GenerateByAdmin 管理员生成邀请码，ttl 为 0 代表不过期
+	GenerateByAdmin(ctx context.Context, inviter int64, maxUses int32, ttl time.Duration) (domain.InviteCode, error)
+	List(ctx context.Context, inviter int64, offset, limit int) ([]domain.InviteCode, error)
+	// Invitations inviter 邀请了谁，inviter 为 0 的时候查所有的
+	Invitations(ctx context.Context, inviter int64, offset, limit int) ([]domain.Invitation, error)
+}
+
+type inviteService struct {
+	repo repository.InviteRepository
+	// 配置会热加载，整个替换掉，读的时候不用加锁
+	cfg atomic.Pointer[InviteConfig]
+}
+
+func NewInviteService(repo repository.InviteRepository, cfg InviteConfig) InviteService {
+	svc := &inviteService{
+		repo: repo,
+	}
+	svc.cfg.Store(&cfg)
+	return svc
+}
+
+func (svc *inviteService) Required() bool {
+	return svc.cfg.Load().Required
+}
+
+func (svc *inviteService) Reload(cfg InviteConfig) {
+	svc.cfg.Store(&cfg)
+}
+
+func (svc *inviteService) Validate(ctx context.Context, code string) error {
+	code = normalizeInviteCode(code)
+	if code == "" {
+		if svc.Required() {
+			return ErrInviteCodeRequired
+		}
+		return nil
+	}
+	ok, err := svc.repo.Usable(ctx, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidInviteCode
+	}
+	return nil
+}
+
+func (svc *inviteService) Generate(ctx context.Context, inviter int64) (domain.InviteCode, error) {
+	cfg := svc.cfg.Load()
+	now := time.Now()
+	cnt, err := svc.repo.CountUsable(ctx, inviter, now)
+	if err != nil {
+		return domain.InviteCode{}, err
+	}
+	if cnt >= cfg.UserQuota {
+		return domain.InviteCode{}, ErrInviteQuotaExceeded
+	}
+	return svc.generate(ctx, inviter, cfg.UserMaxUses, cfg.UserExpiration)
+}
+
+func (svc *inviteService) GenerateByAdmin(ctx context.Context, inviter int64,
+	maxUses int32, ttl time.Duration) (domain.InviteCode, error) {
+	return svc.generate(ctx, inviter, maxUses, ttl)
+}
+
+func (svc *inviteService) generate(ctx context.Context, inviter int64,
+	maxUses int32, ttl time.Duration) (domain.InviteCode, error) {
+	if maxUses <= 0 {
+		return domain.InviteCode{}, ErrInvalidInviteUses
+	}
+	code, err := randomInviteCode()
+	if err != nil {
+		return domain.InviteCode{}, err
+	}
+	now := time.Now()
+	c := domain.InviteCode{
+		Code:    code,
+		Inviter: inviter,
+		MaxUses: maxUses,
+		Ctime:   now,
+	}
+	if ttl > 0 {
+		c.ExpireAt = now.Add(ttl)
+	}
+	return c, svc.repo.Create(ctx, c)
+}
+
+func (svc *inviteService) List(ctx context.Context, inviter int64, offset, limit int) ([]domain.InviteCode, error) {
+	return svc.repo.FindByInviter(ctx, inviter, offset, limit)
+}
+
+func (svc *inviteService) Invitations(ctx context.Context, inviter int64, offset, limit int) ([]domain.Invitation, error) {
+	return svc.repo.FindInvitations(ctx, inviter, offset, limit)
+}
+
+func randomInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	// 字母表的长度是 32，取模不会有偏差
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// normalizeInviteCode 用户手输的邀请码，大小写和前后的空格都不计较
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestInviteService_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.InviteRepository
+		required bool
+		code     string
+
+		wantErr error
+	}{
+		{
+			name: "没开邀请注册，可以不填",
+			mock: func(ctrl *gomock.Controller) repository.InviteRepository {
+				return repomocks.NewMockInviteRepository(ctrl)
+			},
+		},
+		{
+			name: "开了邀请注册，必须填",
+			mock: func(ctrl *gomock.Controller) repository.InviteRepository {
+				return repomocks.NewMockInviteRepository(ctrl)
+			},
+			required: true,
+			code:     "  ",
+			wantErr:  ErrInviteCodeRequired,
+		},
+		{
+			name: "去掉空格转成大写再查",
+			mock: func(ctrl *gomock.Controller) repository.InviteRepository {
+				repo := repomocks.NewMockInviteRepository(ctrl)
+				repo.EXPECT().Usable(gomock.Any(), "ABCD2345", gomock.Any()).Return(true, nil)
+				return repo
+			},
+			required: true,
+			code:     " abcd2345 ",
+		},
+		{
+			name: "没开邀请注册，填了也要能用",
+			mock: func(ctrl *gomock.Controller) repository.InviteRepository {
+				repo := repomocks.NewMockInviteRepository(ctrl)
+				repo.EXPECT().Usable(gomock.Any(), "ABCD2345", gomock.Any()).Return(false, nil)
+				return repo
+			},
+			code:    "ABCD2345",
+			wantErr: ErrInvalidInviteCode,
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) repository.InviteRepository {
+				repo := repomocks.NewMockInviteRepository(ctrl)
+				repo.EXPECT().Usable(gomock.Any(), "ABCD2345", gomock.Any()).Return(false, errors.New("mock error"))
+				return repo
+			},
+			code:    "ABCD2345",
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewInviteService(tc.mock(ctrl), InviteConfig{Required: tc.required})
+			err := svc.Validate(context.Background(), tc.code)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestInviteService_Reload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := NewInviteService(repomocks.NewMockInviteRepository(ctrl), InviteConfig{Required: true})
+	assert.True(t, svc.Required())
+	// 内测结束，关掉邀请注册不用重启
+	svc.Reload(InviteConfig{Required: false})
+	assert.False(t, svc.Required())
+	assert.NoError(t, svc.Validate(context.Background(), ""))
+}
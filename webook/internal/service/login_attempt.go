@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLoginLocked     = errors.New("登录失败次数太多，请稍后再试")
+	ErrTwoFactorLocked = errors.New("两步验证码错误次数太多，请稍后再试")
+)
+
+// LoginAttemptService 防止暴力破解密码，分别按照账号和 IP 统计登录失败次数
+type LoginAttemptService interface {
+	// Check 登录之前检查，被锁定了返回 ErrLoginLocked 和还要等多久
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	// Fail 记录一次失败，返回需要锁定多久
+	Fail(ctx context.Context, email, ip string) (time.Duration, error)
+	// Unlock 登录成功，或者通过短信登录、重置密码证明了身份，解除账号锁定。
+	// IP 的计数不清，不然攻击者用自己的账号穿插登录一次就能绕过去
+	Unlock(ctx context.Context, email string) error
+	// CheckTwoFactor 已经登录的用户开启、关闭两步验证之前检查，被锁定了返回 ErrTwoFactorLocked 和还要等多久
+	CheckTwoFactor(ctx context.Context, uid int64) (time.Duration, error)
+	// FailTwoFactor 记录一次两步验证码错误，返回需要锁定多久
+	FailTwoFactor(ctx context.Context, uid int64) (time.Duration, error)
+	UnlockTwoFactor(ctx context.Context, uid int64) error
+}
+
+type loginAttemptService struct {
+	repo repository.LoginAttemptRepository
+	// 同一个 IP 后面可能有很多人，所以 IP 的阈值要比账号的高
+	emailPolicy repository.LoginAttemptPolicy
+	ipPolicy    repository.LoginAttemptPolicy
+	// 验证码只有 6 位，比密码更容易试出来
+	twoFactorPolicy repository.LoginAttemptPolicy
+}
+
+func NewLoginAttemptService(repo repository.LoginAttemptRepository) LoginAttemptService {
+	return &loginAttemptService{
+		repo: repo,
+		emailPolicy: repository.LoginAttemptPolicy{
+			Threshold: 5,
+			Base:      time.Minute,
+			Max:       time.Hour,
+			Window:    time.Hour * 24,
+		},
+		ipPolicy: repository.LoginAttemptPolicy{
+			Threshold: 20,
+			Base:      time.Minute,
+			Max:       time.Hour,
+			Window:    time.Hour,
+		},
+		twoFactorPolicy: repository.LoginAttemptPolicy{
+			Threshold: 5,
+			Base:      time.Minute * 5,
+			Max:       time.Hour * 24,
+			Window:    time.Hour * 24,
+		},
+	}
+}
+
+func (svc *loginAttemptService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	var res time.Duration
+	for _, key := range svc.keys(email, ip) {
+		d, err := svc.repo.LockedFor(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		res = max(res, d)
+	}
+	if res > 0 {
+		return res, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+func (svc *loginAttemptService) Fail(ctx context.Context, email, ip string) (time.Duration, error) {
+	emailLock, err := svc.repo.Fail(ctx, svc.emailKey(email), svc.emailPolicy)
+	if err != nil {
+		return 0, err
+	}
+	ipLock, err := svc.repo.Fail(ctx, svc.ipKey(ip), svc.ipPolicy)
+	if err != nil {
+		return 0, err
+	}
+	return max(emailLock, ipLock), nil
+}
+
+func (svc *loginAttemptService) Unlock(ctx context.Context, email string) error {
+	if email == "" {
+		return nil
+	}
+	return svc.repo.Reset(ctx, svc.emailKey(email))
+}
+
+func (svc *loginAttemptService) CheckTwoFactor(ctx context.Context, uid int64) (time.Duration, error) {
+	d, err := svc.repo.LockedFor(ctx, svc.twoFactorKey(uid))
+	if err != nil {
+		return 0, err
+	}
+	if d > 0 {
+		return d, ErrTwoFactorLocked
+	}
+	return 0, nil
+}
+
+func (svc *loginAttemptService) FailTwoFactor(ctx context.Context, uid int64) (time.Duration, error) {
+	return svc.repo.Fail(ctx, svc.twoFactorKey(uid), svc.twoFactorPolicy)
+}
+
+func (svc *loginAttemptService) UnlockTwoFactor(ctx context.Context, uid int64) error {
+	return svc.repo.Reset(ctx, svc.twoFactorKey(uid))
+}
+
+func (svc *loginAttemptService) keys(email, ip string) []string {
+	return []string{svc.emailKey(email), svc.ipKey(ip)}
+}
+
+func (svc *loginAttemptService) emailKey(email string) string {
+	return "email:" + strings.ToLower(email)
+}
+
+func (svc *loginAttemptService) ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (svc *loginAttemptService) twoFactorKey(uid int64) string {
+	return "2fa:" + strconv.FormatInt(uid, 10)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptService_Check(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.LoginAttemptRepository
+
+		wantLock time.Duration
+		wantErr  error
+	}{
+		{
+			name: "没有锁定",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().LockedFor(gomock.Any(), "email:123@qq.com").Return(time.Duration(0), nil)
+				repo.EXPECT().LockedFor(gomock.Any(), "ip:127.0.0.1").Return(time.Duration(0), nil)
+				return repo
+			},
+		},
+		{
+			name: "账号锁定，邮箱不区分大小写",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().LockedFor(gomock.Any(), "email:123@qq.com").Return(time.Minute, nil)
+				repo.EXPECT().LockedFor(gomock.Any(), "ip:127.0.0.1").Return(time.Duration(0), nil)
+				return repo
+			},
+			wantLock: time.Minute,
+			wantErr:  ErrLoginLocked,
+		},
+		{
+			name: "都锁定了，取长的那个",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().LockedFor(gomock.Any(), "email:123@qq.com").Return(time.Minute, nil)
+				repo.EXPECT().LockedFor(gomock.Any(), "ip:127.0.0.1").Return(time.Hour, nil)
+				return repo
+			},
+			wantLock: time.Hour,
+			wantErr:  ErrLoginLocked,
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().LockedFor(gomock.Any(), "email:123@qq.com").
+					Return(time.Duration(0), errors.New("mock error"))
+				return repo
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewLoginAttemptService(tc.mock(ctrl))
+			lock, err := svc.Check(context.Background(), "123@QQ.com", "127.0.0.1")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantLock, lock)
+		})
+	}
+}
+
+func TestLoginAttemptService_Fail(t *testing.T) {
+	emailPolicy := repository.LoginAttemptPolicy{Threshold: 5, Base: time.Minute,
+		Max: time.Hour, Window: time.Hour * 24}
+	ipPolicy := repository.LoginAttemptPolicy{Threshold: 20, Base: time.Minute,
+		Max: time.Hour, Window: time.Hour}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.LoginAttemptRepository
+
+		wantLock time.Duration
+		wantErr  error
+	}{
+		{
+			name: "还没到阈值",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().Fail(gomock.Any(), "email:123@qq.com", emailPolicy).Return(time.Duration(0), nil)
+				repo.EXPECT().Fail(gomock.Any(), "ip:127.0.0.1", ipPolicy).Return(time.Duration(0), nil)
+				return repo
+			},
+		},
+		{
+			name: "账号和 IP 都锁定，取长的那个",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().Fail(gomock.Any(), "email:123@qq.com", emailPolicy).Return(time.Minute*2, nil)
+				repo.EXPECT().Fail(gomock.Any(), "ip:127.0.0.1", ipPolicy).Return(time.Minute, nil)
+				return repo
+			},
+			wantLock: time.Minute * 2,
+		},
+		{
+			name: "记录失败",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().Fail(gomock.Any(), "email:123@qq.com", emailPolicy).
+					Return(time.Duration(0), errors.New("mock error"))
+				return repo
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewLoginAttemptService(tc.mock(ctrl))
+			lock, err := svc.Fail(context.Background(), "123@QQ.com", "127.0.0.1")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantLock, lock)
+		})
+	}
+}
+
+func TestLoginAttemptService_Unlock(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) repository.LoginAttemptRepository
+		email string
+	}{
+		{
+			name: "只清账号，不清 IP",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().Reset(gomock.Any(), "email:123@qq.com").Return(nil)
+				return repo
+			},
+			email: "123@QQ.com",
+		},
+		{
+			name: "没有邮箱，什么也不做",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				return repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewLoginAttemptService(tc.mock(ctrl))
+			assert.NoError(t, svc.Unlock(context.Background(), tc.email))
+		})
+	}
+}
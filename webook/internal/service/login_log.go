@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/event/security"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"github.com/zmsocc/practice/webook/pkg/mask"
+	"time"
+)
+
+// LoginLogService 登录审计，记录每一次登录尝试，发现可疑的情况就发告警事件
+type LoginLogService interface {
+	// Record 在后台写记录和发告警，不会拖慢登录，出错了只打日志
+	Record(ctx context.Context, l domain.LoginLog)
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error)
+	// MaskLegacy 把旧记录里面的明文账号换成脱敏之后的账号和盲索引，语义同 repository
+	MaskLegacy(ctx context.Context, afterId int64, limit int) (int64, int, error)
+}
+
+type loginLogService struct {
+	repo     repository.LoginLogRepository
+	userRepo repository.UserRepository
+	producer security.Producer
+	l        logger.Logger
+	// failWindow 内同一个账号失败 failThreshold 次就告警
+	failWindow    time.Duration
+	failThreshold int64
+}
+
+func NewLoginLogService(repo repository.LoginLogRepository, userRepo repository.UserRepository,
+	producer security.Producer, l logger.Logger) LoginLogService {
+	return &loginLogService{
+		repo:          repo,
+		userRepo:      userRepo,
+		producer:      producer,
+		l:             l,
+		failWindow:    time.Minute * 15,
+		failThreshold: 5,
+	}
+}
+
+func (svc *loginLogService) Record(ctx context.Context, l domain.LoginLog) {
+	l.Ctime = time.Now()
+	go func() {
+		// 请求结束之后 ctx 就取消了，不能再用
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		if err := svc.record(ctx, l); err != nil {
+			svc.l.Error("记录登录日志失败",
+				logger.Int64("uid", l.Uid),
+				logger.String("method", l.Method),
+				logger.Error(err))
+		}
+	}()
+}
+
+func (svc *loginLogService) record(ctx context.Context, l domain.LoginLog) error {
+	if l.Uid == 0 && l.Account != "" {
+		l.Uid = svc.resolveUid(ctx, l)
+	}
+	// 要在写入之前判断，不然这一次就把自己算进去了
+	newDevice := svc.isNewDevice(ctx, l)
+	if err := svc.repo.Create(ctx, l); err != nil {
+		return err
+	}
+	if newDevice {
+		svc.alert(ctx, security.AlertNewDevice, l)
+	}
+	if !l.Success && l.Account != "" {
+		cnt, err := svc.repo.CountFailures(ctx, l.Account, l.Ctime.Add(-svc.failWindow))
+		if err != nil {
+			svc.l.Error("统计登录失败次数失败", logger.Error(err))
+			return nil
+		}
+		// 刚好到阈值的时候发一次，后面再失败就不重复发了
+		if cnt == svc.failThreshold {
+			svc.alert(ctx, security.AlertTooManyFailures, l)
+		}
+	}
+	return nil
+}
+
+// resolveUid 登录失败的时候 web 层只拿得到账号，这里查出是哪个用户，不然用户自己查登录记录的时候看不到。
+// 账号不存在就还是 0
+func (svc *loginLogService) resolveUid(ctx context.Context, l domain.LoginLog) int64 {
+	var (
+		u   domain.User
+		err error
+	)
+	switch l.Method {
+	case domain.LoginMethodPassword:
+		u, err = svc.userRepo.FindByEmail(ctx, l.Account)
+	case domain.LoginMethodSMS:
+		u, err = svc.userRepo.FindByPhone(ctx, l.Account)
+	default:
+		return 0
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			svc.l.Error("查找登录账号失败", logger.Account("account", l.Account), logger.Error(err))
+		}
+		return 0
+	}
+	return u.Id
+}
+
+// isNewDevice 以前登录成功过，但是没在这个设备上登录过。
+// 刷新 token 用的是已有的 session，不算
+func (svc *loginLogService) isNewDevice(ctx context.Context, l domain.LoginLog) bool {
+	if !l.Success || l.Uid == 0 || l.Method == domain.LoginMethodRefreshToken {
+		return false
+	}
+	cnt, err := svc.repo.CountSuccess(ctx, l.Uid, l.UserAgent)
+	if err != nil || cnt > 0 {
+		return false
+	}
+	// 第一次登录不算新设备
+	total, err := svc.repo.CountSuccess(ctx, l.Uid, "")
+	return err == nil && total > 0
+}
+
+func (svc *loginLogService) alert(ctx context.Context, typ string, l domain.LoginLog) {
+	err := svc.producer.ProduceLoginAlert(ctx, security.LoginAlertEvent{
+		Type: typ,
+		Uid:  l.Uid,
+		// 告警会被别的系统消费和记录下来，不带完整的手机号和邮箱
+		Account:   mask.Account(l.Account),
+		Method:    l.Method,
+		Ip:        l.Ip,
+		UserAgent: l.UserAgent,
+		Ctime:     l.Ctime.UnixMilli(),
+	})
+	if err != nil {
+		svc.l.Error("发送登录告警失败",
+			logger.String("type", typ),
+			logger.Int64("uid", l.Uid),
+			logger.Account("account", l.Account),
+			logger.Error(err))
+	}
+}
+
+func (svc *loginLogService) List(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	return svc.repo.FindByUid(ctx, uid, offset, limit)
+}
+
+func (svc *loginLogService) MaskLegacy(ctx context.Context, afterId int64, limit int) (int64, int, error) {
+	return svc.repo.MaskLegacy(ctx, afterId, limit)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/event/security"
+	securitymocks "github.com/zmsocc/practice/webook/internal/event/security/mocks"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestLoginLogService_record(t *testing.T) {
+	withUid := func(uid int64) gomock.Matcher {
+		return gomock.Cond(func(l domain.LoginLog) bool { return l.Uid == uid })
+	}
+	withType := func(typ string, uid int64) gomock.Matcher {
+		return gomock.Cond(func(evt security.LoginAlertEvent) bool { return evt.Type == typ && evt.Uid == uid })
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer)
+		log  domain.LoginLog
+
+		wantErr error
+	}{
+		{
+			name: "密码登录失败，按邮箱查出用户",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{Id: 123}, nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				repo.EXPECT().CountFailures(gomock.Any(), "123@qq.com", gomock.Any()).Return(int64(1), nil)
+				return repo, userRepo, securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Method: domain.LoginMethodPassword, Account: "123@qq.com"},
+		},
+		{
+			name: "短信登录失败，按手机号查出用户",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByPhone(gomock.Any(), "15212345678").Return(domain.User{Id: 123}, nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				repo.EXPECT().CountFailures(gomock.Any(), "15212345678", gomock.Any()).Return(int64(1), nil)
+				return repo, userRepo, securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Method: domain.LoginMethodSMS, Account: "15212345678"},
+		},
+		{
+			name: "账号不存在，uid 还是 0",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().Create(gomock.Any(), withUid(0)).Return(nil)
+				repo.EXPECT().CountFailures(gomock.Any(), "123@qq.com", gomock.Any()).Return(int64(1), nil)
+				return repo, userRepo, securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Method: domain.LoginMethodPassword, Account: "123@qq.com"},
+		},
+		{
+			name: "两步验证失败，已经有 uid 不用再查",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				return repo, repomocks.NewMockUserRepository(ctrl), securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Uid: 123, Method: domain.LoginMethodTotp},
+		},
+		{
+			name: "失败次数到了阈值，发告警",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				producer := securitymocks.NewMockProducer(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{Id: 123}, nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				repo.EXPECT().CountFailures(gomock.Any(), "123@qq.com", gomock.Any()).Return(int64(5), nil)
+				producer.EXPECT().ProduceLoginAlert(gomock.Any(), withType(security.AlertTooManyFailures, 123)).Return(nil)
+				return repo, userRepo, producer
+			},
+			log: domain.LoginLog{Method: domain.LoginMethodPassword, Account: "123@qq.com"},
+		},
+		{
+			name: "超过阈值不重复告警",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{Id: 123}, nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				repo.EXPECT().CountFailures(gomock.Any(), "123@qq.com", gomock.Any()).Return(int64(6), nil)
+				return repo, userRepo, securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Method: domain.LoginMethodPassword, Account: "123@qq.com"},
+		},
+		{
+			name: "新设备登录，发告警",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				producer := securitymocks.NewMockProducer(ctrl)
+				repo.EXPECT().CountSuccess(gomock.Any(), int64(123), "chrome").Return(int64(0), nil)
+				repo.EXPECT().CountSuccess(gomock.Any(), int64(123), "").Return(int64(3), nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				producer.EXPECT().ProduceLoginAlert(gomock.Any(), withType(security.AlertNewDevice, 123)).Return(nil)
+				return repo, repomocks.NewMockUserRepository(ctrl), producer
+			},
+			log: domain.LoginLog{Uid: 123, Method: domain.LoginMethodPassword, Account: "123@qq.com",
+				UserAgent: "chrome", Success: true},
+		},
+		{
+			name: "第一次登录不算新设备",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountSuccess(gomock.Any(), int64(123), "chrome").Return(int64(0), nil)
+				repo.EXPECT().CountSuccess(gomock.Any(), int64(123), "").Return(int64(0), nil)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(nil)
+				return repo, repomocks.NewMockUserRepository(ctrl), securitymocks.NewMockProducer(ctrl)
+			},
+			log: domain.LoginLog{Uid: 123, Method: domain.LoginMethodPassword, Account: "123@qq.com",
+				UserAgent: "chrome", Success: true},
+		},
+		{
+			name: "写入失败",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, repository.UserRepository, security.Producer) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), withUid(123)).Return(errors.New("mock error"))
+				return repo, repomocks.NewMockUserRepository(ctrl), securitymocks.NewMockProducer(ctrl)
+			},
+			log:     domain.LoginLog{Uid: 123, Method: domain.LoginMethodTotp},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, userRepo, producer := tc.mock(ctrl)
+			svc := NewLoginLogService(repo, userRepo, producer, logger.NewNopLogger()).(*loginLogService)
+			tc.log.Ctime = time.Now()
+			err := svc.record(context.Background(), tc.log)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+// Record 不等写完就返回，写入是在后台做的
+func TestLoginLogService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockLoginLogRepository(ctrl)
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	done := make(chan struct{})
+	userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{Id: 123}, nil)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, l domain.LoginLog) error {
+		assert.Equal(t, int64(123), l.Uid)
+		assert.False(t, l.Ctime.IsZero())
+		return nil
+	})
+	repo.EXPECT().CountFailures(gomock.Any(), "123@qq.com", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, account string, since time.Time) (int64, error) {
+			close(done)
+			return 1, nil
+		})
+	svc := NewLoginLogService(repo, userRepo, securitymocks.NewMockProducer(ctrl), logger.NewNopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.Record(ctx, domain.LoginLog{Method: domain.LoginMethodPassword, Account: "123@qq.com"})
+	// 请求结束了也要能写进去
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("登录日志没有在后台写入")
+	}
+}
@@ -0,0 +1,13 @@
+package oauth2
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/domain"
+)
+
+type Service interface {
+	// AuthURL 拼接跳转到第三方授权页面的地址，state 用来防 CSRF
+	AuthURL(ctx context.Context, state string) (string, error)
+	// VerifyCode 用授权回调里面的 code 换取用户身份
+	VerifyCode(ctx context.Context, code string) (domain.OAuthInfo, error)
+}
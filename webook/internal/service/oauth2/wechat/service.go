@@ -0,0 +1,134 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"net/http"
+	"net/url"
+)
+
+const Provider = "wechat"
+
+type Service struct {
+	appId       string
+	appSecret   string
+	redirectURL string
+	// 授权页面，默认是微信开放平台
+	authBaseURL string
+	// 换 access_token 和拿用户信息的接口，测试的时候可以换成 httptest
+	apiBaseURL string
+	client     *http.Client
+}
+
+func NewService(appId, appSecret, redirectURL string) *Service {
+	return NewServiceWithURL(appId, appSecret, redirectURL,
+		"https://open.weixin.qq.com", "https://api.weixin.qq.com", http.DefaultClient)
+}
+
+func NewServiceWithURL(appId, appSecret, redirectURL, authBaseURL, apiBaseURL string,
+	client *http.Client) *Service {
+	return &Service{
+		appId:       appId,
+		appSecret:   appSecret,
+		redirectURL: redirectURL,
+		authBaseURL: authBaseURL,
+		apiBaseURL:  apiBaseURL,
+		client:      client,
+	}
+}
+
+func (s *Service) AuthURL(ctx context.Context, state string) (string, error) {
+	v := url.Values{}
+	v.Set("appid", s.appId)
+	v.Set("redirect_uri", s.redirectURL)
+	v.Set("response_type", "code")
+	v.Set("scope", "snsapi_login")
+	v.Set("state", state)
+	return s.authBaseURL + "/connect/qrconnect?" + v.Encode() + "#wechat_redirect", nil
+}
+
+func (s *Service) VerifyCode(ctx context.Context, code string) (domain.OAuthInfo, error) {
+	v := url.Values{}
+	v.Set("appid", s.appId)
+	v.Set("secret", s.appSecret)
+	v.Set("code", code)
+	v.Set("grant_type", "authorization_code")
+	var token tokenResult
+	err := s.get(ctx, "/sns/oauth2/access_token?"+v.Encode(), &token)
+	if err != nil {
+		return domain.OAuthInfo{}, fmt.Errorf("换取 access_token 失败 %w", err)
+	}
+	v = url.Values{}
+	v.Set("access_token", token.AccessToken)
+	v.Set("openid", token.OpenId)
+	var info userInfoResult
+	err = s.get(ctx, "/sns/userinfo?"+v.Encode(), &info)
+	if err != nil {
+		return domain.OAuthInfo{}, fmt.Errorf("获取用户信息失败 %w", err)
+	}
+	unionId := info.UnionId
+	if unionId == "" {
+		unionId = token.UnionId
+	}
+	return domain.OAuthInfo{
+		Provider: Provider,
+		OpenId:   token.OpenId,
+		UnionId:  unionId,
+		Nickname: info.Nickname,
+	}, nil
+}
+
+// get 微信的接口出错也是返回 200，要看 errcode
+func (s *Service) get(ctx context.Context, path string, val errResult) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiBaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP 状态码 %d", resp.StatusCode)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(val); err != nil {
+		return err
+	}
+	return val.err()
+}
+
+type errResult interface {
+	err() error
+}
+
+type baseResult struct {
+	ErrCode int64  `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *baseResult) err() error {
+	if r.ErrCode != 0 {
+		return fmt.Errorf("errcode: %d, errmsg: %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+type tokenResult struct {
+	baseResult
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	OpenId       string `json:"openid"`
+	Scope        string `json:"scope"`
+	UnionId      string `json:"unionid"`
+}
+
+type userInfoResult struct {
+	baseResult
+	OpenId   string `json:"openid"`
+	Nickname string `json:"nickname"`
+	UnionId  string `json:"unionid"`
+}
@@ -0,0 +1,103 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestService_VerifyCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		token    map[string]any
+		userInfo map[string]any
+
+		wantInfo domain.OAuthInfo
+		wantErr  string
+	}{
+		{
+			name: "成功",
+			token: map[string]any{
+				"access_token": "access-token",
+				"openid":       "open-id",
+			},
+			userInfo: map[string]any{
+				"openid":   "open-id",
+				"nickname": "大明",
+				"unionid":  "union-id",
+			},
+			wantInfo: domain.OAuthInfo{
+				Provider: Provider,
+				OpenId:   "open-id",
+				UnionId:  "union-id",
+				Nickname: "大明",
+			},
+		},
+		{
+			name: "code 无效",
+			token: map[string]any{
+				"errcode": 40029,
+				"errmsg":  "invalid code",
+			},
+			wantErr: "errcode: 40029",
+		},
+		{
+			name: "获取用户信息失败",
+			token: map[string]any{
+				"access_token": "access-token",
+				"openid":       "open-id",
+			},
+			userInfo: map[string]any{
+				"errcode": 40003,
+				"errmsg":  "invalid openid",
+			},
+			wantErr: "errcode: 40003",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "app-id", r.URL.Query().Get("appid"))
+				assert.Equal(t, "app-secret", r.URL.Query().Get("secret"))
+				assert.Equal(t, "the-code", r.URL.Query().Get("code"))
+				_ = json.NewEncoder(w).Encode(tc.token)
+			})
+			mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "access-token", r.URL.Query().Get("access_token"))
+				_ = json.NewEncoder(w).Encode(tc.userInfo)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			svc := NewServiceWithURL("app-id", "app-secret", "http://localhost/callback",
+				server.URL, server.URL, server.Client())
+			info, err := svc.VerifyCode(context.Background(), "the-code")
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantInfo, info)
+		})
+	}
+}
+
+func TestService_AuthURL(t *testing.T) {
+	svc := NewService("app-id", "app-secret", "http://localhost/callback")
+	authURL, err := svc.AuthURL(context.Background(), "my-state")
+	require.NoError(t, err)
+	u, err := url.Parse(strings.TrimSuffix(authURL, "#wechat_redirect"))
+	require.NoError(t, err)
+	assert.Equal(t, "app-id", u.Query().Get("appid"))
+	assert.Equal(t, "my-state", u.Query().Get("state"))
+	assert.Equal(t, "http://localhost/callback", u.Query().Get("redirect_uri"))
+}
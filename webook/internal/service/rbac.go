@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+)
+
+var (
+	ErrRoleNotFound = repository.ErrRoleNotFound
+	// ErrLastAdmin 至少要留一个管理员，不然没人能再分配角色了
+	ErrLastAdmin = repository.ErrLastRoleMember
+)
+
+// RBACService 基于角色的权限控制。
+// 角色和权限在登录、刷新 token 的时候写进 access_token，所以授权或者收回角色之后，
+// 要等 access_token 刷新了才会生效
+type RBACService interface {
+	Roles(ctx context.Context, uid int64) ([]domain.Role, error)
+	// Permissions 用户的角色名字，以及这些角色的权限合并去重之后的结果
+	Permissions(ctx context.Context, uid int64) ([]string, []string, error)
+	GrantRole(ctx context.Context, uid int64, role string) error
+	// RevokeRole 收回角色，收回最后一个管理员的管理员角色返回 ErrLastAdmin
+	RevokeRole(ctx context.Context, uid int64, role string) error
+	InitRoles(ctx context.Context, roles []domain.Role) error
+}
+
+type rbacService struct {
+	repo repository.RBACRepository
+}
+
+func NewRBACService(repo repository.RBACRepository) RBACService {
+	return &rbacService{
+		repo: repo,
+	}
+}
+
+func (svc *rbacService) Roles(ctx context.Context, uid int64) ([]domain.Role, error) {
+	return svc.repo.FindRoles(ctx, uid)
+}
+
+func (svc *rbacService) Permissions(ctx context.Context, uid int64) ([]string, []string, error) {
+	roles, err := svc.repo.FindRoles(ctx, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(roles))
+	perms := make([]string, 0, len(roles)*2)
+	seen := make(map[string]struct{}, len(roles)*2)
+	for _, role := range roles {
+		names = append(names, role.Name)
+		for _, perm := range role.Permissions {
+			if _, ok := seen[perm]; ok {
+				continue
+			}
+			seen[perm] = struct{}{}
+			perms = append(perms, perm)
+		}
+	}
+	return names, perms, nil
+}
+
+func (svc *rbacService) GrantRole(ctx context.Context, uid int64, role string) error {
+	return svc.repo.GrantRole(ctx, uid, role)
+}
+
+func (svc *rbacService) RevokeRole(ctx context.Context, uid int64, role string) error {
+	if role == domain.RoleAdmin {
+		return svc.repo.RevokeRoleKeepLast(ctx, uid, role)
+	}
+	return svc.repo.RevokeRole(ctx, uid, role)
+}
+
+func (svc *rbacService) InitRoles(ctx context.Context, roles []domain.Role) error {
+	return svc.repo.InitRoles(ctx, roles)
+}
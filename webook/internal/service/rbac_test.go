@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestRBACService_Permissions(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.RBACRepository
+
+		wantRoles []string
+		wantPerms []string
+		wantErr   error
+	}{
+		{
+			name: "多个角色，权限去重",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().FindRoles(gomock.Any(), int64(1)).Return([]domain.Role{
+					{Name: domain.RoleModerator, Permissions: []string{domain.PermArticleTakedown, domain.PermUserStatus}},
+					{Name: domain.RoleOperator, Permissions: []string{domain.PermJWTRotate, domain.PermUserStatus}},
+				}, nil)
+				return repo
+			},
+			wantRoles: []string{domain.RoleModerator, domain.RoleOperator},
+			wantPerms: []string{domain.PermArticleTakedown, domain.PermUserStatus, domain.PermJWTRotate},
+		},
+		{
+			name: "没有角色",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().FindRoles(gomock.Any(), int64(1)).Return(nil, nil)
+				return repo
+			},
+			wantRoles: []string{},
+			wantPerms: []string{},
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().FindRoles(gomock.Any(), int64(1)).Return(nil, errors.New("mock error"))
+				return repo
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewRBACService(tc.mock(ctrl))
+			roles, perms, err := svc.Permissions(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRoles, roles)
+			assert.Equal(t, tc.wantPerms, perms)
+		})
+	}
+}
+
+func TestRBACService_RevokeRole(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.RBACRepository
+		role string
+
+		wantErr error
+	}{
+		{
+			name: "管理员要留最后一个",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().RevokeRoleKeepLast(gomock.Any(), int64(2), domain.RoleAdmin).
+					Return(repository.ErrLastRoleMember)
+				return repo
+			},
+			role:    domain.RoleAdmin,
+			wantErr: ErrLastAdmin,
+		},
+		{
+			name: "收回管理员",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().RevokeRoleKeepLast(gomock.Any(), int64(2), domain.RoleAdmin).Return(nil)
+				return repo
+			},
+			role: domain.RoleAdmin,
+		},
+		{
+			name: "其它角色直接收回",
+			mock: func(ctrl *gomock.Controller) repository.RBACRepository {
+				repo := repomocks.NewMockRBACRepository(ctrl)
+				repo.EXPECT().RevokeRole(gomock.Any(), int64(2), domain.RoleModerator).Return(nil)
+				return repo
+			},
+			role: domain.RoleModerator,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewRBACService(tc.mock(ctrl))
+			err := svc.RevokeRole(context.Background(), 2, tc.role)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
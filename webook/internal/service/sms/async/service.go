@@ -0,0 +1,162 @@
+// Package async 服务商出问题的时候把短信存到数据库里面，先告诉调用方成功了，再由后台任务重试
+package async
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNoQueued 没有需要重试的短信
+var ErrNoQueued = repository.ErrNoAsyncSMS
+
+type Config struct {
+	// 同步发送超过这个时间就认为服务商变慢了
+	SlowThreshold time.Duration
+	// 发现服务商有问题之后，这段时间内的短信直接存起来异步发送
+	DegradeFor time.Duration
+	MaxRetry   int32
+	// 重试的间隔从 BaseBackoff 开始翻倍，最多到 MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// 抢到之后多久没有结果，别的实例可以再抢
+	Lease time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SlowThreshold: time.Second * 2,
+		DegradeFor:    time.Minute,
+		MaxRetry:      5,
+		BaseBackoff:   time.Second * 10,
+		MaxBackoff:    time.Minute * 5,
+		Lease:         time.Minute,
+	}
+}
+
+type Service struct {
+	svc  sms.Service
+	repo repository.AsyncSMSRepository
+	cfg  Config
+	l    logger.Logger
+	// 降级到什么时候，UnixMilli
+	degradedUntil atomic.Int64
+	now           func() time.Time
+}
+
+func NewService(svc sms.Service, repo repository.AsyncSMSRepository, cfg Config, l logger.Logger) *Service {
+	return &Service{
+		svc:  svc,
+		repo: repo,
+		cfg:  cfg,
+		l:    l,
+		now:  time.Now,
+	}
+}
+
+// Send 服务商正常的时候同步发送，失败了或者已经降级了就存起来，返回成功
+func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	if s.degraded() {
+		return s.enqueue(ctx, biz, args, numbers)
+	}
+	start := s.now()
+	err := s.svc.Send(ctx, biz, args, numbers...)
+	if err == nil {
+		if s.now().Sub(start) > s.cfg.SlowThreshold {
+			s.l.Warn("短信服务商响应太慢，后面的短信改成异步发送",
+				logger.String("biz", biz))
+			s.degrade()
+		}
+		return nil
+	}
+	s.l.Warn("同步发送短信失败，改成异步发送",
+		logger.String("biz", biz), logger.Error(err))
+	s.degrade()
+	if qerr := s.enqueue(ctx, biz, args, numbers); qerr != nil {
+		return errors.Join(err, qerr)
+	}
+	return nil
+}
+
+// SendQueued 抢占一条到了重试时间的短信并发送，没有的话返回 ErrNoQueued。
+// 发送失败只会记录下来等下一次重试，不会返回 error
+func (s *Service) SendQueued(ctx context.Context) error {
+	msg, err := s.repo.Preempt(ctx, s.cfg.Lease)
+	if errors.Is(err, ErrNoQueued) {
+		return err
+	}
+	if err != nil {
+		if msg.Id == 0 {
+			return err
+		}
+		// 内容解不开，重试多少次都没用
+		return s.markDone(s.repo.MarkRetry(ctx, msg, time.Time{}, truncate(err.Error())))
+	}
+	err = s.svc.Send(ctx, msg.Biz, msg.Args, msg.Numbers...)
+	if err == nil {
+		return s.markDone(s.repo.MarkSuccess(ctx, msg))
+	}
+	var next time.Time
+	// 这一次是第 RetryCnt+1 次重试
+	if msg.RetryCnt+1 < msg.MaxRetry {
+		next = s.now().Add(s.backoff(msg.RetryCnt))
+	} else {
+		s.l.Error("异步短信重试次数用完，放弃发送",
+			logger.Int64("id", msg.Id),
+			logger.String("biz", msg.Biz),
+			logger.Error(err))
+	}
+	return s.markDone(s.repo.MarkRetry(ctx, msg, next, truncate(err.Error())))
+}
+
+// markDone 租期过了被别的实例抢走了，结果以别的实例为准
+func (s *Service) markDone(err error) error {
+	if errors.Is(err, repository.ErrAsyncSMSPreempted) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) enqueue(ctx context.Context, biz string, args []string, numbers []string) error {
+	return s.repo.Create(ctx, domain.AsyncSMS{
+		Biz:      biz,
+		Args:     args,
+		Numbers:  numbers,
+		MaxRetry: s.cfg.MaxRetry,
+	})
+}
+
+func (s *Service) degraded() bool {
+	return s.now().UnixMilli() < s.degradedUntil.Load()
+}
+
+func (s *Service) degrade() {
+	s.degradedUntil.Store(s.now().Add(s.cfg.DegradeFor).UnixMilli())
+}
+
+func (s *Service) backoff(retryCnt int32) time.Duration {
+	d := s.cfg.BaseBackoff
+	for i := int32(0); i < retryCnt && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.MaxBackoff)
+}
+
+// truncate 失败原因存在 varchar(512) 里面，按字节截断的时候不能把一个汉字截成两半
+func truncate(reason string) string {
+	const maxLen = 512
+	if len(reason) <= maxLen {
+		return reason
+	}
+	n := maxLen
+	for n > 0 && !utf8.RuneStart(reason[n]) {
+		n--
+	}
+	return reason[:n]
+}
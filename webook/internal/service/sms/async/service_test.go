@@ -0,0 +1,250 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestService_Send(t *testing.T) {
+	now := time.Now()
+	dbErr := errors.New("mock db error")
+	queued := domain.AsyncSMS{
+		Biz:      "login",
+		Args:     []string{"123456"},
+		Numbers:  []string{"13800138000"},
+		MaxRetry: 5,
+	}
+	testCases := []struct {
+		name string
+		// clock 是 Service 看到的当前时间，服务商的 mock 可以拨动它，模拟发送很慢
+		mock          func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository)
+		degradedUntil time.Time
+
+		wantErr      error
+		wantDegraded bool
+	}{
+		{
+			name: "服务商正常，同步发送",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc, repomocks.NewMockAsyncSMSRepository(ctrl)
+			},
+		},
+		{
+			name: "服务商失败了，存起来，对调用方来说还是成功的",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				repo.EXPECT().Create(gomock.Any(), queued).Return(nil)
+				return svc, repo
+			},
+			wantDegraded: true,
+		},
+		{
+			name: "存也存不进去",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				repo.EXPECT().Create(gomock.Any(), queued).Return(dbErr)
+				return svc, repo
+			},
+			wantErr:      dbErr,
+			wantDegraded: true,
+		},
+		{
+			name: "发送成功但是太慢，后面的改成异步",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					DoAndReturn(func(ctx context.Context, biz string, args []string, numbers ...string) error {
+						*clock = clock.Add(time.Second * 3)
+						return nil
+					})
+				return svc, repomocks.NewMockAsyncSMSRepository(ctrl)
+			},
+			wantDegraded: true,
+		},
+		{
+			name: "降级期间不再同步调用服务商",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), queued).Return(nil)
+				return smsmocks.NewMockService(ctrl), repo
+			},
+			degradedUntil: now.Add(time.Second * 30),
+			wantDegraded:  true,
+		},
+		{
+			name: "降级时间过了再试同步发送",
+			mock: func(ctrl *gomock.Controller, clock *time.Time) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc, repomocks.NewMockAsyncSMSRepository(ctrl)
+			},
+			degradedUntil: now.Add(-time.Second),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			clock := now
+			provider, repo := tc.mock(ctrl, &clock)
+			svc := NewService(provider, repo, DefaultConfig(), logger.NewNopLogger())
+			svc.now = func() time.Time {
+				return clock
+			}
+			svc.degradedUntil.Store(tc.degradedUntil.UnixMilli())
+
+			err := svc.Send(context.Background(), "login", []string{"123456"}, "13800138000")
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+			assert.Equal(t, tc.wantDegraded, svc.degraded())
+		})
+	}
+}
+
+func TestService_SendQueued(t *testing.T) {
+	now := time.Now()
+	msg := func(retryCnt int32) domain.AsyncSMS {
+		return domain.AsyncSMS{
+			Id:       1,
+			Biz:      "login",
+			Args:     []string{"123456"},
+			Numbers:  []string{"13800138000"},
+			RetryCnt: retryCnt,
+			MaxRetry: 5,
+		}
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository)
+
+		wantErr error
+	}{
+		{
+			name: "重试成功",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(msg(0), nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				repo.EXPECT().MarkSuccess(gomock.Any(), msg(0)).Return(nil)
+				return svc, repo
+			},
+		},
+		{
+			name: "第一次重试失败",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(msg(0), nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				repo.EXPECT().MarkRetry(gomock.Any(), msg(0), now.Add(time.Second*10), "mock error").Return(nil)
+				return svc, repo
+			},
+		},
+		{
+			name: "间隔翻倍",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(msg(2), nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				repo.EXPECT().MarkRetry(gomock.Any(), msg(2), now.Add(time.Second*40), "mock error").Return(nil)
+				return svc, repo
+			},
+		},
+		{
+			name: "重试次数用完，不再安排下一次",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(msg(4), nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				repo.EXPECT().MarkRetry(gomock.Any(), msg(4), time.Time{}, "mock error").Return(nil)
+				return svc, repo
+			},
+		},
+		{
+			name: "内容解不开，不发送，也不再重试",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).
+					Return(domain.AsyncSMS{Id: 1}, errors.New("decrypt error"))
+				repo.EXPECT().MarkRetry(gomock.Any(), domain.AsyncSMS{Id: 1}, time.Time{}, "decrypt error").Return(nil)
+				return smsmocks.NewMockService(ctrl), repo
+			},
+		},
+		{
+			name: "租期过了被别的实例抢走，以别的实例为准",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(msg(0), nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				repo.EXPECT().MarkSuccess(gomock.Any(), msg(0)).Return(repository.ErrAsyncSMSPreempted)
+				return svc, repo
+			},
+		},
+		{
+			name: "没有要重试的短信",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSMSRepository) {
+				repo := repomocks.NewMockAsyncSMSRepository(ctrl)
+				repo.EXPECT().Preempt(gomock.Any(), time.Minute).Return(domain.AsyncSMS{}, repository.ErrNoAsyncSMS)
+				return smsmocks.NewMockService(ctrl), repo
+			},
+			wantErr: ErrNoQueued,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			provider, repo := tc.mock(ctrl)
+			svc := NewService(provider, repo, DefaultConfig(), logger.NewNopLogger())
+			svc.now = func() time.Time {
+				return now
+			}
+			err := svc.SendQueued(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestService_Backoff(t *testing.T) {
+	testCases := []struct {
+		retryCnt int32
+		want     time.Duration
+	}{
+		{retryCnt: 0, want: time.Second * 10},
+		{retryCnt: 3, want: time.Second * 80},
+		// 最多到 MaxBackoff
+		{retryCnt: 10, want: time.Minute * 5},
+	}
+	svc := NewService(nil, nil, DefaultConfig(), logger.NewNopLogger())
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, svc.backoff(tc.retryCnt))
+	}
+}
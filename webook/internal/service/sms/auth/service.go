@@ -0,0 +1,93 @@
+// Package auth 给内部的其它服务用的短信入口。调用方拿不到服务商的账号，
+// 只能拿到我们签发的业务 token，token 里面写死了能用哪个模板
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
+	"time"
+)
+
+// Issuer 业务 token 的签发方，和登录用的 token 区分开，免得混用
+const Issuer = "webook-sms"
+
+var ErrInvalidToken = errors.New("短信业务 token 不合法")
+
+// Claims 业务 token 里面的内容，Subject 是调用方的名字
+type Claims struct {
+	jwt.RegisteredClaims
+	// 允许使用的模板，也就是模板注册表里面的 biz
+	Tpl string
+}
+
+type Service struct {
+	svc sms.Service
+	key []byte
+	// 按照调用方限流，为 nil 就不限
+	limiter ratelimit.Limiter
+	now     func() time.Time
+}
+
+func NewService(svc sms.Service, key []byte, limiter ratelimit.Limiter) *Service {
+	return &Service{
+		svc:     svc,
+		key:     key,
+		limiter: limiter,
+		now:     time.Now,
+	}
+}
+
+// Send biz 是签发的业务 token，校验通过之后用 token 里面的模板发送
+func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	claims, err := s.Parse(biz)
+	if err != nil {
+		return err
+	}
+	if s.limiter != nil {
+		limited, err := s.limiter.Limit(ctx, "sms:limit:caller:"+claims.Subject)
+		if err != nil {
+			return fmt.Errorf("短信限流出现异常 %w", err)
+		}
+		if limited {
+			return &sms.LimitedError{Scope: sms.LimitScopeCaller}
+		}
+	}
+	return s.svc.Send(ctx, claims.Tpl, args, numbers...)
+}
+
+// Parse 校验签名、签发方和过期时间，没有过期时间的 token 也不认
+func (s *Service) Parse(token string) (Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now))
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Tpl == "" || claims.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: 缺少模板或者调用方", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// NewToken 给调用方 caller 签发只能用模板 tpl 的 token
+func (s *Service) NewToken(caller, tpl string, expiration time.Duration) (string, error) {
+	now := s.now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   caller,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		},
+		Tpl: tpl,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+}
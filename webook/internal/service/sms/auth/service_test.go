@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
+	limitmocks "github.com/zmsocc/practice/webook/pkg/ratelimit/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestService_Send(t *testing.T) {
+	key := []byte("sms-gateway-test-key")
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter)
+		token func(t *testing.T, svc *Service) string
+
+		wantErr error
+		// 触发限流的时候，LimitedError 里面的维度
+		wantScope string
+	}{
+		{
+			name: "校验通过，用 token 里面的模板",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "sms:limit:caller:order").Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc, limiter
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "login", time.Minute)
+			},
+		},
+		{
+			name: "调用方触发限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "sms:limit:caller:order").Return(true, nil)
+				return smsmocks.NewMockService(ctrl), limiter
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "login", time.Minute)
+			},
+			wantScope: sms.LimitScopeCaller,
+		},
+		{
+			name: "限流器出错，不发",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "sms:limit:caller:order").Return(false, errors.New("redis error"))
+				return smsmocks.NewMockService(ctrl), limiter
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "login", time.Minute)
+			},
+			wantErr: errors.New("短信限流出现异常 redis error"),
+		},
+		{
+			name: "别的密钥签的",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, NewService(nil, []byte("another-key"), nil), "order", "login", time.Minute)
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "过期了",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "login", -time.Minute)
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "没有过期时间",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return sign(t, key, Claims{
+					RegisteredClaims: jwt.RegisteredClaims{Issuer: Issuer, Subject: "order"},
+					Tpl:              "login",
+				})
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "签发方不对",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return sign(t, key, Claims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						Issuer:    "webook",
+						Subject:   "order",
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+					},
+					Tpl: "login",
+				})
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "没有模板",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "", time.Minute)
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "没有调用方",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "", "login", time.Minute)
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "根本不是 token",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				return smsmocks.NewMockService(ctrl), limitmocks.NewMockLimiter(ctrl)
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return "login"
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "不限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc, nil
+			},
+			token: func(t *testing.T, svc *Service) string {
+				return newToken(t, svc, "order", "login", time.Minute)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			provider, limiter := tc.mock(ctrl)
+			svc := NewService(provider, key, limiter)
+			err := svc.Send(context.Background(), tc.token(t, svc), []string{"123456"}, "13800138000")
+			switch {
+			case tc.wantScope != "":
+				var lerr *sms.LimitedError
+				require.ErrorAs(t, err, &lerr)
+				assert.Equal(t, tc.wantScope, lerr.Scope)
+			case tc.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.wantErr, ErrInvalidToken):
+				assert.ErrorIs(t, err, tc.wantErr)
+			default:
+				assert.EqualError(t, err, tc.wantErr.Error())
+			}
+		})
+	}
+}
+
+func newToken(t *testing.T, svc *Service, caller, tpl string, expiration time.Duration) string {
+	token, err := svc.NewToken(caller, tpl, expiration)
+	require.NoError(t, err)
+	return token
+}
+
+func sign(t *testing.T, key []byte, claims Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"sync"
+	"time"
+)
+
+type state uint8
+
+const (
+	stateHealthy state = iota
+	// stateDown 被摘掉了，到 until 之后才能试探
+	stateDown
+	// stateProbing 有一个试探的请求正在路上，其它请求先不要过来
+	stateProbing
+)
+
+func (s state) String() string {
+	switch s {
+	case stateHealthy:
+		return "healthy"
+	case stateDown:
+		return "down"
+	case stateProbing:
+		return "probing"
+	default:
+		return "unknown"
+	}
+}
+
+type Stats struct {
+	Name  string
+	State string
+	// 最近一个窗口里面的调用次数、错误率和平均响应时间
+	Calls      int
+	ErrorRate  float64
+	AvgLatency time.Duration
+}
+
+type result struct {
+	failed  bool
+	timeout bool
+	latency time.Duration
+}
+
+type provider struct {
+	name string
+	svc  sms.Service
+
+	mu    sync.Mutex
+	state state
+	until time.Time
+	// 最近的调用结果，环形缓冲区
+	window []result
+	next   int
+	filled bool
+	// 连续超时的次数
+	timeouts int
+}
+
+func newProvider(p Provider, windowSize int) *provider {
+	return &provider{
+		name:   p.Name,
+		svc:    p.Svc,
+		window: make([]result, max(windowSize, 1)),
+	}
+}
+
+// acquire 能不能把请求发给它。冷却结束之后，第一个拿到的请求就是试探请求
+func (p *provider) acquire(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.state {
+	case stateHealthy:
+		return true
+	case stateDown:
+		if now.Before(p.until) {
+			return false
+		}
+		p.state = stateProbing
+		return true
+	default:
+		return false
+	}
+}
+
+// release 试探请求被调用方取消了，没有结论，下一个请求接着试探
+func (p *provider) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == stateProbing {
+		p.state = stateDown
+	}
+}
+
+func (p *provider) record(res result, now time.Time, cfg Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != stateHealthy {
+		// 试探请求，或者所有服务商都被摘掉的时候硬着头皮发的请求
+		if res.failed {
+			p.state = stateDown
+			p.until = now.Add(cfg.Cooldown)
+			return
+		}
+		p.reset()
+		p.state = stateHealthy
+	}
+	p.window[p.next] = res
+	p.next = (p.next + 1) % len(p.window)
+	if p.next == 0 {
+		p.filled = true
+	}
+	if res.timeout {
+		p.timeouts++
+	} else {
+		p.timeouts = 0
+	}
+	calls, failed, _ := p.summary()
+	if p.timeouts >= cfg.MaxTimeouts ||
+		(calls >= cfg.MinCalls && float64(failed)/float64(calls) >= cfg.ErrorRate) {
+		// 统计数据先留着方便排查，恢复的时候再清掉
+		p.state = stateDown
+		p.until = now.Add(cfg.Cooldown)
+	}
+}
+
+func (p *provider) reset() {
+	clear(p.window)
+	p.next = 0
+	p.filled = false
+	p.timeouts = 0
+}
+
+func (p *provider) summary() (calls, failed int, latency time.Duration) {
+	calls = p.next
+	if p.filled {
+		calls = len(p.window)
+	}
+	for _, r := range p.window[:calls] {
+		if r.failed {
+			failed++
+		}
+		latency += r.latency
+	}
+	return calls, failed, latency
+}
+
+func (p *provider) stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	calls, failed, latency := p.summary()
+	res := Stats{
+		Name:  p.name,
+		State: p.state.String(),
+		Calls: calls,
+	}
+	if calls > 0 {
+		res.ErrorRate = float64(failed) / float64(calls)
+		res.AvgLatency = latency / time.Duration(calls)
+	}
+	return res
+}
@@ -0,0 +1,156 @@
+// Package failover 在多个短信服务商之间自动切换。
+// 每个服务商单独统计最近的错误率和响应时间，错误太多或者连续超时就先摘掉，
+// 冷却之后放一个请求过去试探，成功了再加回来
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"sync/atomic"
+	"time"
+)
+
+// Strategy 选择服务商的顺序
+type Strategy uint8
+
+const (
+	// StrategyOrdered 总是从第一个开始，前面的是主服务商，后面的是备用的
+	StrategyOrdered Strategy = iota
+	// StrategyRoundRobin 轮流从不同的服务商开始，把量分摊开
+	StrategyRoundRobin
+)
+
+var ErrAllFailed = errors.New("所有的短信服务商都发送失败")
+
+type Provider struct {
+	Name string
+	Svc  sms.Service
+}
+
+type Config struct {
+	Strategy Strategy
+	// 调用单个服务商的超时时间，服务商自己不理会 ctx 也会按时返回
+	Timeout time.Duration
+	// 统计最近多少次调用
+	WindowSize int
+	// 窗口里面至少有这么多次调用才按错误率判断，免得一两次失败就被摘掉
+	MinCalls  int
+	ErrorRate float64
+	// 连续超时这么多次直接摘掉，不用等错误率
+	MaxTimeouts int
+	// 摘掉之后过多久再试探
+	Cooldown time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Strategy:    StrategyOrdered,
+		Timeout:     time.Second * 3,
+		WindowSize:  50,
+		MinCalls:    10,
+		ErrorRate:   0.5,
+		MaxTimeouts: 3,
+		Cooldown:    time.Minute,
+	}
+}
+
+type Service struct {
+	providers []*provider
+	cfg       Config
+	idx       atomic.Uint64
+	now       func() time.Time
+}
+
+func NewService(providers []Provider, cfg Config) *Service {
+	ps := make([]*provider, 0, len(providers))
+	for _, p := range providers {
+		ps = append(ps, newProvider(p, cfg.WindowSize))
+	}
+	return &Service{
+		providers: ps,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	n := len(s.providers)
+	if n == 0 {
+		return ErrAllFailed
+	}
+	start := 0
+	if s.cfg.Strategy == StrategyRoundRobin {
+		start = int((s.idx.Add(1) - 1) % uint64(n))
+	}
+	var errs []error
+	tried := false
+	for i := 0; i < n; i++ {
+		p := s.providers[(start+i)%n]
+		if !p.acquire(s.now()) {
+			continue
+		}
+		tried = true
+		err := s.send(ctx, p, biz, args, numbers)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+		// 调用方已经不等了，没必要再试下一个
+		if ctx.Err() != nil {
+			return errors.Join(errs...)
+		}
+	}
+	if !tried {
+		// 全都被摘掉了，与其直接失败，不如按顺序都再试一遍
+		for i := 0; i < n; i++ {
+			p := s.providers[(start+i)%n]
+			err := s.send(ctx, p, biz, args, numbers)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+			if ctx.Err() != nil {
+				return errors.Join(errs...)
+			}
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrAllFailed, errors.Join(errs...))
+}
+
+func (s *Service) send(ctx context.Context, p *provider, biz string, args []string, numbers []string) error {
+	cctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- p.svc.Send(cctx, biz, args, numbers...)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-cctx.Done():
+		err = cctx.Err()
+	}
+	// 调用方自己取消的，不算服务商的问题
+	if ctx.Err() != nil {
+		p.release()
+		return err
+	}
+	p.record(result{
+		failed:  err != nil,
+		timeout: errors.Is(err, context.DeadlineExceeded),
+		latency: time.Since(start),
+	}, s.now(), s.cfg)
+	return err
+}
+
+// Stats 每个服务商现在的情况，按照配置的顺序
+func (s *Service) Stats() []Stats {
+	res := make([]Stats, 0, len(s.providers))
+	for _, p := range s.providers {
+		res = append(res, p.stats())
+	}
+	return res
+}
@@ -0,0 +1,237 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动拨动的时钟，用来跳过冷却时间
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// slow 模拟不理会 ctx 的 SDK，一直等到超时
+func slow(ctx context.Context, biz string, args []string, numbers ...string) error {
+	time.Sleep(time.Millisecond * 100)
+	return nil
+}
+
+func expectSend(svc *smsmocks.MockService) *gomock.Call {
+	return svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000")
+}
+
+func TestService_Send(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy Strategy
+		mock     func(ctrl *gomock.Controller) []sms.Service
+		// before 先发 before 条短信，再把时钟往后拨 advance，用来把服务商弄到想要的状态
+		before  int
+		advance time.Duration
+		// ctxTimeout 调用方自己的超时
+		ctxTimeout time.Duration
+
+		wantErr error
+		// 每个服务商的错误都要带上
+		wantErrContains []string
+		wantStates      []string
+		wantStats       func(t *testing.T, stats []Stats)
+	}{
+		{
+			name:     "按顺序，第一个成功就不用第二个",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(nil).Times(3)
+				return []sms.Service{a, b}
+			},
+			before:     2,
+			wantStates: []string{"healthy", "healthy"},
+		},
+		{
+			name:     "按顺序，第一个失败了马上换第二个",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(errors.New("mock error"))
+				expectSend(b).Return(nil)
+				return []sms.Service{a, b}
+			},
+			wantStates: []string{"healthy", "healthy"},
+		},
+		{
+			name:     "轮询，平均分给每一家",
+			strategy: StrategyRoundRobin,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b, c := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(nil).Times(2)
+				expectSend(b).Return(nil).Times(2)
+				expectSend(c).Return(nil).Times(2)
+				return []sms.Service{a, b, c}
+			},
+			before:     5,
+			wantStates: []string{"healthy", "healthy", "healthy"},
+		},
+		{
+			name:     "错误率太高，摘掉之后全部走第二个",
+			strategy: StrategyRoundRobin,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(errors.New("mock error")).Times(4)
+				expectSend(b).Return(nil).Times(10)
+				return []sms.Service{a, b}
+			},
+			before:     9,
+			wantStates: []string{"down", "healthy"},
+			wantStats: func(t *testing.T, stats []Stats) {
+				assert.Equal(t, 4, stats[0].Calls)
+				assert.Equal(t, float64(1), stats[0].ErrorRate)
+			},
+		},
+		{
+			name:     "连续超时被摘掉，冷却期间不再等它",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).DoAndReturn(slow).Times(2)
+				expectSend(b).Return(nil).Times(5)
+				return []sms.Service{a, b}
+			},
+			before:     4,
+			wantStates: []string{"down", "healthy"},
+			wantStats: func(t *testing.T, stats []Stats) {
+				assert.GreaterOrEqual(t, stats[0].AvgLatency, time.Millisecond*20)
+			},
+		},
+		{
+			name:     "冷却结束，试探还是超时，继续摘掉",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).DoAndReturn(slow).Times(3)
+				expectSend(b).Return(nil).Times(3)
+				return []sms.Service{a, b}
+			},
+			before:     2,
+			advance:    time.Minute,
+			wantStates: []string{"down", "healthy"},
+		},
+		{
+			name:     "冷却结束，试探成功就加回来",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).DoAndReturn(slow).Times(2)
+				expectSend(a).Return(nil)
+				expectSend(b).Return(nil).Times(2)
+				return []sms.Service{a, b}
+			},
+			before:     2,
+			advance:    time.Minute,
+			wantStates: []string{"healthy", "healthy"},
+		},
+		{
+			name:     "全部失败",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(errors.New("mock error a"))
+				expectSend(b).Return(errors.New("mock error b"))
+				return []sms.Service{a, b}
+			},
+			wantErr:         ErrAllFailed,
+			wantErrContains: []string{"a: mock error a", "b: mock error b"},
+			wantStates:      []string{"healthy", "healthy"},
+		},
+		{
+			name:     "都被摘掉之后还会硬着头皮试一遍，谁成功了谁就恢复",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).Return(errors.New("mock error a")).Times(5)
+				expectSend(b).Return(errors.New("mock error b")).Times(4)
+				expectSend(b).Return(nil)
+				return []sms.Service{a, b}
+			},
+			before:     4,
+			wantStates: []string{"down", "healthy"},
+		},
+		{
+			name:     "调用方自己超时了，不试下一个，也不算服务商的错",
+			strategy: StrategyOrdered,
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				a, b := smsmocks.NewMockService(ctrl), smsmocks.NewMockService(ctrl)
+				expectSend(a).DoAndReturn(slow)
+				return []sms.Service{a, b}
+			},
+			ctxTimeout: time.Millisecond * 5,
+			wantErr:    context.DeadlineExceeded,
+			wantStates: []string{"healthy", "healthy"},
+			wantStats: func(t *testing.T, stats []Stats) {
+				assert.Equal(t, 0, stats[0].Calls)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			var providers []Provider
+			for i, svc := range tc.mock(ctrl) {
+				providers = append(providers, Provider{Name: string(rune('a' + i)), Svc: svc})
+			}
+			svc := NewService(providers, Config{
+				Strategy:    tc.strategy,
+				Timeout:     time.Millisecond * 20,
+				WindowSize:  10,
+				MinCalls:    4,
+				ErrorRate:   0.5,
+				MaxTimeouts: 2,
+				Cooldown:    time.Minute,
+			})
+			clock := &fakeClock{now: time.Now()}
+			svc.now = clock.Now
+
+			for i := 0; i < tc.before; i++ {
+				// 这里的结果不重要，看最后一次
+				_ = svc.Send(context.Background(), "login", []string{"123456"}, "13800138000")
+			}
+			clock.now = clock.now.Add(tc.advance)
+			ctx := context.Background()
+			if tc.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.ctxTimeout)
+				defer cancel()
+			}
+			err := svc.Send(ctx, "login", []string{"123456"}, "13800138000")
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+			for _, msg := range tc.wantErrContains {
+				assert.ErrorContains(t, err, msg)
+			}
+			stats := svc.Stats()
+			states := make([]string, 0, len(stats))
+			for _, s := range stats {
+				states = append(states, s.State)
+			}
+			assert.Equal(t, tc.wantStates, states)
+			if tc.wantStats != nil {
+				tc.wantStats(t, stats)
+			}
+		})
+	}
+}
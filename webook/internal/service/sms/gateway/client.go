@@ -0,0 +1,74 @@
+// Package gateway 通过 HTTP 调用 webook 的短信网关，Client 实现了 sms.Service，
+// 其它服务把原来的服务商换成它就可以了，不需要拿到服务商的账号
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/internal/service/sms/auth"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"net/http"
+	"strings"
+)
+
+var ErrGateway = errors.New("短信网关返回错误")
+
+type Client struct {
+	// webook 的地址，例如 http://webook.internal
+	addr   string
+	client *http.Client
+}
+
+func NewClient(addr string, client *http.Client) *Client {
+	return &Client{
+		addr:   strings.TrimSuffix(addr, "/"),
+		client: client,
+	}
+}
+
+// Send biz 传签发给调用方的业务 token，用哪个模板由 token 决定
+func (c *Client) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	body, err := json.Marshal(sendReq{Args: args, Numbers: numbers})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr+"/sms/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+biz)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return auth.ErrInvalidToken
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: HTTP 状态码 %d", ErrGateway, resp.StatusCode)
+	}
+	var res ginx.Result
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%w: 解析响应失败 %w", ErrGateway, err)
+	}
+	switch res.Code {
+	case 0:
+		return nil
+	case 6:
+		scope, _ := res.Data.(string)
+		return &sms.LimitedError{Scope: scope}
+	default:
+		return fmt.Errorf("%w: %d %s", ErrGateway, res.Code, res.Msg)
+	}
+}
+
+type sendReq struct {
+	Args    []string `json:"args"`
+	Numbers []string `json:"numbers"`
+}
@@ -0,0 +1,116 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/internal/service/sms/auth"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"github.com/zmsocc/practice/webook/internal/web"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 客户端直接打到真正的 handler 上面，后面接 mock 的服务商
+func TestClient_Send(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) sms.Service
+		// token 为 false 的时候不带签发的 token，直接把模板名字当成 biz
+		token   bool
+		numbers []string
+
+		wantErr error
+		// 限流的错误原样还原出来，调用方可以按维度处理
+		wantScope string
+	}{
+		{
+			name: "发送成功，用 token 里面的模板",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc
+			},
+			token:   true,
+			numbers: []string{"13800138000"},
+		},
+		{
+			name: "token 不合法",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			numbers: []string{"13800138000"},
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name: "没有手机号",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token:   true,
+			wantErr: ErrGateway,
+		},
+		{
+			name: "触发限流",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(&sms.LimitedError{Scope: sms.LimitScopeNumber})
+				return svc
+			},
+			token:     true,
+			numbers:   []string{"13800138000"},
+			wantScope: sms.LimitScopeNumber,
+		},
+		{
+			name: "服务商出错",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(errors.New("mock error"))
+				return svc
+			},
+			token:   true,
+			numbers: []string{"13800138000"},
+			wantErr: ErrGateway,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			authSvc := auth.NewService(tc.mock(ctrl), []byte("sms-gateway-test-key"), nil)
+			server := gin.New()
+			web.NewSMSGatewayHandler(authSvc, logger.NewNopLogger()).RegisterRoutes(server)
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			biz := "login"
+			if tc.token {
+				var err error
+				biz, err = authSvc.NewToken("order", "login", time.Minute)
+				require.NoError(t, err)
+			}
+			var client sms.Service = NewClient(ts.URL+"/", ts.Client())
+			err := client.Send(context.Background(), biz, []string{"123456"}, tc.numbers...)
+			if tc.wantScope != "" {
+				var lerr *sms.LimitedError
+				require.ErrorAs(t, err, &lerr)
+				assert.Equal(t, tc.wantScope, lerr.Scope)
+				return
+			}
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
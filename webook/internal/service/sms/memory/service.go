@@ -2,17 +2,28 @@ package memory
 
 import (
 	"context"
-	"fmt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"strings"
 )
 
+// Service 本地开发用的，不真的发短信，只写日志
 type Service struct {
+	l logger.Logger
 }
 
-func NewService() *Service {
-	return &Service{}
+func NewService(l logger.Logger) *Service {
+	return &Service{
+		l: l,
+	}
 }
 
 func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
-	fmt.Println(args)
+	for _, number := range numbers {
+		// 参数里面是验证码，只在 Debug 级别输出
+		s.l.Debug("模拟发送短信",
+			logger.String("biz", biz),
+			logger.Phone("number", number),
+			logger.String("args", strings.Join(args, ",")))
+	}
 	return nil
 }
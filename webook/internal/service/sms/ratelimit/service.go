@@ -0,0 +1,54 @@
+// Package ratelimit 限制短信的发送频率，一个是所有短信加起来的速率，保护服务商的配额，
+// 一个是单个手机号一天的发送次数，防止被人拿来轰炸别人的手机
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
+	"strings"
+)
+
+type Service struct {
+	svc    sms.Service
+	global ratelimit.Limiter
+	number ratelimit.Limiter
+	// 限流的 key 里面用手机号的盲索引，redis 里面不出现明文的手机号
+	cipher *cryptox.FieldCipher
+}
+
+// NewService global 按照 LimitScopeGlobal 限流，number 按照手机号限流，为 nil 就不限
+func NewService(svc sms.Service, global, number ratelimit.Limiter, cipher *cryptox.FieldCipher) *Service {
+	return &Service{
+		svc:    svc,
+		global: global,
+		number: number,
+		cipher: cipher,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	if s.global != nil {
+		limited, err := s.global.Limit(ctx, "sms:limit:global")
+		if err != nil {
+			return fmt.Errorf("短信限流出现异常 %w", err)
+		}
+		if limited {
+			return &sms.LimitedError{Scope: sms.LimitScopeGlobal}
+		}
+	}
+	if s.number != nil {
+		for _, number := range numbers {
+			limited, err := s.number.Limit(ctx, "sms:limit:number:"+s.cipher.BlindIndex(strings.TrimSpace(number)))
+			if err != nil {
+				return fmt.Errorf("短信限流出现异常 %w", err)
+			}
+			if limited {
+				return &sms.LimitedError{Scope: sms.LimitScopeNumber}
+			}
+		}
+	}
+	return s.svc.Send(ctx, biz, args, numbers...)
+}
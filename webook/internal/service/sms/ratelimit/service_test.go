@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
+	limitmocks "github.com/zmsocc/practice/webook/pkg/ratelimit/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestService_Send(t *testing.T) {
+	cipher, err := cryptox.NewFieldCipher("k1", map[string][]byte{"k1": make([]byte, 32)}, make([]byte, 32))
+	require.NoError(t, err)
+	// key 里面是手机号的盲索引，不是明文
+	numberKey := func(number string) string {
+		return "sms:limit:number:" + cipher.BlindIndex(number)
+	}
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter)
+		numbers []string
+
+		wantErr error
+		// 触发限流的时候，LimitedError 里面的维度
+		wantScope string
+	}{
+		{
+			name: "没有触发限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				global, number := limitmocks.NewMockLimiter(ctrl), limitmocks.NewMockLimiter(ctrl)
+				global.EXPECT().Limit(gomock.Any(), "sms:limit:global").Return(false, nil)
+				number.EXPECT().Limit(gomock.Any(), numberKey("13800138000")).Return(false, nil)
+				number.EXPECT().Limit(gomock.Any(), numberKey("13800138001")).Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000", "13800138001").
+					Return(nil)
+				return svc, global, number
+			},
+			numbers: []string{"13800138000", "13800138001"},
+		},
+		{
+			name: "全局触发限流，不再看手机号",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter) {
+				global := limitmocks.NewMockLimiter(ctrl)
+				global.EXPECT().Limit(gomock.Any(), "sms:limit:global").Return(true, nil)
+				return smsmocks.NewMockService(ctrl), global, limitmocks.NewMockLimiter(ctrl)
+			},
+			numbers:   []string{"13800138000"},
+			wantScope: sms.LimitScopeGlobal,
+		},
+		{
+			name: "其中一个手机号触发限流，整条都不发",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter) {
+				global, number := limitmocks.NewMockLimiter(ctrl), limitmocks.NewMockLimiter(ctrl)
+				global.EXPECT().Limit(gomock.Any(), "sms:limit:global").Return(false, nil)
+				number.EXPECT().Limit(gomock.Any(), numberKey("13800138000")).Return(false, nil)
+				number.EXPECT().Limit(gomock.Any(), numberKey("13800138001")).Return(true, nil)
+				return smsmocks.NewMockService(ctrl), global, number
+			},
+			numbers:   []string{"13800138000", "13800138001"},
+			wantScope: sms.LimitScopeNumber,
+		},
+		{
+			// 短信是要花钱的，限流器出问题的时候宁可不发
+			name: "限流器出错，不发",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter) {
+				global := limitmocks.NewMockLimiter(ctrl)
+				global.EXPECT().Limit(gomock.Any(), "sms:limit:global").Return(false, errors.New("redis error"))
+				return smsmocks.NewMockService(ctrl), global, limitmocks.NewMockLimiter(ctrl)
+			},
+			numbers: []string{"13800138000"},
+			wantErr: errors.New("短信限流出现异常 redis error"),
+		},
+		{
+			name: "不限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, ratelimit.Limiter, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc, nil, nil
+			},
+			numbers: []string{"13800138000"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			sender, global, number := tc.mock(ctrl)
+			svc := NewService(sender, global, number, cipher)
+			err := svc.Send(context.Background(), "login", []string{"123456"}, tc.numbers...)
+			if tc.wantScope != "" {
+				var lerr *sms.LimitedError
+				require.ErrorAs(t, err, &lerr)
+				assert.Equal(t, tc.wantScope, lerr.Scope)
+				return
+			}
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr.Error())
+			}
+		})
+	}
+}
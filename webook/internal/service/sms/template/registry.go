@@ -0,0 +1,70 @@
+// Package template 每个业务场景用哪个短信模板。业务方只传 biz，
+// 到了服务商那里再换成服务商的模板 id 和签名
+package template
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var (
+	ErrUnknownBiz   = errors.New("没有配置这个业务场景的短信模板")
+	ErrArgsMismatch = errors.New("短信参数的个数和模板不一致")
+)
+
+type Template struct {
+	Biz string `yaml:"biz"`
+	// 服务商那边的模板 id
+	Id string `yaml:"id"`
+	// 模板里面按顺序的参数名字，发送的时候参数个数要和它一样
+	Params []string `yaml:"params"`
+	// 为空就用服务商默认的签名
+	SignName string `yaml:"signName"`
+}
+
+// Check 发送的参数能不能填到这个模板里面
+func (t Template) Check(args []string) error {
+	if len(args) != len(t.Params) {
+		return fmt.Errorf("%w: biz %s 需要 %d 个参数，实际 %d 个",
+			ErrArgsMismatch, t.Biz, len(t.Params), len(args))
+	}
+	return nil
+}
+
+// Registry 配置改了之后整个替换掉，读的时候不用加锁
+type Registry struct {
+	tpls atomic.Pointer[map[string]Template]
+}
+
+func NewRegistry(tpls []Template) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Reload(tpls); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Registry) Get(biz string) (Template, error) {
+	tpl, ok := (*r.tpls.Load())[biz]
+	if !ok {
+		return Template{}, fmt.Errorf("%w: %s", ErrUnknownBiz, biz)
+	}
+	return tpl, nil
+}
+
+// Reload 新的配置有问题的话返回 error，继续用原来的
+func (r *Registry) Reload(tpls []Template) error {
+	m := make(map[string]Template, len(tpls))
+	for _, tpl := range tpls {
+		if tpl.Biz == "" || tpl.Id == "" {
+			return fmt.Errorf("短信模板的 biz 和 id 都不能为空: %+v", tpl)
+		}
+		if _, ok := m[tpl.Biz]; ok {
+			return fmt.Errorf("重复的短信模板 biz %s", tpl.Biz)
+		}
+		m[tpl.Biz] = tpl
+	}
+	r.tpls.Store(&m)
+	return nil
+}
@@ -0,0 +1,73 @@
+package template
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRegistry_Reload(t *testing.T) {
+	login := Template{Biz: "login", Id: "1877556", Params: []string{"code"}}
+	testCases := []struct {
+		name string
+		tpls []Template
+
+		wantErr bool
+		// 重新加载之后每个 biz 查出来的模板，没有的 biz 是 ErrUnknownBiz
+		wantTpls map[string]Template
+	}{
+		{
+			name: "替换掉原来的模板",
+			tpls: []Template{
+				{Biz: "login", Id: "1877557", Params: []string{"code"}},
+				{Biz: "bind_phone", Id: "1877558", Params: []string{"code"}, SignName: "webook"},
+			},
+			wantTpls: map[string]Template{
+				"login":      {Biz: "login", Id: "1877557", Params: []string{"code"}},
+				"bind_phone": {Biz: "bind_phone", Id: "1877558", Params: []string{"code"}, SignName: "webook"},
+			},
+		},
+		{
+			name: "删掉的模板查不到了",
+			tpls: []Template{
+				{Biz: "bind_phone", Id: "1877558", Params: []string{"code"}},
+			},
+			wantTpls: map[string]Template{
+				"login":      {},
+				"bind_phone": {Biz: "bind_phone", Id: "1877558", Params: []string{"code"}},
+			},
+		},
+		{
+			name: "biz 重复，继续用原来的",
+			tpls: []Template{
+				{Biz: "login", Id: "1877559"},
+				{Biz: "login", Id: "1877560"},
+			},
+			wantErr:  true,
+			wantTpls: map[string]Template{"login": login, "bind_phone": {}},
+		},
+		{
+			name:     "没有模板 id，继续用原来的",
+			tpls:     []Template{{Biz: "login"}},
+			wantErr:  true,
+			wantTpls: map[string]Template{"login": login},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRegistry([]Template{login})
+			require.NoError(t, err)
+			err = r.Reload(tc.tpls)
+			assert.Equal(t, tc.wantErr, err != nil)
+			for biz, want := range tc.wantTpls {
+				tpl, err := r.Get(biz)
+				if want.Biz == "" {
+					assert.ErrorIs(t, err, ErrUnknownBiz)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, want, tpl)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package template
+
+import (
+	"context"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+)
+
+// Service 放在最外面，没有模板或者参数对不上的短信直接拒绝，
+// 免得被限流算进去，或者存起来白白重试
+type Service struct {
+	svc      sms.Service
+	registry *Registry
+}
+
+func NewService(svc sms.Service, registry *Registry) *Service {
+	return &Service{
+		svc:      svc,
+		registry: registry,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	tpl, err := s.registry.Get(biz)
+	if err != nil {
+		return err
+	}
+	if err = tpl.Check(args); err != nil {
+		return err
+	}
+	return s.svc.Send(ctx, biz, args, numbers...)
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	smsmocks "github.com/zmsocc/practice/webook/internal/service/sms/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestService_Send(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) sms.Service
+		biz  string
+		args []string
+
+		wantErr error
+	}{
+		{
+			name: "参数对得上，交给下一层",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").Return(nil)
+				return svc
+			},
+			biz:  "login",
+			args: []string{"123456"},
+		},
+		{
+			name: "参数个数不对，不发",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			biz:     "login",
+			args:    []string{"123456", "10"},
+			wantErr: ErrArgsMismatch,
+		},
+		{
+			name: "没有配置模板，不发",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			biz:     "reset_password",
+			args:    []string{"123456"},
+			wantErr: ErrUnknownBiz,
+		},
+		{
+			name: "下一层的错误原样返回",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "login", []string{"123456"}, "13800138000").
+					Return(&sms.LimitedError{Scope: sms.LimitScopeGlobal})
+				return svc
+			},
+			biz:     "login",
+			args:    []string{"123456"},
+			wantErr: &sms.LimitedError{Scope: sms.LimitScopeGlobal},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			r, err := NewRegistry([]Template{
+				{Biz: "login", Id: "1877556", Params: []string{"code"}},
+			})
+			require.NoError(t, err)
+			svc := NewService(tc.mock(ctrl), r)
+			err = svc.Send(context.Background(), tc.biz, tc.args, "13800138000")
+			switch {
+			case tc.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.wantErr, ErrArgsMismatch), errors.Is(tc.wantErr, ErrUnknownBiz):
+				assert.ErrorIs(t, err, tc.wantErr)
+			default:
+				assert.Equal(t, tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -7,24 +7,28 @@ import (
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	sms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
+	"github.com/zmsocc/practice/webook/internal/service/sms/template"
 	"os"
 )
 
 type Service struct {
-	client   *sms.Client
-	appId    *string
-	signName *string
+	client *sms.Client
+	appId  *string
+	// 模板没有单独配置签名的时候用这个
+	signName string
+	tpls     *template.Registry
 }
 
-func NewService(client *sms.Client, appId string, signName string) *Service {
+func NewService(client *sms.Client, appId string, signName string, tpls *template.Registry) *Service {
 	return &Service{
 		client:   client,
 		appId:    ekit.ToPtr[string](appId),
-		signName: ekit.ToPtr[string](signName),
+		signName: signName,
+		tpls:     tpls,
 	}
 }
 
-func InitSmsTencentService() *Service {
+func InitSmsTencentService(tpls *template.Registry) *Service {
 	secretId, ok := os.LookupEnv("TENCENT_SECRET_ID")
 	if !ok {
 		panic("没有找到环境变量 TENCENT_SECRET_ID")
@@ -34,14 +38,23 @@ func InitSmsTencentService() *Service {
 	if err != nil {
 		panic("没有找到环境变量 TENCENT_SECRET_KEY")
 	}
-	return NewService(c, "1400842696", "妙影科技")
+	return NewService(c, "1400842696", "妙影科技", tpls)
 }
 
+// Send biz 是业务场景，在模板注册表里面换成腾讯的模板 id 和签名
 func (s *Service) Send(ctx context.Context, biz string, args []string, numbers ...string) error {
+	tpl, err := s.tpls.Get(biz)
+	if err != nil {
+		return err
+	}
+	signName := tpl.SignName
+	if signName == "" {
+		signName = s.signName
+	}
 	req := sms.NewSendSmsRequest()
 	req.SmsSdkAppId = s.appId
-	req.SignName = s.signName
-	req.TemplateId = ekit.ToPtr[string](biz)
+	req.SignName = ekit.ToPtr[string](signName)
+	req.TemplateId = ekit.ToPtr[string](tpl.Id)
 	req.PhoneNumberSet = toStringPtrSlice(numbers)
 	req.TemplateParamSet = toStringPtrSlice(args)
 	//req.SetContext(ctx)
@@ -1,7 +1,29 @@
 package sms
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type Service interface {
 	Send(ctx context.Context, biz string, args []string, numbers ...string) error
 }
+
+// 限流的维度
+const (
+	// LimitScopeGlobal 所有短信加起来的发送速率
+	LimitScopeGlobal = "global"
+	// LimitScopeNumber 单个手机号一天之内的发送次数
+	LimitScopeNumber = "number"
+	// LimitScopeCaller 短信网关上单个内部调用方的发送速率
+	LimitScopeCaller = "caller"
+)
+
+// LimitedError 触发了限流，短信没有发出去。用 errors.As 拿到它，按 Scope 给用户不同的提示
+type LimitedError struct {
+	Scope string
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("短信发送触发限流: %s", e.Scope)
+}
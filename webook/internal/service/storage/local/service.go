@@ -0,0 +1,122 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/service/storage"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Service 把对象存到本地目录，由 web 服务器把 dir 挂到 baseURL 下面
+type Service struct {
+	dir     string
+	baseURL string
+}
+
+func NewService(dir, baseURL string) *Service {
+	return &Service{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *Service) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	// 先写临时文件再改名，别人不会读到写了一半的文件
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Chmod(f.Name(), 0644); err != nil {
+		return "", err
+	}
+	if err = os.Rename(f.Name(), p); err != nil {
+		return "", err
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *Service) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, storage.ErrObjectNotFound
+	}
+	return data, err
+}
+
+func (s *Service) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	var res []storage.Object
+	// 只需要遍历 prefix 所在的那个目录
+	root := filepath.Join(s.dir, filepath.FromSlash(prefix[:strings.LastIndex(prefix, "/")+1]))
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// 还没写过任何东西
+				return nil
+			}
+			return err
+		}
+		// 写了一半的临时文件不算
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		res = append(res, storage.Object{Key: key, Mtime: info.ModTime()})
+		return nil
+	})
+	return res, err
+}
+
+// path 不允许 key 跳出 dir
+func (s *Service) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return "", storage.ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidKey     = errors.New("非法的对象名")
+	ErrObjectNotFound = errors.New("对象不存在")
+)
+
+// Service 对象存储，key 用 / 分隔，比如 avatars/1_256.jpg。
+// 本地开发用文件系统，线上可以换成 S3 兼容的实现
+type Service interface {
+	// Put 保存对象，已经存在就覆盖，返回可以直接访问的 URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Get 读取对象，不存在返回 ErrObjectNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// List 列出 key 以 prefix 开头的对象，用来清理过期的文件
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+type Object struct {
+	Key string
+	// 最后一次写入的时间
+	Mtime time.Time
+}
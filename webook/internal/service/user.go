@@ -2,59 +2,187 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/pkg/totp"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
+)
+
+const (
+	totpIssuer      = "webook"
+	recoveryCodeCnt = 10
 )
 
 var (
 	ErrUserDuplicateEmail = repository.ErrUserDuplicateEmail
 	ErrInvalidUserOrEmail = errors.New("无效的邮箱或密码")
 	ErrUserNotFound       = errors.New("用户不存在")
+	ErrInvalidOldPassword = errors.New("原密码错误")
+	ErrTotpAlreadyEnabled = errors.New("两步验证已经开启")
+	ErrTotpNotEnrolled    = errors.New("还没有绑定验证器")
+	ErrInvalidTotpCode    = errors.New("两步验证码错误")
+	ErrPhoneAlreadySet    = errors.New("当前账号已经绑定手机号")
+	ErrEmailAlreadySet    = errors.New("当前账号已经绑定邮箱")
+	ErrAccountConflict    = errors.New("已被其他账号使用")
 )
 
 type UserService interface {
-	Signup(ctx context.Context, u domain.User) error
+	// Signup inviteCode 可以为空，开启了邀请注册的时候为空返回 ErrInviteCodeRequired，
+	// 填了但是不能用返回 ErrInvalidInviteCode
+	Signup(ctx context.Context, u domain.User, inviteCode string) error
+	// Login 密码正确但是账号被封禁或者暂停，返回 ErrUserBanned 或者 ErrUserSuspended，
+	// 暂停的时候同时返回用户，可以拿到暂停到什么时候
 	Login(ctx context.Context, email, password string) (domain.User, error)
 	Profile(ctx context.Context, id int64) (domain.User, error)
 	EditProfile(ctx context.Context, u domain.User) error
-	FindOrCreate(ctx context.Context, phone string) (domain.User, error)
+	// FindOrCreate 账号状态的检查同 Login，第一次登录的时候自动注册，inviteCode 同 Signup
+	FindOrCreate(ctx context.Context, phone string, inviteCode string) (domain.User, error)
+	// CheckInvite 短信登录校验验证码之前先调用，手机号还没注册的话检查邀请码，错误同 Signup。
+	// 邀请码不对的时候验证码还没被用掉，改了邀请码可以接着用
+	CheckInvite(ctx context.Context, phone string, inviteCode string) error
+	FindByEmail(ctx context.Context, email string) (domain.User, error)
+	// ResetPassword 忘记密码，已经通过邮箱验证码验证过身份了
+	ResetPassword(ctx context.Context, email, password string) (domain.User, error)
+	// ChangePassword 已登录用户修改密码，需要校验原密码
+	ChangePassword(ctx context.Context, uid int64, oldPassword, password string) error
+
+	// EnrollTotp 生成 TOTP 密钥，返回给验证器 App 扫码用的 otpauth:// 链接，还没有开启
+	EnrollTotp(ctx context.Context, uid int64) (string, error)
+	// ActivateTotp 用验证器上的验证码确认绑定成功，开启两步验证，返回一次性的恢复码
+	ActivateTotp(ctx context.Context, uid int64, code string) ([]string, error)
+	DisableTotp(ctx context.Context, uid int64, code string) error
+	// VerifyTwoFactor 登录的第二步，code 可以是验证器上的验证码，也可以是恢复码
+	VerifyTwoFactor(ctx context.Context, uid int64, code string) error
+
+	// FindOrCreateByOAuth 第三方登录，第一次登录的时候自动注册，inviteCode 同 Signup。
+	// UnionId 和已有的用户一样的话，说明是同一个人从同一个开放平台下面的别的应用进来，直接绑定到这个用户
+	FindOrCreateByOAuth(ctx context.Context, info domain.OAuthInfo, inviteCode string) (domain.User, error)
+
+	// BindPhone 给当前账号绑定手机号。手机号已经注册过别的账号的时候，
+	// merge 为 false 返回 ErrAccountConflict，为 true 就把那个账号合并进来，返回被合并的账号 id
+	BindPhone(ctx context.Context, uid int64, phone string, merge bool) (int64, error)
+	// BindEmail 同 BindPhone
+	BindEmail(ctx context.Context, uid int64, email string, merge bool) (int64, error)
+
+	// ReEncrypt 密钥轮换之后，分批把手机号、邮箱和 TOTP 密钥用新的密钥重新加密，语义同 repository
+	ReEncrypt(ctx context.Context, afterId int64, limit int) (int64, int, error)
+	// PhoneConflicts 同一个手机号注册过的多个账号，给运维一次性处理用，见 main.go 的 -phone-conflicts
+	PhoneConflicts(ctx context.Context) ([]domain.PhoneConflict, error)
+	// MergePhoneConflict 把 Uids 后面的账号逐个合并到 Uids[0]，和绑定手机号时候的合并是同一套流程
+	MergePhoneConflict(ctx context.Context, c domain.PhoneConflict) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	invite InviteService
 }
 
-func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.User, error) {
+func (svc *userService) FindOrCreate(ctx context.Context, phone string, inviteCode string) (domain.User, error) {
 	u, err := svc.repo.FindByPhone(ctx, phone)
+	if err == nil {
+		return u, checkLogin(u)
+	}
 	if !errors.Is(err, repository.ErrUserNotFound) {
 		return u, err
 	}
 	u = domain.User{
 		Phone: phone,
 	}
-	err = svc.repo.Create(ctx, u)
+	err = svc.create(ctx, u, inviteCode)
 	if err != nil && !errors.Is(err, repository.ErrUserDuplicateEmail) {
 		return u, err
 	}
 	return svc.repo.FindByPhone(ctx, phone)
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
+func (svc *userService) CheckInvite(ctx context.Context, phone string, inviteCode string) error {
+	_, err := svc.repo.FindByPhone(ctx, phone)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+	return svc.invite.Validate(ctx, inviteCode)
+}
+
+func (svc *userService) FindOrCreateByOAuth(ctx context.Context, info domain.OAuthInfo, inviteCode string) (domain.User, error) {
+	u, err := svc.repo.FindByOAuth(ctx, info.Provider, info.OpenId)
+	if err == nil {
+		return u, checkLogin(u)
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return u, err
+	}
+	if info.UnionId != "" {
+		// 同一个开放平台下面别的应用登录过，是同一个人，绑定到原来的账号上面
+		u, err = svc.repo.FindByUnionId(ctx, info.Provider, info.UnionId)
+		switch {
+		case err == nil:
+			err = svc.repo.BindOAuth(ctx, u.Id, info)
+			if err != nil && !errors.Is(err, repository.ErrUserDuplicateEmail) {
+				return domain.User{}, err
+			}
+			return u, checkLogin(u)
+		case !errors.Is(err, repository.ErrUserNotFound):
+			return domain.User{}, err
+		}
+	}
+	code := normalizeInviteCode(inviteCode)
+	if code == "" && svc.invite.Required() {
+		return domain.User{}, ErrInviteCodeRequired
+	}
+	err = svc.repo.CreateWithOAuth(ctx, domain.User{
+		Nickname: info.Nickname,
+	}, info, code)
+	if errors.Is(err, repository.ErrInviteCodeInvalid) {
+		return domain.User{}, ErrInvalidInviteCode
+	}
+	// 并发的时候可能别人已经创建好了
+	if err != nil && !errors.Is(err, repository.ErrUserDuplicateEmail) {
+		return domain.User{}, err
+	}
+	return svc.repo.FindByOAuth(ctx, info.Provider, info.OpenId)
+}
+
+func NewUserService(repo repository.UserRepository, invite InviteService) UserService {
 	return &userService{
-		repo: repo,
+		repo:   repo,
+		invite: invite,
 	}
 }
 
-func (svc *userService) Signup(ctx context.Context, u domain.User) error {
+func (svc *userService) Signup(ctx context.Context, u domain.User, inviteCode string) error {
 	// BCrypt 加密
 	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 	u.Password = string(hash)
-	return svc.repo.Create(ctx, u)
+	return svc.create(ctx, u, inviteCode)
+}
+
+// create 创建用户，带了邀请码就在同一个事务里面用掉它
+func (svc *userService) create(ctx context.Context, u domain.User, inviteCode string) error {
+	code := normalizeInviteCode(inviteCode)
+	if code == "" {
+		if svc.invite.Required() {
+			return ErrInviteCodeRequired
+		}
+		return svc.repo.Create(ctx, u)
+	}
+	err := svc.repo.CreateWithInvite(ctx, u, code)
+	if errors.Is(err, repository.ErrInviteCodeInvalid) {
+		return ErrInvalidInviteCode
+	}
+	return err
 }
 
 func (svc *userService) Login(ctx context.Context, email, password string) (domain.User, error) {
@@ -70,7 +198,8 @@ func (svc *userService) Login(ctx context.Context, email, password string) (doma
 	if err != nil {
 		return domain.User{}, ErrInvalidUserOrEmail
 	}
-	return u, nil
+	// 密码对了才告诉他账号的状态，不然别人能用这个来探测账号
+	return u, checkLogin(u)
 }
 
 func (svc *userService) Profile(ctx context.Context, id int64) (domain.User, error) {
@@ -86,3 +215,235 @@ func (svc *userService) EditProfile(ctx context.Context, u domain.User) error {
 	}
 	return svc.repo.Update(ctx, u)
 }
+
+func (svc *userService) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	u, err := svc.repo.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return domain.User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+func (svc *userService) ResetPassword(ctx context.Context, email, password string) (domain.User, error) {
+	u, err := svc.FindByEmail(ctx, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return u, svc.repo.UpdatePassword(ctx, u.Id, string(hash))
+}
+
+func (svc *userService) ChangePassword(ctx context.Context, uid int64, oldPassword, password string) error {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(oldPassword))
+	if err != nil {
+		return ErrInvalidOldPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return svc.repo.UpdatePassword(ctx, uid, string(hash))
+}
+
+func (svc *userService) EnrollTotp(ctx context.Context, uid int64) (string, error) {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	if u.TotpEnabled {
+		return "", ErrTotpAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	err = svc.repo.UpdateTotpSecret(ctx, uid, secret)
+	if err != nil {
+		return "", err
+	}
+	account := u.Email
+	if account == "" {
+		account = u.Phone
+	}
+	return totp.URI(totpIssuer, account, secret), nil
+}
+
+func (svc *userService) ActivateTotp(ctx context.Context, uid int64, code string) ([]string, error) {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if u.TotpEnabled {
+		return nil, ErrTotpAlreadyEnabled
+	}
+	secret, err := svc.repo.FindTotpSecret(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, ErrTotpNotEnrolled
+	}
+	ok, err := svc.checkTotp(ctx, uid, secret, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTotpCode
+	}
+	codes := make([]string, 0, recoveryCodeCnt)
+	hashes := make([]string, 0, recoveryCodeCnt)
+	for i := 0; i < recoveryCodeCnt; i++ {
+		c, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+	err = svc.repo.EnableTotp(ctx, uid, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (svc *userService) DisableTotp(ctx context.Context, uid int64, code string) error {
+	err := svc.VerifyTwoFactor(ctx, uid, code)
+	if err != nil {
+		return err
+	}
+	return svc.repo.DisableTotp(ctx, uid)
+}
+
+func (svc *userService) VerifyTwoFactor(ctx context.Context, uid int64, code string) error {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !u.TotpEnabled {
+		return ErrTotpNotEnrolled
+	}
+	secret, err := svc.repo.FindTotpSecret(ctx, uid)
+	if err != nil {
+		return err
+	}
+	ok, err := svc.checkTotp(ctx, uid, secret, code)
+	if err != nil || ok {
+		return err
+	}
+	// 不是验证码，就当恢复码试一下
+	err = svc.repo.ConsumeRecoveryCode(ctx, uid, hashRecoveryCode(code))
+	if errors.Is(err, repository.ErrRecoveryCodeNotFound) {
+		return ErrInvalidTotpCode
+	}
+	return err
+}
+
+// checkTotp 校验验证码，同一个周期的验证码只能用一次，被人看到了也没办法马上拿去重放
+func (svc *userService) checkTotp(ctx context.Context, uid int64, secret, code string) (bool, error) {
+	step, ok := totp.Match(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return svc.repo.MarkTotpUsed(ctx, uid, step, totp.Lifetime())
+}
+
+func (svc *userService) BindPhone(ctx context.Context, uid int64, phone string, merge bool) (int64, error) {
+	u, err := svc.Profile(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	if u.Phone != "" {
+		return 0, ErrPhoneAlreadySet
+	}
+	other, err := svc.repo.FindByPhone(ctx, phone)
+	return svc.bind(ctx, uid, other, err, merge, func() error {
+		return svc.repo.UpdatePhone(ctx, uid, phone)
+	})
+}
+
+func (svc *userService) BindEmail(ctx context.Context, uid int64, email string, merge bool) (int64, error) {
+	u, err := svc.Profile(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	if u.Email != "" {
+		return 0, ErrEmailAlreadySet
+	}
+	other, err := svc.repo.FindByEmail(ctx, email)
+	return svc.bind(ctx, uid, other, err, merge, func() error {
+		return svc.repo.UpdateEmail(ctx, uid, email)
+	})
+}
+
+// bind other 是手机号或者邮箱原本的主人，findErr 是查找它的时候的错误
+func (svc *userService) bind(ctx context.Context, uid int64,
+	other domain.User, findErr error, merge bool, update func() error) (int64, error) {
+	switch {
+	case errors.Is(findErr, repository.ErrUserNotFound):
+		// 没人用过，直接绑定
+		err := update()
+		if errors.Is(err, repository.ErrUserDuplicateEmail) {
+			// 并发的时候被别人抢先注册了
+			return 0, ErrAccountConflict
+		}
+		return 0, err
+	case findErr != nil:
+		return 0, findErr
+	case other.Id == uid:
+		return 0, nil
+	case !merge:
+		return 0, ErrAccountConflict
+	}
+	// 合并的时候，手机号或者邮箱会跟着转移过来
+	err := svc.repo.Merge(ctx, uid, other.Id)
+	if err != nil {
+		return 0, err
+	}
+	return other.Id, nil
+}
+
+// generateRecoveryCode 恢复码形如 xxxxx-xxxxx，去掉了容易看错的字符
+func generateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = alphabet[int(buf[i])%len(alphabet)]
+	}
+	return string(buf[:5]) + "-" + string(buf[5:]), nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+func (svc *userService) ReEncrypt(ctx context.Context, afterId int64, limit int) (int64, int, error) {
+	return svc.repo.ReEncrypt(ctx, afterId, limit)
+}
+
+func (svc *userService) PhoneConflicts(ctx context.Context) ([]domain.PhoneConflict, error) {
+	return svc.repo.FindPhoneConflicts(ctx)
+}
+
+func (svc *userService) MergePhoneConflict(ctx context.Context, c domain.PhoneConflict) error {
+	for _, uid := range c.Uids[1:] {
+		if err := svc.repo.Merge(ctx, c.Uids[0], uid); err != nil {
+			return fmt.Errorf("合并账号 %d 到 %d 失败: %w", uid, c.Uids[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"time"
+)
+
+var (
+	ErrUserBanned        = errors.New("账号已被封禁")
+	ErrUserSuspended     = errors.New("账号已被暂停使用")
+	ErrUserMuted         = errors.New("账号已被禁言")
+	ErrInvalidUserStatus = errors.New("非法的用户状态")
+)
+
+// UserStatusService 封禁、暂停、禁言用户
+type UserStatusService interface {
+	// Check 用个人访问令牌访问的时候检查，被封禁返回 ErrUserBanned，
+	// 暂停期间返回 ErrUserSuspended，这个时候返回的用户里面有暂停到什么时候
+	Check(ctx context.Context, uid int64) (domain.User, error)
+	// UpdateStatus 修改用户状态，suspendedUntil 只有暂停的时候才有用。
+	// 封禁和暂停的时候会踢掉所有已经登录的设备，JWT 请求就不用每次都查用户状态了
+	UpdateStatus(ctx context.Context, uid int64, status domain.UserStatus, suspendedUntil time.Time) error
+}
+
+type userStatusService struct {
+	repo     repository.UserRepository
+	sessions SessionRevoker
+}
+
+func NewUserStatusService(repo repository.UserRepository, sessions SessionRevoker) UserStatusService {
+	return &userStatusService{
+		repo:     repo,
+		sessions: sessions,
+	}
+}
+
+func (svc *userStatusService) Check(ctx context.Context, uid int64) (domain.User, error) {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return domain.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	return u, checkLogin(u)
+}
+
+func (svc *userStatusService) UpdateStatus(ctx context.Context, uid int64,
+	status domain.UserStatus, suspendedUntil time.Time) error {
+	if !status.Valid() {
+		return ErrInvalidUserStatus
+	}
+	if status == domain.UserStatusSuspended {
+		if !suspendedUntil.After(time.Now()) {
+			return ErrInvalidUserStatus
+		}
+	} else {
+		suspendedUntil = time.Time{}
+	}
+	err := svc.repo.UpdateStatus(ctx, uid, status, suspendedUntil)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// 封禁和暂停期间都登录不了，已经登录的 session 失效了也就拿不到新的 token
+	if status == domain.UserStatusBanned || status == domain.UserStatusSuspended {
+		return svc.sessions.RevokeAllSessions(ctx, uid)
+	}
+	return nil
+}
+
+// checkLogin 登录和每次请求的时候检查
+func checkLogin(u domain.User) error {
+	now := time.Now()
+	switch {
+	case u.Status == domain.UserStatusBanned:
+		return ErrUserBanned
+	case u.Suspended(now):
+		return ErrUserSuspended
+	default:
+		return nil
+	}
+}
+
+// checkWritable 发文章、点赞、收藏之前检查，禁言的用户返回 ErrUserMuted
+func checkWritable(ctx context.Context, repo repository.UserRepository, uid int64) error {
+	u, err := repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if err = checkLogin(u); err != nil {
+		return err
+	}
+	if u.Status == domain.UserStatusMuted {
+		return ErrUserMuted
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	ijwtmocks "github.com/zmsocc/practice/webook/internal/web/ijwt/mocks"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestUserStatusService_UpdateStatus(t *testing.T) {
+	until := time.Now().Add(time.Hour * 24)
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker)
+		status domain.UserStatus
+		until  time.Time
+
+		wantErr error
+	}{
+		{
+			name: "封禁，踢掉所有设备",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				sessions := ijwtmocks.NewMockHandler(ctrl)
+				repo.EXPECT().UpdateStatus(gomock.Any(), int64(1), domain.UserStatusBanned, time.Time{}).Return(nil)
+				sessions.EXPECT().RevokeAllSessions(gomock.Any(), int64(1)).Return(nil)
+				return repo, sessions
+			},
+			status: domain.UserStatusBanned,
+		},
+		{
+			name: "暂停，踢掉所有设备",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				sessions := ijwtmocks.NewMockHandler(ctrl)
+				repo.EXPECT().UpdateStatus(gomock.Any(), int64(1), domain.UserStatusSuspended, until).Return(nil)
+				sessions.EXPECT().RevokeAllSessions(gomock.Any(), int64(1)).Return(nil)
+				return repo, sessions
+			},
+			status: domain.UserStatusSuspended,
+			until:  until,
+		},
+		{
+			name: "禁言还能读，不用踢掉",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateStatus(gomock.Any(), int64(1), domain.UserStatusMuted, time.Time{}).Return(nil)
+				return repo, ijwtmocks.NewMockHandler(ctrl)
+			},
+			status: domain.UserStatusMuted,
+			until:  until,
+		},
+		{
+			name: "暂停时间已经过了",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				return repomocks.NewMockUserRepository(ctrl), ijwtmocks.NewMockHandler(ctrl)
+			},
+			status:  domain.UserStatusSuspended,
+			until:   time.Now().Add(-time.Hour),
+			wantErr: ErrInvalidUserStatus,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateStatus(gomock.Any(), int64(1), domain.UserStatusBanned, time.Time{}).
+					Return(repository.ErrUserNotFound)
+				return repo, ijwtmocks.NewMockHandler(ctrl)
+			},
+			status:  domain.UserStatusBanned,
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "踢掉设备失败",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, SessionRevoker) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				sessions := ijwtmocks.NewMockHandler(ctrl)
+				repo.EXPECT().UpdateStatus(gomock.Any(), int64(1), domain.UserStatusBanned, time.Time{}).Return(nil)
+				sessions.EXPECT().RevokeAllSessions(gomock.Any(), int64(1)).Return(errors.New("mock error"))
+				return repo, sessions
+			},
+			status:  domain.UserStatusBanned,
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserStatusService(tc.mock(ctrl))
+			err := svc.UpdateStatus(context.Background(), 1, tc.status, tc.until)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserStatusService_Check(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+		},
+		{
+			name: "禁言的用户可以访问",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Status: domain.UserStatusMuted}, nil)
+				return repo
+			},
+		},
+		{
+			name: "被封禁",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Status: domain.UserStatusBanned}, nil)
+				return repo
+			},
+			wantErr: ErrUserBanned,
+		},
+		{
+			name: "暂停期间",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Status: domain.UserStatusSuspended, SuspendedUntil: time.Now().Add(time.Hour),
+				}, nil)
+				return repo
+			},
+			wantErr: ErrUserSuspended,
+		},
+		{
+			name: "暂停已经结束",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Status: domain.UserStatusSuspended, SuspendedUntil: time.Now().Add(-time.Hour),
+				}, nil)
+				return repo
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserStatusService(tc.mock(ctrl), ijwtmocks.NewMockHandler(ctrl))
+			_, err := svc.Check(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
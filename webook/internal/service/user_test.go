@@ -0,0 +1,741 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	repomocks "github.com/zmsocc/practice/webook/internal/repository/mocks"
+	"github.com/zmsocc/practice/webook/pkg/totp"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+// bcryptOf 匹配 password 的 bcrypt 哈希
+type bcryptOf string
+
+func (m bcryptOf) Matches(x any) bool {
+	hash, ok := x.(string)
+	return ok && bcrypt.CompareHashAndPassword([]byte(hash), []byte(m)) == nil
+}
+
+func (m bcryptOf) String() string {
+	return "bcrypt hash of " + string(m)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "重置成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{Id: 1, Email: "123@qq.com"}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1), bcryptOf("hello#world123")).
+					Return(nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 1, Email: "123@qq.com"},
+		},
+		{
+			name: "邮箱没注册",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "更新失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{Id: 1, Email: "123@qq.com"}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).
+					Return(errors.New("mock error"))
+				return repo
+			},
+			wantUser: domain.User{Id: 1, Email: "123@qq.com"},
+			wantErr:  errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			u, err := svc.ResetPassword(context.Background(), "123@qq.com", "hello#world123")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("hello#world123"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) repository.UserRepository
+		oldPassword string
+
+		wantErr error
+	}{
+		{
+			name: "修改成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Password: string(oldHash)}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1), bcryptOf("new#world123")).
+					Return(nil)
+				return repo
+			},
+			oldPassword: "hello#world123",
+		},
+		{
+			name: "原密码不对",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Password: string(oldHash)}, nil)
+				return repo
+			},
+			oldPassword: "hello#world124",
+			wantErr:     ErrInvalidOldPassword,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			oldPassword: "hello#world123",
+			wantErr:     ErrUserNotFound,
+		},
+		{
+			name: "更新失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Password: string(oldHash)}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).
+					Return(errors.New("mock error"))
+				return repo
+			},
+			oldPassword: "hello#world123",
+			wantErr:     errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			err := svc.ChangePassword(context.Background(), 1, tc.oldPassword, "new#world123")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_ActivateTotp(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := totp.Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := now.Unix() / 30
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		code string
+
+		wantCodes int
+		wantErr   error
+	}{
+		{
+			name: "开启成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().MarkTotpUsed(gomock.Any(), int64(1), step, totp.Lifetime()).
+					Return(true, nil)
+				repo.EXPECT().EnableTotp(gomock.Any(), int64(1), gomock.Len(recoveryCodeCnt)).
+					Return(nil)
+				return repo
+			},
+			code:      code,
+			wantCodes: recoveryCodeCnt,
+		},
+		{
+			name: "验证码已经用过了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().MarkTotpUsed(gomock.Any(), int64(1), step, totp.Lifetime()).
+					Return(false, nil)
+				return repo
+			},
+			code:    code,
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				return repo
+			},
+			code:    "abcdef",
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "还没有绑定",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return("", nil)
+				return repo
+			},
+			code:    code,
+			wantErr: ErrTotpNotEnrolled,
+		},
+		{
+			name: "已经开启了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, TotpEnabled: true}, nil)
+				return repo
+			},
+			code:    code,
+			wantErr: ErrTotpAlreadyEnabled,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			codes, err := svc.ActivateTotp(context.Background(), 1, tc.code)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Len(t, codes, tc.wantCodes)
+		})
+	}
+}
+
+func TestUserService_VerifyTwoFactor(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := totp.Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := now.Unix() / 30
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		code string
+
+		wantErr error
+	}{
+		{
+			name: "验证码正确",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, TotpEnabled: true}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().MarkTotpUsed(gomock.Any(), int64(1), step, totp.Lifetime()).
+					Return(true, nil)
+				return repo
+			},
+			code: code,
+		},
+		{
+			name: "重放用过的验证码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, TotpEnabled: true}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().MarkTotpUsed(gomock.Any(), int64(1), step, totp.Lifetime()).
+					Return(false, nil)
+				repo.EXPECT().ConsumeRecoveryCode(gomock.Any(), int64(1), hashRecoveryCode(code)).
+					Return(repository.ErrRecoveryCodeNotFound)
+				return repo
+			},
+			code:    code,
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "恢复码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, TotpEnabled: true}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().ConsumeRecoveryCode(gomock.Any(), int64(1), hashRecoveryCode("abcde-fghjk")).
+					Return(nil)
+				return repo
+			},
+			code: "abcde-fghjk",
+		},
+		{
+			name: "标记失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, TotpEnabled: true}, nil)
+				repo.EXPECT().FindTotpSecret(gomock.Any(), int64(1)).Return(secret, nil)
+				repo.EXPECT().MarkTotpUsed(gomock.Any(), int64(1), step, totp.Lifetime()).
+					Return(false, errors.New("mock error"))
+				return repo
+			},
+			code:    code,
+			wantErr: errors.New("mock error"),
+		},
+		{
+			name: "没有开启",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			code:    code,
+			wantErr: ErrTotpNotEnrolled,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			err := svc.VerifyTwoFactor(context.Background(), 1, tc.code)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_FindOrCreateByOAuth(t *testing.T) {
+	info := domain.OAuthInfo{Provider: "wechat", OpenId: "open-id", UnionId: "union-id", Nickname: "大明"}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		info domain.OAuthInfo
+		// 是否开启了邀请注册
+		required   bool
+		inviteCode string
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "已经绑定过",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			info:     info,
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "UnionId 一样，绑定到原来的用户",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().BindOAuth(gomock.Any(), int64(1), info).Return(nil)
+				return repo
+			},
+			info:     info,
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "并发绑定过了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().BindOAuth(gomock.Any(), int64(1), info).
+					Return(repository.ErrUserDuplicateEmail)
+				return repo
+			},
+			info:     info,
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "UnionId 对应的用户被封禁了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{Id: 1, Status: domain.UserStatusBanned}, nil)
+				repo.EXPECT().BindOAuth(gomock.Any(), int64(1), info).Return(nil)
+				return repo
+			},
+			info:     info,
+			wantUser: domain.User{Id: 1, Status: domain.UserStatusBanned},
+			wantErr:  ErrUserBanned,
+		},
+		{
+			name: "新用户",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithOAuth(gomock.Any(), domain.User{Nickname: "大明"}, info, "").Return(nil)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{Id: 2, Nickname: "大明"}, nil)
+				return repo
+			},
+			info:     info,
+			wantUser: domain.User{Id: 2, Nickname: "大明"},
+		},
+		{
+			name: "没有 UnionId，直接注册",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithOAuth(gomock.Any(), domain.User{}, gomock.Any(), "").Return(nil)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{Id: 2}, nil)
+				return repo
+			},
+			info:     domain.OAuthInfo{Provider: "wechat", OpenId: "open-id"},
+			wantUser: domain.User{Id: 2},
+		},
+		{
+			name: "已经绑定过，开启了邀请注册也不用邀请码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			info:     info,
+			required: true,
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "新用户，开启了邀请注册，没有邀请码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			info:     info,
+			required: true,
+			wantErr:  ErrInviteCodeRequired,
+		},
+		{
+			name: "新用户，用邀请码注册",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithOAuth(gomock.Any(), domain.User{Nickname: "大明"}, info, "ABCD2345").
+					Return(nil)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{Id: 2, Nickname: "大明"}, nil)
+				return repo
+			},
+			info:       info,
+			required:   true,
+			inviteCode: " abcd2345 ",
+			wantUser:   domain.User{Id: 2, Nickname: "大明"},
+		},
+		{
+			name: "新用户，邀请码不能用",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithOAuth(gomock.Any(), domain.User{Nickname: "大明"}, info, "ABCD2345").
+					Return(repository.ErrInviteCodeInvalid)
+				return repo
+			},
+			info:       info,
+			required:   true,
+			inviteCode: "ABCD2345",
+			wantErr:    ErrInvalidInviteCode,
+		},
+		{
+			name: "按 UnionId 查询失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByOAuth(gomock.Any(), "wechat", "open-id").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByUnionId(gomock.Any(), "wechat", "union-id").
+					Return(domain.User{}, errors.New("mock error"))
+				return repo
+			},
+			info:    info,
+			wantErr: errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl),
+				NewInviteService(repomocks.NewMockInviteRepository(ctrl), InviteConfig{Required: tc.required}))
+			u, err := svc.FindOrCreateByOAuth(context.Background(), tc.info, tc.inviteCode)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestUserService_BindPhone(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) repository.UserRepository
+		merge bool
+
+		wantMerged int64
+		wantErr    error
+	}{
+		{
+			name: "没人用过，直接绑定",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().UpdatePhone(gomock.Any(), int64(1), "15212345678").Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "并发的时候被别人抢先了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().UpdatePhone(gomock.Any(), int64(1), "15212345678").
+					Return(repository.ErrUserDuplicateEmail)
+				return repo
+			},
+			wantErr: ErrAccountConflict,
+		},
+		{
+			name: "别的账号用了，不合并",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{Id: 2}, nil)
+				return repo
+			},
+			wantErr: ErrAccountConflict,
+		},
+		{
+			name: "别的账号用了，合并进来",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{Id: 2}, nil)
+				repo.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).Return(nil)
+				return repo
+			},
+			merge:      true,
+			wantMerged: 2,
+		},
+		{
+			name: "合并失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{Id: 2}, nil)
+				repo.EXPECT().Merge(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock error"))
+				return repo
+			},
+			merge:   true,
+			wantErr: errors.New("mock error"),
+		},
+		{
+			name: "本来就是自己的",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").
+					Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			merge: true,
+		},
+		{
+			name: "已经绑定过手机号",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByID(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, Phone: "15200000000"}, nil)
+				return repo
+			},
+			wantErr: ErrPhoneAlreadySet,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			merged, err := svc.BindPhone(context.Background(), 1, "15212345678", tc.merge)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantMerged, merged)
+		})
+	}
+}
+
+func TestUserService_CheckInvite(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mock       func(ctrl *gomock.Controller) (repository.UserRepository, repository.InviteRepository)
+		required   bool
+		inviteCode string
+
+		wantErr error
+	}{
+		{
+			name: "已经注册过，不用邀请码",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.InviteRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").Return(domain.User{Id: 1}, nil)
+				return repo, repomocks.NewMockInviteRepository(ctrl)
+			},
+			required: true,
+		},
+		{
+			name: "第一次登录，邀请码可以用",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.InviteRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				inviteRepo := repomocks.NewMockInviteRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").Return(domain.User{}, repository.ErrUserNotFound)
+				inviteRepo.EXPECT().Usable(gomock.Any(), "ABCD2345", gomock.Any()).Return(true, nil)
+				return repo, inviteRepo
+			},
+			required:   true,
+			inviteCode: "ABCD2345",
+		},
+		{
+			name: "第一次登录，没有邀请码",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.InviteRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").Return(domain.User{}, repository.ErrUserNotFound)
+				return repo, repomocks.NewMockInviteRepository(ctrl)
+			},
+			required: true,
+			wantErr:  ErrInviteCodeRequired,
+		},
+		{
+			name: "查询用户失败",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.InviteRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "15212345678").Return(domain.User{}, errors.New("mock error"))
+				return repo, repomocks.NewMockInviteRepository(ctrl)
+			},
+			required: true,
+			wantErr:  errors.New("mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, inviteRepo := tc.mock(ctrl)
+			svc := NewUserService(repo, NewInviteService(inviteRepo, InviteConfig{Required: tc.required}))
+			err := svc.CheckInvite(context.Background(), "15212345678", tc.inviteCode)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_MergePhoneConflict(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		wantErr error
+	}{
+		{
+			name: "其它账号逐个合并到第一个",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().Merge(gomock.Any(), int64(1), int64(5)).Return(nil)
+				repo.EXPECT().Merge(gomock.Any(), int64(1), int64(9)).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "合并失败，后面的不再合并",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().Merge(gomock.Any(), int64(1), int64(5)).Return(errors.New("mock error"))
+				return repo
+			},
+			wantErr: errors.New("合并账号 5 到 1 失败: mock error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			err := svc.MergePhoneConflict(context.Background(), domain.PhoneConflict{
+				Phone: "15212345678",
+				Uids:  []int64{1, 5, 9},
+			})
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"strconv"
+	"time"
+)
+
+// AccessTokenHandler 管理个人访问令牌，这些接口本身只能用 JWT 访问
+type AccessTokenHandler struct {
+	svc service.AccessTokenService
+	l   logger.Logger
+}
+
+func NewAccessTokenHandler(svc service.AccessTokenService, l logger.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *AccessTokenHandler) RegisterRoutes(server *gin.Engine) {
+	tg := server.Group("/users/tokens")
+	tg.POST("", ginx.WrapBody(h.Create))
+	tg.GET("", ginx.WrapBody(h.List))
+	tg.DELETE("/:id", ginx.WrapBody(h.Revoke))
+}
+
+func (h *AccessTokenHandler) Create(ctx *gin.Context) (Result, error) {
+	type CreateReq struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		// 多少天之后过期，0 代表永不过期
+		ExpiresInDays int `json:"expires_in_days"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req CreateReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 365 {
+		return Result{Code: 4, Msg: "有效期最长一年"}, nil
+	}
+	t := domain.AccessToken{
+		Uid:    uc.Uid,
+		Name:   req.Name,
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		t.ExpiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+	t, token, err := h.svc.Create(ctx, t)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidAccessTokenName):
+		return Result{Code: 4, Msg: "令牌名字不能为空，并且不能超过 64 个字符"}, nil
+	case errors.Is(err, service.ErrInvalidScope):
+		return Result{Code: 4, Msg: "无效的权限范围"}, nil
+	case errors.Is(err, service.ErrTooManyAccessTokens):
+		return Result{Code: 4, Msg: "访问令牌数量太多，请先删除不用的令牌"}, nil
+	default:
+		h.l.Error("创建访问令牌失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	vo := h.toVO(t)
+	vo.Token = token
+	return Result{Msg: "请妥善保存令牌，关闭之后就看不到了", Data: vo}, nil
+}
+
+func (h *AccessTokenHandler) List(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	ts, err := h.svc.List(ctx, uc.Uid)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{
+		Data: slice.Map[domain.AccessToken, AccessTokenVO](ts, func(idx int, src domain.AccessToken) AccessTokenVO {
+			return h.toVO(src)
+		}),
+	}, nil
+}
+
+func (h *AccessTokenHandler) Revoke(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	err = h.svc.Revoke(ctx, uc.Uid, id)
+	if errors.Is(err, service.ErrAccessTokenNotFound) {
+		return Result{Code: 4, Msg: "令牌不存在"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "令牌已删除"}, nil
+}
+
+func (h *AccessTokenHandler) toVO(t domain.AccessToken) AccessTokenVO {
+	vo := AccessTokenVO{
+		Id:     t.Id,
+		Name:   t.Name,
+		Prefix: t.Prefix,
+		Scopes: t.Scopes,
+		Ctime:  t.Ctime.Format(time.DateTime),
+	}
+	if !t.LastUsedAt.IsZero() {
+		vo.LastUsedAt = t.LastUsedAt.Format(time.DateTime)
+	}
+	if !t.ExpiresAt.IsZero() {
+		vo.ExpiresAt = t.ExpiresAt.Format(time.DateTime)
+	}
+	return vo
+}
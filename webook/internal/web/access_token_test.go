@@ -0,0 +1,196 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveAccessToken(t *testing.T, mock func(svc *svcmocks.MockAccessTokenService),
+	method, path, body string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	svc := svcmocks.NewMockAccessTokenService(ctrl)
+	if mock != nil {
+		mock(svc)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("users", ijwt.UserClaims{Uid: 1, Ssid: "ssid"})
+	})
+	NewAccessTokenHandler(svc, logger.NewNopLogger()).RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestAccessTokenHandler_Create(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	testCases := []struct {
+		name    string
+		mock    func(svc *svcmocks.MockAccessTokenService)
+		reqBody string
+
+		wantCode int
+		wantMsg  string
+		wantData AccessTokenVO
+	}{
+		{
+			name: "永不过期",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Create(gomock.Any(), domain.AccessToken{
+					Uid:    1,
+					Name:   "备份脚本",
+					Scopes: []string{domain.ScopeArticleRead},
+				}).Return(domain.AccessToken{
+					Id:     10,
+					Uid:    1,
+					Name:   "备份脚本",
+					Prefix: "wbk_abcdef",
+					Scopes: []string{domain.ScopeArticleRead},
+					Ctime:  now,
+				}, "wbk_abcdefghijk", nil)
+			},
+			reqBody: `{"name":"备份脚本","scopes":["article:read"]}`,
+			wantMsg: "请妥善保存令牌，关闭之后就看不到了",
+			wantData: AccessTokenVO{
+				Id:     10,
+				Name:   "备份脚本",
+				Prefix: "wbk_abcdef",
+				Scopes: []string{domain.ScopeArticleRead},
+				Ctime:  now.Format(time.DateTime),
+				Token:  "wbk_abcdefghijk",
+			},
+		},
+		{
+			name: "带过期时间",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, at domain.AccessToken) (domain.AccessToken, string, error) {
+						assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), at.ExpiresAt, time.Minute)
+						at.Id = 10
+						at.ExpiresAt = now
+						at.Ctime = now
+						return at, "wbk_abcdefghijk", nil
+					})
+			},
+			reqBody: `{"name":"备份脚本","scopes":["article:read"],"expires_in_days":30}`,
+			wantMsg: "请妥善保存令牌，关闭之后就看不到了",
+			wantData: AccessTokenVO{
+				Id:        10,
+				Name:      "备份脚本",
+				Scopes:    []string{domain.ScopeArticleRead},
+				ExpiresAt: now.Format(time.DateTime),
+				Ctime:     now.Format(time.DateTime),
+				Token:     "wbk_abcdefghijk",
+			},
+		},
+		{
+			name:     "有效期太长",
+			reqBody:  `{"name":"备份脚本","scopes":["article:read"],"expires_in_days":366}`,
+			wantCode: 4,
+			wantMsg:  "有效期最长一年",
+		},
+		{
+			name: "无效的权限范围",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(domain.AccessToken{}, "", service.ErrInvalidScope)
+			},
+			reqBody:  `{"name":"备份脚本","scopes":["user:role"]}`,
+			wantCode: 4,
+			wantMsg:  "无效的权限范围",
+		},
+		{
+			name: "令牌太多",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(domain.AccessToken{}, "", service.ErrTooManyAccessTokens)
+			},
+			reqBody:  `{"name":"备份脚本","scopes":["article:read"]}`,
+			wantCode: 4,
+			wantMsg:  "访问令牌数量太多，请先删除不用的令牌",
+		},
+		{
+			name: "系统错误",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(domain.AccessToken{}, "", errors.New("mock error"))
+			},
+			reqBody:  `{"name":"备份脚本","scopes":["article:read"]}`,
+			wantCode: 5,
+			wantMsg:  "系统错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAccessToken(t, tc.mock, http.MethodPost, "/users/tokens", tc.reqBody)
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res struct {
+				Code int
+				Msg  string
+				Data AccessTokenVO
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Equal(t, tc.wantCode, res.Code)
+			assert.Equal(t, tc.wantMsg, res.Msg)
+			assert.Equal(t, tc.wantData, res.Data)
+		})
+	}
+}
+
+func TestAccessTokenHandler_Revoke(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockAccessTokenService)
+		path string
+
+		wantRes Result
+	}{
+		{
+			name: "删除成功",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Revoke(gomock.Any(), int64(1), int64(10)).Return(nil)
+			},
+			path:    "/users/tokens/10",
+			wantRes: Result{Msg: "令牌已删除"},
+		},
+		{
+			name: "不是自己的令牌",
+			mock: func(svc *svcmocks.MockAccessTokenService) {
+				svc.EXPECT().Revoke(gomock.Any(), int64(1), int64(10)).Return(service.ErrAccessTokenNotFound)
+			},
+			path:    "/users/tokens/10",
+			wantRes: Result{Code: 4, Msg: "令牌不存在"},
+		},
+		{
+			name:    "ID 不对",
+			path:    "/users/tokens/abc",
+			wantRes: Result{Code: 4, Msg: "参数错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAccessToken(t, tc.mock, http.MethodDelete, tc.path, "")
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"net/http"
+	"time"
+)
+
+// AccountHandler 导出个人数据和注销账号
+type AccountHandler struct {
+	svc service.AccountService
+	l   logger.Logger
+}
+
+func NewAccountHandler(svc service.AccountService, l logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *AccountHandler) RegisterRoutes(server *gin.Engine) {
+	ug := server.Group("/users")
+	ug.POST("/export", ginx.WrapBody(h.Export))
+	ug.GET("/export", ginx.WrapBody(h.ExportStatus))
+	ug.GET("/export/download", h.Download)
+
+	ug.DELETE("/me", ginx.WrapBody(h.Delete))
+	ug.POST("/me/deletion/cancel", ginx.WrapBody(h.CancelDeletion))
+}
+
+func (h *AccountHandler) Export(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	task, err := h.svc.Export(ctx, uc.Uid)
+	switch {
+	case err == nil:
+		return Result{Msg: "正在打包，请稍后查看", Data: h.toVO(task)}, nil
+	case errors.Is(err, service.ErrExportInProgress):
+		return Result{Code: 4, Msg: "正在打包，请稍后查看"}, nil
+	case errors.Is(err, service.ErrExportTooFrequent):
+		return Result{Code: 6, Msg: "导出太频繁，请稍后再试"}, nil
+	default:
+		h.l.Error("导出个人数据失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+func (h *AccountHandler) ExportStatus(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	task, err := h.svc.ExportTask(ctx, uc.Uid)
+	if errors.Is(err, service.ErrExportTaskNotFound) {
+		return Result{Code: 4, Msg: "没有导出任务"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: h.toVO(task)}, nil
+}
+
+func (h *AccountHandler) Download(ctx *gin.Context) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	data, err := h.svc.ExportFile(ctx, uc.Uid)
+	if errors.Is(err, service.ErrExportTaskNotFound) || errors.Is(err, service.ErrExportNotReady) {
+		ctx.JSON(http.StatusOK, Result{Code: 4, Msg: "导出文件还没有准备好"})
+		return
+	}
+	if err != nil {
+		h.l.Error("读取导出文件失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="webook-export.zip"`)
+	ctx.Data(http.StatusOK, "application/zip", data)
+}
+
+// Delete 申请注销。冷静期过了之后，个人信息会被清除，文章会被撤回，点赞、收藏、阅读记录也会被删除
+func (h *AccountHandler) Delete(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	deleteAt, err := h.svc.RequestDeletion(ctx, uc.Uid)
+	if err != nil {
+		h.l.Error("申请注销失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{
+		Msg:  "已申请注销，在此之前可以撤销",
+		Data: deleteAt.Format(time.DateTime),
+	}, nil
+}
+
+func (h *AccountHandler) CancelDeletion(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	err := h.svc.CancelDeletion(ctx, uc.Uid)
+	if errors.Is(err, service.ErrNoPendingDeletion) {
+		return Result{Code: 4, Msg: "没有待注销的申请"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "已撤销注销"}, nil
+}
+
+func (h *AccountHandler) toVO(task domain.ExportTask) ExportTaskVO {
+	return ExportTaskVO{
+		Status: task.Status.ToUint8(),
+		Ctime:  task.Ctime.Format(time.DateTime),
+		Utime:  task.Utime.Format(time.DateTime),
+	}
+}
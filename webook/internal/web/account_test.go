@@ -0,0 +1,147 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveAccount(t *testing.T, mock func(svc *svcmocks.MockAccountService),
+	method, path string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	svc := svcmocks.NewMockAccountService(ctrl)
+	if mock != nil {
+		mock(svc)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("users", ijwt.UserClaims{Uid: 1, Ssid: "ssid"})
+	})
+	NewAccountHandler(svc, logger.NewNopLogger()).RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(nil))
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestAccountHandler_Export(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockAccountService)
+
+		wantRes Result
+	}{
+		{
+			name: "开始打包",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().Export(gomock.Any(), int64(1)).Return(domain.ExportTask{
+					Uid: 1, Status: domain.ExportStatusPending, Ctime: now, Utime: now,
+				}, nil)
+			},
+			wantRes: Result{
+				Msg: "正在打包，请稍后查看",
+				Data: map[string]any{
+					"status": float64(domain.ExportStatusPending),
+					"ctime":  now.Format(time.DateTime),
+					"utime":  now.Format(time.DateTime),
+				},
+			},
+		},
+		{
+			name: "正在打包",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().Export(gomock.Any(), int64(1)).Return(domain.ExportTask{}, service.ErrExportInProgress)
+			},
+			wantRes: Result{Code: 4, Msg: "正在打包，请稍后查看"},
+		},
+		{
+			name: "太频繁",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().Export(gomock.Any(), int64(1)).Return(domain.ExportTask{}, service.ErrExportTooFrequent)
+			},
+			wantRes: Result{Code: 6, Msg: "导出太频繁，请稍后再试"},
+		},
+		{
+			name: "系统错误",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().Export(gomock.Any(), int64(1)).Return(domain.ExportTask{}, errors.New("mock error"))
+			},
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAccount(t, tc.mock, http.MethodPost, "/users/export")
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestAccountHandler_Download(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockAccountService)
+
+		// 下载成功的时候是 zip，否则是 JSON
+		wantZip []byte
+		wantRes Result
+	}{
+		{
+			name: "下载",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().ExportFile(gomock.Any(), int64(1)).Return([]byte("zip"), nil)
+			},
+			wantZip: []byte("zip"),
+		},
+		{
+			name: "还没打包好",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().ExportFile(gomock.Any(), int64(1)).Return(nil, service.ErrExportNotReady)
+			},
+			wantRes: Result{Code: 4, Msg: "导出文件还没有准备好"},
+		},
+		{
+			name: "没有导出任务",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().ExportFile(gomock.Any(), int64(1)).Return(nil, service.ErrExportTaskNotFound)
+			},
+			wantRes: Result{Code: 4, Msg: "导出文件还没有准备好"},
+		},
+		{
+			name: "系统错误",
+			mock: func(svc *svcmocks.MockAccountService) {
+				svc.EXPECT().ExportFile(gomock.Any(), int64(1)).Return(nil, errors.New("mock error"))
+			},
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAccount(t, tc.mock, http.MethodGet, "/users/export/download")
+			if tc.wantZip == nil {
+				assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+				return
+			}
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="webook-export.zip"`, resp.Header().Get("Content-Disposition"))
+			assert.Equal(t, tc.wantZip, resp.Body.Bytes())
+		})
+	}
+}
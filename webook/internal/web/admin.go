@@ -0,0 +1,257 @@
+package web
+
+import (
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/internal/web/middleware"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"strconv"
+	"time"
+)
+
+// AdminHandler 给审核、运营、运维用的接口，统一挂在 /admin 下面，每个接口各自声明需要的权限
+type AdminHandler struct {
+	artSvc      service.ArticleService
+	rbacSvc     service.RBACService
+	loginLogSvc service.LoginLogService
+	statusSvc   service.UserStatusService
+	inviteSvc   service.InviteService
+	l           logger.Logger
+}
+
+func NewAdminHandler(artSvc service.ArticleService, rbacSvc service.RBACService,
+	loginLogSvc service.LoginLogService, statusSvc service.UserStatusService,
+	inviteSvc service.InviteService, l logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		artSvc:      artSvc,
+		rbacSvc:     rbacSvc,
+		loginLogSvc: loginLogSvc,
+		statusSvc:   statusSvc,
+		inviteSvc:   inviteSvc,
+		l:           l,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(server *gin.Engine) {
+	ag := server.Group("/admin")
+	ag.POST("/articles/takedown",
+		middleware.RequirePermission(domain.PermArticleTakedown),
+		ginx.WrapBody(h.TakeDownArticle))
+
+	ag.GET("/users/:id/logins",
+		middleware.RequirePermission(domain.PermLoginAudit),
+		ginx.WrapBody(h.LoginLogs))
+
+	ag.POST("/users/status",
+		middleware.RequirePermission(domain.PermUserStatus),
+		ginx.WrapBody(h.UpdateUserStatus))
+
+	ig := ag.Group("", middleware.RequirePermission(domain.PermInviteManage))
+	ig.POST("/invites", ginx.WrapBody(h.GenerateInvite))
+	ig.GET("/invitations", ginx.WrapBody(h.Invitations))
+
+	rg := ag.Group("/users", middleware.RequirePermission(domain.PermRoleManage))
+	rg.GET("/:id/roles", ginx.WrapBody(h.Roles))
+	rg.POST("/roles/grant", ginx.WrapBody(h.GrantRole))
+	rg.POST("/roles/revoke", ginx.WrapBody(h.RevokeRole))
+}
+
+func (h *AdminHandler) TakeDownArticle(ctx *gin.Context) (Result, error) {
+	type TakeDownReq struct {
+		Id int64 `json:"id"`
+		// 下架原因，只记日志
+		Reason string `json:"reason"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req TakeDownReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	err := h.artSvc.TakeDown(ctx, req.Id)
+	if err != nil {
+		h.l.Error("下架文章失败",
+			logger.Int64("aid", req.Id), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	h.l.Info("下架文章",
+		logger.Int64("aid", req.Id),
+		logger.Int64("operator", uc.Uid),
+		logger.String("reason", req.Reason))
+	return Result{Msg: "下架成功"}, nil
+}
+
+// LoginLogs 查看某个用户的登录记录，排查账号被盗之类的问题
+func (h *AdminHandler) LoginLogs(ctx *gin.Context) (Result, error) {
+	uid, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	offset, limit, ok := pageQuery(ctx, loginLogPageDefaultLimit, loginLogPageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	logs, err := h.loginLogSvc.List(ctx, uid, offset, limit)
+	if err != nil {
+		h.l.Error("查询登录记录失败", logger.Int64("uid", uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toLoginLogVOs(logs)}, nil
+}
+
+// UpdateUserStatus 封禁、暂停、禁言或者恢复用户
+func (h *AdminHandler) UpdateUserStatus(ctx *gin.Context) (Result, error) {
+	type UpdateUserStatusReq struct {
+		Uid int64 `json:"uid"`
+		// 0 正常，1 禁言，2 暂停，3 封禁
+		Status uint8 `json:"status"`
+		// 暂停多少个小时，只有暂停的时候才需要
+		Hours  int64  `json:"hours"`
+		Reason string `json:"reason"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req UpdateUserStatusReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.Uid == uc.Uid {
+		return Result{Code: 4, Msg: "不能修改自己的状态"}, nil
+	}
+	var until time.Time
+	if req.Hours > 0 {
+		until = time.Now().Add(time.Duration(req.Hours) * time.Hour)
+	}
+	err := h.statusSvc.UpdateStatus(ctx, req.Uid, domain.UserStatus(req.Status), until)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidUserStatus):
+		return Result{Code: 4, Msg: "状态不对，暂停需要指定时长"}, nil
+	case errors.Is(err, service.ErrUserNotFound):
+		return Result{Code: 4, Msg: "用户不存在"}, nil
+	default:
+		h.l.Error("修改用户状态失败", logger.Int64("uid", req.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	h.l.Info("修改用户状态",
+		logger.Int64("uid", req.Uid),
+		logger.Int64("status", int64(req.Status)),
+		logger.Int64("operator", uc.Uid),
+		logger.String("reason", req.Reason))
+	return Result{Msg: "修改成功"}, nil
+}
+
+func (h *AdminHandler) Roles(ctx *gin.Context) (Result, error) {
+	uid, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	roles, err := h.rbacSvc.Roles(ctx, uid)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{
+		Data: slice.Map[domain.Role, RoleVO](roles, func(idx int, src domain.Role) RoleVO {
+			return RoleVO{
+				Name:        src.Name,
+				Permissions: src.Permissions,
+			}
+		}),
+	}, nil
+}
+
+type roleReq struct {
+	Uid  int64  `json:"uid"`
+	Role string `json:"role"`
+}
+
+func (h *AdminHandler) GrantRole(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req roleReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	err := h.rbacSvc.GrantRole(ctx, req.Uid, req.Role)
+	if errors.Is(err, service.ErrRoleNotFound) {
+		return Result{Code: 4, Msg: "角色不存在"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	h.l.Info("授予角色",
+		logger.Int64("uid", req.Uid),
+		logger.String("role", req.Role),
+		logger.Int64("operator", uc.Uid))
+	return Result{Msg: "授权成功"}, nil
+}
+
+func (h *AdminHandler) RevokeRole(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req roleReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.Uid == uc.Uid && req.Role == domain.RoleAdmin {
+		// 收回自己的管理员角色多半是误操作，收回之后自己也改不回来了
+		return Result{Code: 4, Msg: "不能收回自己的管理员角色"}, nil
+	}
+	err := h.rbacSvc.RevokeRole(ctx, req.Uid, req.Role)
+	if errors.Is(err, service.ErrLastAdmin) {
+		return Result{Code: 4, Msg: "至少要保留一个管理员"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	h.l.Info("收回角色",
+		logger.Int64("uid", req.Uid),
+		logger.String("role", req.Role),
+		logger.Int64("operator", uc.Uid))
+	return Result{Msg: "已收回"}, nil
+}
+
+// GenerateInvite 内测发给种子用户的邀请码，可以用很多次
+func (h *AdminHandler) GenerateInvite(ctx *gin.Context) (Result, error) {
+	type GenerateInviteReq struct {
+		MaxUses int32 `json:"max_uses"`
+		// 多少个小时之后过期，0 代表不过期
+		Hours int64 `json:"hours"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req GenerateInviteReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.Hours < 0 {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	c, err := h.inviteSvc.GenerateByAdmin(ctx, uc.Uid, req.MaxUses, time.Duration(req.Hours)*time.Hour)
+	if errors.Is(err, service.ErrInvalidInviteUses) {
+		return Result{Code: 4, Msg: "可用次数必须大于 0"}, nil
+	}
+	if err != nil {
+		h.l.Error("生成邀请码失败", logger.Int64("operator", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toInviteCodeVO(c)}, nil
+}
+
+// Invitations 谁邀请了谁，可以用 inviter 过滤
+func (h *AdminHandler) Invitations(ctx *gin.Context) (Result, error) {
+	inviter, err := strconv.ParseInt(ctx.DefaultQuery("inviter", "0"), 10, 64)
+	if err != nil || inviter < 0 {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	offset, limit, ok := pageQuery(ctx, invitePageDefaultLimit, invitePageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	invs, err := h.inviteSvc.Invitations(ctx, inviter, offset, limit)
+	if err != nil {
+		h.l.Error("查询邀请记录失败", logger.Int64("inviter", inviter), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toInvitationVOs(invs)}, nil
+}
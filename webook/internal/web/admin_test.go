@@ -0,0 +1,180 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveAdmin 以 uid 的身份、带着 perms 这些权限访问 /admin 下面的接口
+func serveAdmin(t *testing.T, mock func(rbacSvc *svcmocks.MockRBACService), uid int64, perms []string,
+	method, path, body string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	rbacSvc := svcmocks.NewMockRBACService(ctrl)
+	if mock != nil {
+		mock(rbacSvc)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("users", ijwt.UserClaims{Uid: uid, Ssid: "ssid", Perms: perms})
+	})
+	h := NewAdminHandler(svcmocks.NewMockArticleService(ctrl), rbacSvc,
+		svcmocks.NewMockLoginLogService(ctrl), svcmocks.NewMockUserStatusService(ctrl),
+		svcmocks.NewMockInviteService(ctrl), logger.NewNopLogger())
+	h.RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestAdminHandler_GrantRole(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(rbacSvc *svcmocks.MockRBACService)
+		perms   []string
+		reqBody string
+
+		wantCode int
+		wantRes  Result
+	}{
+		{
+			name: "授权成功",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().GrantRole(gomock.Any(), int64(2), domain.RoleModerator).Return(nil)
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"moderator"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Msg: "授权成功"},
+		},
+		{
+			name: "角色不存在",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().GrantRole(gomock.Any(), int64(2), "root").Return(service.ErrRoleNotFound)
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"root"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Code: 4, Msg: "角色不存在"},
+		},
+		{
+			name: "系统错误",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().GrantRole(gomock.Any(), int64(2), domain.RoleModerator).
+					Return(errors.New("mock error"))
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"moderator"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:     "没有分配角色的权限",
+			perms:    []string{domain.PermUserStatus},
+			reqBody:  `{"uid":2,"role":"admin"}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAdmin(t, tc.mock, 1, tc.perms, http.MethodPost, "/admin/users/roles/grant", tc.reqBody)
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestAdminHandler_RevokeRole(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(rbacSvc *svcmocks.MockRBACService)
+		perms   []string
+		reqBody string
+
+		wantCode int
+		wantRes  Result
+	}{
+		{
+			name: "收回成功",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().RevokeRole(gomock.Any(), int64(2), domain.RoleAdmin).Return(nil)
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"admin"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Msg: "已收回"},
+		},
+		{
+			name:     "不能收回自己的管理员角色",
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":1,"role":"admin"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Code: 4, Msg: "不能收回自己的管理员角色"},
+		},
+		{
+			name: "收回自己的其它角色",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().RevokeRole(gomock.Any(), int64(1), domain.RoleOperator).Return(nil)
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":1,"role":"operator"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Msg: "已收回"},
+		},
+		{
+			name: "最后一个管理员",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().RevokeRole(gomock.Any(), int64(2), domain.RoleAdmin).Return(service.ErrLastAdmin)
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"admin"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Code: 4, Msg: "至少要保留一个管理员"},
+		},
+		{
+			name: "系统错误",
+			mock: func(rbacSvc *svcmocks.MockRBACService) {
+				rbacSvc.EXPECT().RevokeRole(gomock.Any(), int64(2), domain.RoleAdmin).
+					Return(errors.New("mock error"))
+			},
+			perms:    []string{domain.PermRoleManage},
+			reqBody:  `{"uid":2,"role":"admin"}`,
+			wantCode: http.StatusOK,
+			wantRes:  Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:     "没有权限",
+			reqBody:  `{"uid":2,"role":"admin"}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveAdmin(t, tc.mock, 1, tc.perms, http.MethodPost, "/admin/users/roles/revoke", tc.reqBody)
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
@@ -16,19 +16,21 @@ import (
 )
 
 type ArticleHandler struct {
-	svc     service.ArticleService
-	l       logger.Logger
-	intrSvc service.InteractiveService
-	biz     string
+	svc       service.ArticleService
+	l         logger.Logger
+	intrSvc   service.InteractiveService
+	followSvc service.FollowService
+	biz       string
 }
 
 func NewArticleHandler(svc service.ArticleService, l logger.Logger,
-	intrSvc service.InteractiveService) *ArticleHandler {
+	intrSvc service.InteractiveService, followSvc service.FollowService) *ArticleHandler {
 	return &ArticleHandler{
-		svc:     svc,
-		l:       l,
-		intrSvc: intrSvc,
-		biz:     "article",
+		svc:       svc,
+		l:         l,
+		intrSvc:   intrSvc,
+		followSvc: followSvc,
+		biz:       "article",
 	}
 }
 
@@ -52,7 +54,7 @@ func (h *ArticleHandler) Edit(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
 	}
-	claims, ok := ctx.MustGet("users").(*ijwt.UserClaims)
+	claims, ok := ctx.MustGet("users").(ijwt.UserClaims)
 	if !ok {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
@@ -70,12 +72,16 @@ func (h *ArticleHandler) Publish(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
 	}
-	claims, ok := ctx.MustGet("users").(*ijwt.UserClaims)
+	claims, ok := ctx.MustGet("users").(ijwt.UserClaims)
 	if !ok {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
 	}
 	id, err := h.svc.Publish(ctx, req.toDomain(claims.Uid))
+	if res, ok := userStatusResult(domain.User{}, err); ok {
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
@@ -89,7 +95,7 @@ func (h *ArticleHandler) Withdraw(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		return
 	}
-	claims, ok := ctx.MustGet("users").(*ijwt.UserClaims)
+	claims, ok := ctx.MustGet("users").(ijwt.UserClaims)
 	if !ok {
 		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
 		h.l.Error("未发现用户的 session 信息")
@@ -105,7 +111,7 @@ func (h *ArticleHandler) Withdraw(ctx *gin.Context) {
 }
 
 func (h *ArticleHandler) Detail(ctx *gin.Context) (Result, error) {
-	var uc ijwt.UserClaims
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -132,10 +138,11 @@ func (h *ArticleHandler) Detail(ctx *gin.Context) (Result, error) {
 }
 
 func (h *ArticleHandler) List(ctx *gin.Context) (Result, error) {
-	var (
-		req ListReq
-		uc  ijwt.UserClaims
-	)
+	var req ListReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	res, err := h.svc.List(ctx, uc.Uid, req.Offset, req.Limit)
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
@@ -168,7 +175,7 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 	}
 	var eg errgroup.Group
 	var art domain.Article
-	uc := ctx.MustGet("users").(*ijwt.UserClaims)
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	eg.Go(func() error {
 		art, err = h.svc.GetPubById(ctx, id, uc.Uid)
 		return err
@@ -184,16 +191,25 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 			h.l.Error("增加阅读计数失败")
 		}
 	}()
+	followed, err := h.followSvc.Followed(ctx, uc.Uid, art.Author.Id)
+	if err != nil {
+		// 不影响看文章
+		h.l.Error("查询关注关系失败", logger.Int64("uid", uc.Uid),
+			logger.Int64("author", art.Author.Id), logger.Error(err))
+	}
 
 	ctx.JSON(http.StatusOK, Result{
 		Data: ArticleVO{
-			Id:      art.Id,
-			Title:   art.Title,
-			Content: art.Content,
-			Status:  art.Status.ToUint8(),
-			Author:  art.Author.Name,
-			Ctime:   art.Ctime.Format(time.DateTime),
-			Utime:   art.Utime.Format(time.DateTime),
+			Id:           art.Id,
+			Title:        art.Title,
+			Content:      art.Content,
+			Status:       art.Status.ToUint8(),
+			Author:       art.Author.Name,
+			AuthorId:     art.Author.Id,
+			AuthorAvatar: art.Author.Avatar,
+			Followed:     followed,
+			Ctime:        art.Ctime.Format(time.DateTime),
+			Utime:        art.Utime.Format(time.DateTime),
 		},
 	})
 }
@@ -201,12 +217,18 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 func (h *ArticleHandler) Like(ctx *gin.Context) (Result, error) {
 	var err error
 	var req LikeReq
-	var uc ijwt.UserClaims
+	if err = ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	if req.Like {
 		err = h.intrSvc.Like(ctx, h.biz, req.Id, uc.Uid)
 	} else {
 		err = h.intrSvc.CancelLike(ctx, h.biz, req.Id, uc.Uid)
 	}
+	if res, ok := userStatusResult(domain.User{}, err); ok {
+		return res, nil
+	}
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
@@ -216,12 +238,18 @@ func (h *ArticleHandler) Like(ctx *gin.Context) (Result, error) {
 func (h *ArticleHandler) Collect(ctx *gin.Context) (Result, error) {
 	var err error
 	var req CollectReq
-	var uc ijwt.UserClaims
+	if err = ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	if req.Collect {
 		err = h.intrSvc.Collect(ctx, h.biz, req.Id, uc.Uid)
 	} else {
 		err = h.intrSvc.CancelCollect(ctx, h.biz, req.Id, uc.Uid)
 	}
+	if res, ok := userStatusResult(domain.User{}, err); ok {
+		return res, nil
+	}
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
@@ -9,12 +9,14 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/service"
-	artsvcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
 	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
 	"go.uber.org/mock/gomock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestArticleHandler_Publish(t *testing.T) {
@@ -29,7 +31,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 		{
 			name: "新建并发表",
 			mock: func(ctrl *gomock.Controller) service.ArticleService {
-				svc := artsvcmocks.NewMockArticleService(ctrl)
+				svc := svcmocks.NewMockArticleService(ctrl)
 				svc.EXPECT().Publish(gomock.Any(), domain.Article{
 					Title:   "我的标题",
 					Content: "我的内容",
@@ -52,7 +54,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 		{
 			name: "发表失败",
 			mock: func(ctrl *gomock.Controller) service.ArticleService {
-				svc := artsvcmocks.NewMockArticleService(ctrl)
+				svc := svcmocks.NewMockArticleService(ctrl)
 				svc.EXPECT().Publish(gomock.Any(), domain.Article{
 					Title:   "我的标题",
 					Content: "我的内容",
@@ -79,11 +81,11 @@ func TestArticleHandler_Publish(t *testing.T) {
 			defer ctrl.Finish()
 			server := gin.Default()
 			server.Use(func(ctx *gin.Context) {
-				ctx.Set("users", &ijwt.UserClaims{
+				ctx.Set("users", ijwt.UserClaims{
 					Uid: 123,
 				})
 			})
-			h := NewArticleHandler(tc.mock(ctrl))
+			h := NewArticleHandler(tc.mock(ctrl), logger.NewNopLogger(), nil, nil)
 			h.RegisterRoutes(server)
 
 			req, err := http.NewRequest(http.MethodPost, "/articles/publish",
@@ -104,3 +106,338 @@ func TestArticleHandler_Publish(t *testing.T) {
 		})
 	}
 }
+
+type articleHandlerMocks struct {
+	svc     *svcmocks.MockArticleService
+	intrSvc *svcmocks.MockInteractiveService
+}
+
+// serveArticle 以 uid 为 123 的用户访问文章相关的接口
+func serveArticle(t *testing.T, mock func(m articleHandlerMocks),
+	method, path, body string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	m := articleHandlerMocks{
+		svc:     svcmocks.NewMockArticleService(ctrl),
+		intrSvc: svcmocks.NewMockInteractiveService(ctrl),
+	}
+	if mock != nil {
+		mock(m)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("users", ijwt.UserClaims{Uid: 123})
+	})
+	h := NewArticleHandler(m.svc, logger.NewNopLogger(), m.intrSvc, nil)
+	h.RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestArticleHandler_Edit(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m articleHandlerMocks)
+		reqBody string
+
+		// Bind 失败的时候 gin 直接返回 400
+		wantStatus int
+		wantRes    Result
+	}{
+		{
+			name: "保存成功",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().Save(gomock.Any(), domain.Article{
+					Id:      2,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author:  domain.Author{Id: 123},
+				}).Return(int64(2), nil)
+			},
+			reqBody: `{"id":2,"title":"我的标题","content":"我的内容"}`,
+			wantRes: Result{Data: float64(2)},
+		},
+		{
+			name:       "参数错误",
+			reqBody:    `{"id":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveArticle(t, tc.mock, http.MethodPost, "/articles/edit", tc.reqBody)
+			if tc.wantStatus != 0 {
+				assert.Equal(t, tc.wantStatus, resp.Code)
+				return
+			}
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestArticleHandler_Detail(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	testCases := []struct {
+		name string
+		mock func(m articleHandlerMocks)
+		path string
+
+		// 返回了 error 的话 WrapBody 什么都不写
+		wantEmpty bool
+		wantCode  int
+		wantMsg   string
+		wantData  ArticleVO
+	}{
+		{
+			name: "作者查看自己的文章",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().GetById(gomock.Any(), int64(2)).Return(domain.Article{
+					Id:      2,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatusPublished,
+					Ctime:   now,
+					Utime:   now,
+				}, nil)
+			},
+			path: "/articles/detail/2",
+			wantData: ArticleVO{
+				Id:      2,
+				Title:   "我的标题",
+				Content: "我的内容",
+				Status:  domain.ArticleStatusPublished.ToUint8(),
+				Ctime:   now.Format(time.DateTime),
+				Utime:   now.Format(time.DateTime),
+			},
+		},
+		{
+			name: "不是作者",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().GetById(gomock.Any(), int64(2)).Return(domain.Article{
+					Id:     2,
+					Author: domain.Author{Id: 456},
+				}, nil)
+			},
+			path:      "/articles/detail/2",
+			wantEmpty: true,
+		},
+		{
+			name:     "ID 不对",
+			path:     "/articles/detail/abc",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+		{
+			name: "查询失败",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().GetById(gomock.Any(), int64(2)).Return(domain.Article{}, errors.New("mock error"))
+			},
+			path:     "/articles/detail/2",
+			wantCode: 5,
+			wantMsg:  "系统错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveArticle(t, tc.mock, http.MethodGet, tc.path, "")
+			require.Equal(t, http.StatusOK, resp.Code)
+			if tc.wantEmpty {
+				assert.Equal(t, 0, resp.Body.Len())
+				return
+			}
+			var res struct {
+				Code int
+				Msg  string
+				Data ArticleVO
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Equal(t, tc.wantCode, res.Code)
+			assert.Equal(t, tc.wantMsg, res.Msg)
+			assert.Equal(t, tc.wantData, res.Data)
+		})
+	}
+}
+
+func TestArticleHandler_List(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m articleHandlerMocks)
+		reqBody string
+
+		wantStatus int
+		wantCode   int
+		wantMsg    string
+		wantIds    []int64
+	}{
+		{
+			name: "按当前用户和分页参数查询",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().List(gomock.Any(), int64(123), 10, 5).Return([]domain.Article{
+					{Id: 3, Title: "标题 3"},
+					{Id: 2, Title: "标题 2"},
+				}, nil)
+			},
+			reqBody: `{"offset":10,"limit":5}`,
+			wantIds: []int64{3, 2},
+		},
+		{
+			name:       "参数错误",
+			reqBody:    `{"offset":"abc"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "查询失败",
+			mock: func(m articleHandlerMocks) {
+				m.svc.EXPECT().List(gomock.Any(), int64(123), 0, 10).Return(nil, errors.New("mock error"))
+			},
+			reqBody:  `{"offset":0,"limit":10}`,
+			wantCode: 5,
+			wantMsg:  "系统错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveArticle(t, tc.mock, http.MethodPost, "/articles/list", tc.reqBody)
+			if tc.wantStatus != 0 {
+				assert.Equal(t, tc.wantStatus, resp.Code)
+				return
+			}
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res struct {
+				Code int
+				Msg  string
+				Data []ArticleVO
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Equal(t, tc.wantCode, res.Code)
+			assert.Equal(t, tc.wantMsg, res.Msg)
+			ids := make([]int64, 0, len(res.Data))
+			for _, vo := range res.Data {
+				ids = append(ids, vo.Id)
+			}
+			if tc.wantIds == nil {
+				tc.wantIds = []int64{}
+			}
+			assert.Equal(t, tc.wantIds, ids)
+		})
+	}
+}
+
+func TestArticleHandler_Like(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m articleHandlerMocks)
+		reqBody string
+
+		// Bind 失败的时候 gin 直接返回 400
+		wantStatus int
+		wantRes    Result
+	}{
+		{
+			name: "点赞",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().Like(gomock.Any(), "article", int64(2), int64(123)).Return(nil)
+			},
+			reqBody: `{"id":2,"like":true}`,
+			wantRes: Result{Msg: "ok"},
+		},
+		{
+			name: "取消点赞",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().CancelLike(gomock.Any(), "article", int64(2), int64(123)).Return(nil)
+			},
+			reqBody: `{"id":2,"like":false}`,
+			wantRes: Result{Msg: "ok"},
+		},
+		{
+			name: "被禁言了",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().Like(gomock.Any(), "article", int64(2), int64(123)).Return(service.ErrUserMuted)
+			},
+			reqBody: `{"id":2,"like":true}`,
+			wantRes: Result{Code: 4, Msg: "你已被禁言，暂时不能发表文章、点赞和收藏"},
+		},
+		{
+			name: "系统错误",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().Like(gomock.Any(), "article", int64(2), int64(123)).Return(errors.New("mock error"))
+			},
+			reqBody: `{"id":2,"like":true}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:       "参数错误",
+			reqBody:    `{"id":"2"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveArticle(t, tc.mock, http.MethodPost, "/pub/like", tc.reqBody)
+			if tc.wantStatus != 0 {
+				assert.Equal(t, tc.wantStatus, resp.Code)
+				return
+			}
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestArticleHandler_Collect(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m articleHandlerMocks)
+		reqBody string
+
+		// Bind 失败的时候 gin 直接返回 400
+		wantStatus int
+		wantRes    Result
+	}{
+		{
+			name: "收藏",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().Collect(gomock.Any(), "article", int64(2), int64(123)).Return(nil)
+			},
+			reqBody: `{"id":2,"like":true}`,
+			wantRes: Result{Msg: "ok"},
+		},
+		{
+			name: "取消收藏",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().CancelCollect(gomock.Any(), "article", int64(2), int64(123)).Return(nil)
+			},
+			reqBody: `{"id":2,"like":false}`,
+			wantRes: Result{Msg: "ok"},
+		},
+		{
+			name: "系统错误",
+			mock: func(m articleHandlerMocks) {
+				m.intrSvc.EXPECT().Collect(gomock.Any(), "article", int64(2), int64(123)).
+					Return(errors.New("mock error"))
+			},
+			reqBody: `{"id":2,"like":true}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:       "参数错误",
+			reqBody:    `{"id":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveArticle(t, tc.mock, http.MethodPost, "/pub/collect", tc.reqBody)
+			if tc.wantStatus != 0 {
+				assert.Equal(t, tc.wantStatus, resp.Code)
+				return
+			}
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
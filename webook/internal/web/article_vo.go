@@ -44,10 +44,13 @@ type ArticleVO struct {
 	Content  string `json:"content"`
 	Abstract string `json:"abstract"`
 	// Author 要从用户来
-	Author string `json:"author"`
-	Status uint8  `json:"status"`
-	Ctime  string `json:"ctime"`
-	Utime  string `json:"utime"`
+	Author   string `json:"author"`
+	AuthorId int64  `json:"author_id"`
+	// 作者头像小图
+	AuthorAvatar string `json:"author_avatar"`
+	Status       uint8  `json:"status"`
+	Ctime        string `json:"ctime"`
+	Utime        string `json:"utime"`
 
 	// 点赞之类的信息
 	ReadCnt    int64 `json:"read_cnt"`
@@ -57,4 +60,6 @@ type ArticleVO struct {
 	// 个人是否点赞信息
 	Collected bool `json:"collected"`
 	Liked     bool `json:"liked"`
+	// 当前用户是否关注了作者
+	Followed bool `json:"followed"`
 }
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const (
+	authorPageDefaultLimit = 10
+	authorPageMaxLimit     = 100
+)
+
+// AuthorHandler 作者公开主页，不登录也可以看
+type AuthorHandler struct {
+	svc       service.AuthorService
+	followSvc service.FollowService
+	l         logger.Logger
+}
+
+func NewAuthorHandler(svc service.AuthorService, followSvc service.FollowService,
+	l logger.Logger) *AuthorHandler {
+	return &AuthorHandler{
+		svc:       svc,
+		followSvc: followSvc,
+		l:         l,
+	}
+}
+
+func (h *AuthorHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/authors/:id", ginx.WrapBody(h.Profile))
+}
+
+func (h *AuthorHandler) Profile(ctx *gin.Context) (Result, error) {
+	uid, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || uid <= 0 {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	offset, limit, ok := pageQuery(ctx, authorPageDefaultLimit, authorPageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+
+	p, err := h.svc.Profile(ctx, uid)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return Result{Code: 4, Msg: "作者不存在"}, nil
+	}
+	if err != nil {
+		h.l.Error("查询作者主页失败", logger.Int64("uid", uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	arts, err := h.svc.ListPublished(ctx, uid, offset, limit)
+	if err != nil {
+		h.l.Error("查询作者文章失败", logger.Int64("uid", uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	var followed bool
+	// 没登录就没有 claims
+	if uc, ok := ctx.Get("users"); ok {
+		followed, err = h.followSvc.Followed(ctx, uc.(ijwt.UserClaims).Uid, uid)
+		if err != nil {
+			// 不影响看主页
+			h.l.Error("查询关注关系失败", logger.Int64("uid", uid), logger.Error(err))
+		}
+	}
+	return Result{
+		Data: AuthorProfileVO{
+			Id:         p.Id,
+			Nickname:   p.Nickname,
+			AboutMe:    p.AboutMe,
+			Avatar:     p.Avatar,
+			Ctime:      p.Ctime.Format(time.DateOnly),
+			ArticleCnt: p.ArticleCnt,
+			ReadCnt:    p.ReadCnt,
+			LikeCnt:    p.LikeCnt,
+			CollectCnt: p.CollectCnt,
+			Followers:  p.Followers,
+			Followees:  p.Followees,
+			Followed:   followed,
+			Articles: slice.Map[domain.Article, ArticleVO](arts, func(idx int, src domain.Article) ArticleVO {
+				return ArticleVO{
+					Id:       src.Id,
+					Title:    src.Title,
+					Abstract: src.Abstract(),
+					Author:   p.Nickname,
+					AuthorId: p.Id,
+					Status:   src.Status.ToUint8(),
+					Ctime:    src.Ctime.Format(time.DateTime),
+					Utime:    src.Utime.Format(time.DateTime),
+				}
+			}),
+		},
+	}, nil
+}
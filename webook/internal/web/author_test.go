@@ -0,0 +1,161 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthorHandler_Profile(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	profile := domain.AuthorProfile{
+		Id:         1,
+		Nickname:   "大明",
+		Ctime:      now,
+		ArticleCnt: 1,
+		ReadCnt:    100,
+		Followers:  7,
+	}
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockAuthorService, followSvc *svcmocks.MockFollowService)
+		// 0 代表没登录
+		uid  int64
+		path string
+
+		wantCode int
+		wantMsg  string
+		wantData AuthorProfileVO
+	}{
+		{
+			name: "没登录",
+			mock: func(svc *svcmocks.MockAuthorService, followSvc *svcmocks.MockFollowService) {
+				svc.EXPECT().Profile(gomock.Any(), int64(1)).Return(profile, nil)
+				svc.EXPECT().ListPublished(gomock.Any(), int64(1), 0, authorPageDefaultLimit).
+					Return([]domain.Article{{Id: 2, Title: "标题", Ctime: now, Utime: now}}, nil)
+			},
+			path: "/authors/1",
+			wantData: AuthorProfileVO{
+				Id:         1,
+				Nickname:   "大明",
+				Ctime:      now.Format(time.DateOnly),
+				ArticleCnt: 1,
+				ReadCnt:    100,
+				Followers:  7,
+				Articles: []ArticleVO{{
+					Id:       2,
+					Title:    "标题",
+					Author:   "大明",
+					AuthorId: 1,
+					Ctime:    now.Format(time.DateTime),
+					Utime:    now.Format(time.DateTime),
+				}},
+			},
+		},
+		{
+			name: "登录了，已关注，分页参数超过上限",
+			mock: func(svc *svcmocks.MockAuthorService, followSvc *svcmocks.MockFollowService) {
+				svc.EXPECT().Profile(gomock.Any(), int64(1)).Return(profile, nil)
+				svc.EXPECT().ListPublished(gomock.Any(), int64(1), 10, authorPageMaxLimit).Return(nil, nil)
+				followSvc.EXPECT().Followed(gomock.Any(), int64(123), int64(1)).Return(true, nil)
+			},
+			uid:  123,
+			path: "/authors/1?offset=10&limit=1000",
+			wantData: AuthorProfileVO{
+				Id:         1,
+				Nickname:   "大明",
+				Ctime:      now.Format(time.DateOnly),
+				ArticleCnt: 1,
+				ReadCnt:    100,
+				Followers:  7,
+				Followed:   true,
+				Articles:   []ArticleVO{},
+			},
+		},
+		{
+			name: "查询关注关系失败不影响",
+			mock: func(svc *svcmocks.MockAuthorService, followSvc *svcmocks.MockFollowService) {
+				svc.EXPECT().Profile(gomock.Any(), int64(1)).Return(profile, nil)
+				svc.EXPECT().ListPublished(gomock.Any(), int64(1), 0, authorPageDefaultLimit).Return(nil, nil)
+				followSvc.EXPECT().Followed(gomock.Any(), int64(123), int64(1)).
+					Return(false, errors.New("mock error"))
+			},
+			uid:  123,
+			path: "/authors/1",
+			wantData: AuthorProfileVO{
+				Id:         1,
+				Nickname:   "大明",
+				Ctime:      now.Format(time.DateOnly),
+				ArticleCnt: 1,
+				ReadCnt:    100,
+				Followers:  7,
+				Articles:   []ArticleVO{},
+			},
+		},
+		{
+			name: "作者不存在",
+			mock: func(svc *svcmocks.MockAuthorService, followSvc *svcmocks.MockFollowService) {
+				svc.EXPECT().Profile(gomock.Any(), int64(1)).Return(domain.AuthorProfile{}, service.ErrUserNotFound)
+			},
+			path:     "/authors/1",
+			wantCode: 4,
+			wantMsg:  "作者不存在",
+		},
+		{
+			name:     "ID 不对",
+			path:     "/authors/0",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+		{
+			name:     "分页参数不对",
+			path:     "/authors/1?limit=-1",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := svcmocks.NewMockAuthorService(ctrl)
+			followSvc := svcmocks.NewMockFollowService(ctrl)
+			if tc.mock != nil {
+				tc.mock(svc, followSvc)
+			}
+			gin.SetMode(gin.ReleaseMode)
+			server := gin.New()
+			if tc.uid > 0 {
+				server.Use(func(ctx *gin.Context) {
+					ctx.Set("users", ijwt.UserClaims{Uid: tc.uid})
+				})
+			}
+			NewAuthorHandler(svc, followSvc, logger.NewNopLogger()).RegisterRoutes(server)
+
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res struct {
+				Code int
+				Msg  string
+				Data AuthorProfileVO
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Equal(t, tc.wantCode, res.Code)
+			assert.Equal(t, tc.wantMsg, res.Msg)
+			assert.Equal(t, tc.wantData, res.Data)
+		})
+	}
+}
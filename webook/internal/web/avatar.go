@@ -0,0 +1,70 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"io"
+	"net/http"
+)
+
+type AvatarHandler struct {
+	svc service.AvatarService
+	l   logger.Logger
+}
+
+func NewAvatarHandler(svc service.AvatarService, l logger.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *AvatarHandler) RegisterRoutes(server *gin.Engine) {
+	server.POST("/users/avatar", ginx.WrapBody(h.Upload))
+}
+
+// Upload multipart 表单，文件字段名是 avatar
+func (h *AvatarHandler) Upload(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	// 留一点给表单的其它部分，整个请求体太大直接拒绝，不要先读到内存或者临时文件里面
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, service.MaxAvatarSize+1<<20)
+	fh, err := ctx.FormFile("avatar")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return Result{Code: 4, Msg: "头像不能超过 5M"}, nil
+		}
+		return Result{Code: 4, Msg: "请上传头像"}, nil
+	}
+	if fh.Size > service.MaxAvatarSize {
+		return Result{Code: 4, Msg: "头像不能超过 5M"}, nil
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxAvatarSize+1))
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	u, err := h.svc.Upload(ctx, uc.Uid, data)
+	switch {
+	case err == nil:
+		return Result{
+			Msg:  "上传成功",
+			Data: AvatarVO{Avatar: u.Avatar, AvatarThumb: u.AvatarThumb},
+		}, nil
+	case errors.Is(err, service.ErrAvatarTooLarge):
+		return Result{Code: 4, Msg: "头像太大"}, nil
+	case errors.Is(err, service.ErrInvalidAvatarType):
+		return Result{Code: 4, Msg: "头像只支持 JPEG、PNG、GIF"}, nil
+	default:
+		h.l.Error("上传头像失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
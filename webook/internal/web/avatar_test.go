@@ -0,0 +1,130 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAvatarHandler_Upload(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockAvatarService)
+		// 表单的文件字段名和内容
+		field string
+		data  []byte
+
+		wantRes Result
+	}{
+		{
+			name: "上传成功",
+			mock: func(svc *svcmocks.MockAvatarService) {
+				svc.EXPECT().Upload(gomock.Any(), int64(1), []byte("image")).Return(domain.User{
+					Id:          1,
+					Avatar:      "/static/avatars/1_256.jpg?v=1",
+					AvatarThumb: "/static/avatars/1_64.jpg?v=1",
+				}, nil)
+			},
+			field: "avatar",
+			data:  []byte("image"),
+			wantRes: Result{
+				Msg: "上传成功",
+				Data: map[string]any{
+					"avatar":       "/static/avatars/1_256.jpg?v=1",
+					"avatar_thumb": "/static/avatars/1_64.jpg?v=1",
+				},
+			},
+		},
+		{
+			name:    "没有上传文件",
+			field:   "file",
+			data:    []byte("image"),
+			wantRes: Result{Code: 4, Msg: "请上传头像"},
+		},
+		{
+			name:    "文件超过 5M",
+			field:   "avatar",
+			data:    make([]byte, service.MaxAvatarSize+1),
+			wantRes: Result{Code: 4, Msg: "头像不能超过 5M"},
+		},
+		{
+			name:    "请求体太大",
+			field:   "avatar",
+			data:    make([]byte, service.MaxAvatarSize+2<<20),
+			wantRes: Result{Code: 4, Msg: "头像不能超过 5M"},
+		},
+		{
+			name: "图片尺寸太大",
+			mock: func(svc *svcmocks.MockAvatarService) {
+				svc.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any()).
+					Return(domain.User{}, service.ErrAvatarTooLarge)
+			},
+			field:   "avatar",
+			data:    []byte("image"),
+			wantRes: Result{Code: 4, Msg: "头像太大"},
+		},
+		{
+			name: "格式不对",
+			mock: func(svc *svcmocks.MockAvatarService) {
+				svc.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any()).
+					Return(domain.User{}, service.ErrInvalidAvatarType)
+			},
+			field:   "avatar",
+			data:    []byte("text"),
+			wantRes: Result{Code: 4, Msg: "头像只支持 JPEG、PNG、GIF"},
+		},
+		{
+			name: "存储出错",
+			mock: func(svc *svcmocks.MockAvatarService) {
+				svc.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any()).
+					Return(domain.User{}, errors.New("mock error"))
+			},
+			field:   "avatar",
+			data:    []byte("image"),
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := svcmocks.NewMockAvatarService(ctrl)
+			if tc.mock != nil {
+				tc.mock(svc)
+			}
+			gin.SetMode(gin.ReleaseMode)
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("users", ijwt.UserClaims{Uid: 1, Ssid: "ssid"})
+			})
+			NewAvatarHandler(svc, logger.NewNopLogger()).RegisterRoutes(server)
+
+			var body bytes.Buffer
+			w := multipart.NewWriter(&body)
+			fw, err := w.CreateFormFile(tc.field, "avatar.png")
+			require.NoError(t, err)
+			_, err = fw.Write(tc.data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			req, err := http.NewRequest(http.MethodPost, "/users/avatar", &body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
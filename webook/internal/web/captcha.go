@@ -0,0 +1,40 @@
+package web
+
+import (
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+)
+
+// CaptchaHandler 获取图形验证码，不需要登录
+type CaptchaHandler struct {
+	svc service.CaptchaService
+	l   logger.Logger
+}
+
+func NewCaptchaHandler(svc service.CaptchaService, l logger.Logger) *CaptchaHandler {
+	return &CaptchaHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *CaptchaHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/captcha", ginx.WrapBody(h.Generate))
+}
+
+func (h *CaptchaHandler) Generate(ctx *gin.Context) (Result, error) {
+	id, img, err := h.svc.Generate(ctx)
+	if err != nil {
+		h.l.Error("生成图形验证码失败", logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{
+		Data: CaptchaVO{
+			Id:    id,
+			Image: "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+		},
+	}, nil
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const (
+	followPageDefaultLimit = 20
+	followPageMaxLimit     = 100
+)
+
+type FollowHandler struct {
+	svc service.FollowService
+	l   logger.Logger
+}
+
+func NewFollowHandler(svc service.FollowService, l logger.Logger) *FollowHandler {
+	return &FollowHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *FollowHandler) RegisterRoutes(server *gin.Engine) {
+	server.POST("/follow", ginx.WrapBody(h.Follow))
+	server.POST("/unfollow", ginx.WrapBody(h.Unfollow))
+	fg := server.Group("/follow")
+	fg.GET("/followees", ginx.WrapBody(h.Followees))
+	fg.GET("/followers", ginx.WrapBody(h.Followers))
+}
+
+func (h *FollowHandler) Follow(ctx *gin.Context) (Result, error) {
+	var req FollowReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	err := h.svc.Follow(ctx, uc.Uid, req.Followee)
+	switch {
+	case err == nil:
+		return Result{Msg: "ok"}, nil
+	case errors.Is(err, service.ErrFollowSelf):
+		return Result{Code: 4, Msg: "不能关注自己"}, nil
+	case errors.Is(err, service.ErrUserNotFound):
+		return Result{Code: 4, Msg: "用户不存在"}, nil
+	default:
+		h.l.Error("关注失败", logger.Int64("follower", uc.Uid),
+			logger.Int64("followee", req.Followee), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+func (h *FollowHandler) Unfollow(ctx *gin.Context) (Result, error) {
+	var req FollowReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	err := h.svc.Unfollow(ctx, uc.Uid, req.Followee)
+	if err != nil {
+		h.l.Error("取消关注失败", logger.Int64("follower", uc.Uid),
+			logger.Int64("followee", req.Followee), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "ok"}, nil
+}
+
+// Followees 关注列表，不传 uid 就是查自己的
+func (h *FollowHandler) Followees(ctx *gin.Context) (Result, error) {
+	return h.list(ctx, h.svc.Followees, func(r domain.FollowRelation) int64 {
+		return r.Followee
+	})
+}
+
+// Followers 粉丝列表，不传 uid 就是查自己的
+func (h *FollowHandler) Followers(ctx *gin.Context) (Result, error) {
+	return h.list(ctx, h.svc.Followers, func(r domain.FollowRelation) int64 {
+		return r.Follower
+	})
+}
+
+func (h *FollowHandler) list(ctx *gin.Context,
+	find func(ctx context.Context, uid, cursor int64, limit int) ([]domain.FollowRelation, error),
+	other func(r domain.FollowRelation) int64) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	uid := uc.Uid
+	if s := ctx.Query("uid"); s != "" {
+		var err error
+		uid, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || uid <= 0 {
+			return Result{Code: 4, Msg: "参数错误"}, nil
+		}
+	}
+	cursor, err := strconv.ParseInt(ctx.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil || cursor < 0 {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(followPageDefaultLimit)))
+	if err != nil || limit <= 0 {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if limit > followPageMaxLimit {
+		limit = followPageMaxLimit
+	}
+	rs, err := find(ctx, uid, cursor, limit)
+	if err != nil {
+		h.l.Error("查询关注列表失败", logger.Int64("uid", uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	var next int64
+	// 不满一页说明没有下一页了
+	if len(rs) == limit {
+		next = rs[len(rs)-1].Id
+	}
+	return Result{
+		Data: FollowListVO{
+			List: slice.Map[domain.FollowRelation, FollowVO](rs, func(idx int, src domain.FollowRelation) FollowVO {
+				return FollowVO{
+					Uid:   other(src),
+					Ctime: src.Ctime.Format(time.DateTime),
+				}
+			}),
+			NextCursor: next,
+		},
+	}, nil
+}
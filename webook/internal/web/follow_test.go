@@ -0,0 +1,214 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveFollow(t *testing.T, mock func(svc *svcmocks.MockFollowService),
+	method, path, body string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	svc := svcmocks.NewMockFollowService(ctrl)
+	if mock != nil {
+		mock(svc)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("users", ijwt.UserClaims{Uid: 1, Ssid: "ssid"})
+	})
+	NewFollowHandler(svc, logger.NewNopLogger()).RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestFollowHandler_Follow(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(svc *svcmocks.MockFollowService)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "关注成功",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(nil)
+			},
+			reqBody: `{"followee":2}`,
+			wantRes: Result{Msg: "ok"},
+		},
+		{
+			name: "关注自己",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Follow(gomock.Any(), int64(1), int64(1)).Return(service.ErrFollowSelf)
+			},
+			reqBody: `{"followee":1}`,
+			wantRes: Result{Code: 4, Msg: "不能关注自己"},
+		},
+		{
+			name: "用户不存在",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(service.ErrUserNotFound)
+			},
+			reqBody: `{"followee":2}`,
+			wantRes: Result{Code: 4, Msg: "用户不存在"},
+		},
+		{
+			name: "系统错误",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock error"))
+			},
+			reqBody: `{"followee":2}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveFollow(t, tc.mock, http.MethodPost, "/follow", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestFollowHandler_List(t *testing.T) {
+	ctime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	// 按 id 倒序的 n 条关注记录，id 从 start 开始
+	relations := func(start int64, n int) []domain.FollowRelation {
+		res := make([]domain.FollowRelation, 0, n)
+		for i := 0; i < n; i++ {
+			res = append(res, domain.FollowRelation{
+				Id: start - int64(i), Follower: 1, Followee: 100 + int64(i), Ctime: ctime,
+			})
+		}
+		return res
+	}
+	testCases := []struct {
+		name string
+		mock func(svc *svcmocks.MockFollowService)
+		path string
+
+		wantCode int
+		wantMsg  string
+		// 返回的用户和下一页的游标
+		wantUids   []int64
+		wantCursor int64
+	}{
+		{
+			name: "满一页，返回下一页的游标",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followees(gomock.Any(), int64(1), int64(0), 2).Return(relations(9, 2), nil)
+			},
+			path:       "/follow/followees?limit=2",
+			wantUids:   []int64{100, 101},
+			wantCursor: 8,
+		},
+		{
+			name: "带着游标翻页，不满一页说明到头了",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followees(gomock.Any(), int64(1), int64(8), 2).Return(relations(5, 1), nil)
+			},
+			path:     "/follow/followees?cursor=8&limit=2",
+			wantUids: []int64{100},
+		},
+		{
+			name: "默认每页条数",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followees(gomock.Any(), int64(1), int64(0), followPageDefaultLimit).Return(nil, nil)
+			},
+			path:     "/follow/followees",
+			wantUids: []int64{},
+		},
+		{
+			name: "每页条数超过上限",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followees(gomock.Any(), int64(1), int64(0), followPageMaxLimit).Return(nil, nil)
+			},
+			path:     "/follow/followees?limit=1000",
+			wantUids: []int64{},
+		},
+		{
+			name: "查别人的粉丝，返回的是关注者",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followers(gomock.Any(), int64(3), int64(0), 2).Return([]domain.FollowRelation{
+					{Id: 9, Follower: 5, Followee: 3, Ctime: ctime},
+					{Id: 6, Follower: 4, Followee: 3, Ctime: ctime},
+				}, nil)
+			},
+			path:       "/follow/followers?uid=3&limit=2",
+			wantUids:   []int64{5, 4},
+			wantCursor: 6,
+		},
+		{
+			name:     "游标不对",
+			path:     "/follow/followees?cursor=-1",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+		{
+			name:     "每页条数不对",
+			path:     "/follow/followees?limit=0",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+		{
+			name:     "uid 不对",
+			path:     "/follow/followers?uid=abc",
+			wantCode: 4,
+			wantMsg:  "参数错误",
+		},
+		{
+			name: "查询失败",
+			mock: func(svc *svcmocks.MockFollowService) {
+				svc.EXPECT().Followees(gomock.Any(), int64(1), int64(0), followPageDefaultLimit).
+					Return(nil, errors.New("mock error"))
+			},
+			path:     "/follow/followees",
+			wantCode: 5,
+			wantMsg:  "系统错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveFollow(t, tc.mock, http.MethodGet, tc.path, "")
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res struct {
+				Code int
+				Msg  string
+				Data FollowListVO
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Equal(t, tc.wantCode, res.Code)
+			assert.Equal(t, tc.wantMsg, res.Msg)
+			if tc.wantCode != 0 {
+				return
+			}
+			uids := make([]int64, 0, len(res.Data.List))
+			for _, vo := range res.Data.List {
+				uids = append(uids, vo.Uid)
+				assert.Equal(t, ctime.Format(time.DateTime), vo.Ctime)
+			}
+			assert.Equal(t, tc.wantUids, uids)
+			assert.Equal(t, tc.wantCursor, res.Data.NextCursor)
+		})
+	}
+}
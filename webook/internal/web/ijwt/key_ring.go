@@ -0,0 +1,219 @@
+package ijwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKeyNotFound = errors.New("找不到对应的签名密钥")
+	ErrNoActiveKey = errors.New("没有可用的签名密钥")
+	ErrEmptyKid    = errors.New("kid 不能为空")
+)
+
+// KeyConfig 一把 ES512 私钥，Kid 会写进 token 的 header 里面
+type KeyConfig struct {
+	Kid  string `yaml:"kid"`
+	Path string `yaml:"path"`
+}
+
+type signingKey struct {
+	kid string
+	key *ecdsa.PrivateKey
+	// 被轮换下来的时间，零值代表还没有被轮换下来
+	retiredAt time.Time
+}
+
+// KeyRing 管理所有的签名密钥。
+// 同一时刻只有一把密钥用来签名，旧的密钥继续用来校验，
+// 直到用它签出来的 token（最长的是 refresh_token）全部过期，这样轮换密钥就不会把所有人踢下线
+//
+// 注意：轮换只发生在当前实例的内存里面，多实例部署的时候要么每个实例都调一次，
+// 要么改配置里面的 active 然后重启
+type KeyRing struct {
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active string
+	// 旧密钥要保留多久
+	retention time.Duration
+}
+
+func NewKeyRing(retention time.Duration) *KeyRing {
+	return &KeyRing{
+		keys:      make(map[string]*signingKey),
+		retention: retention,
+	}
+}
+
+// LoadKeyRing 从 PEM 文件里面加载密钥，active 就是用来签名的那一把
+func LoadKeyRing(cfgs []KeyConfig, active string, retention time.Duration) (*KeyRing, error) {
+	ring := NewKeyRing(retention)
+	for _, cfg := range cfgs {
+		key, err := readPrivateKey(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("加载密钥 %s 失败: %w", cfg.Kid, err)
+		}
+		ring.Add(cfg.Kid, key)
+	}
+	if active == "" && len(cfgs) > 0 {
+		active = cfgs[len(cfgs)-1].Kid
+	}
+	if err := ring.Rotate(active); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+func readPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM 格式解析失败")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析 ECDSA 私钥失败: %w", err)
+	}
+	if key.Curve != elliptic.P521() {
+		return nil, errors.New("ES512 要求使用 P-521 曲线")
+	}
+	return key, nil
+}
+
+// Add 加入一把密钥，只用来校验，要签名的话还要 Rotate 到它
+func (r *KeyRing) Add(kid string, key *ecdsa.PrivateKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[kid] = &signingKey{kid: kid, key: key}
+}
+
+// Rotate 切换签名密钥。kid 必须是从配置里面加载进来的密钥，
+// 不能现场生成，不然重启就丢了，别的实例也不认识它签出来的 token
+func (r *KeyRing) Rotate(kid string) error {
+	if kid == "" {
+		return ErrEmptyKid
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: kid=%s", ErrKeyNotFound, kid)
+	}
+	if kid == r.active {
+		return nil
+	}
+	now := time.Now()
+	if old, ok := r.keys[r.active]; ok {
+		old.retiredAt = now
+	}
+	next.retiredAt = time.Time{}
+	r.active = kid
+	r.purge(now)
+	return nil
+}
+
+// Signer 返回当前用来签名的密钥
+func (r *KeyRing) Signer() (string, *ecdsa.PrivateKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[r.active]
+	if !ok {
+		return "", nil, ErrNoActiveKey
+	}
+	return k.kid, k.key, nil
+}
+
+// Verifier 按照 kid 找校验用的公钥。
+// 老版本签出来的 token 没有 kid，就用当前的签名密钥来校验
+func (r *KeyRing) Verifier(kid string) (*ecdsa.PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if kid == "" {
+		kid = r.active
+	}
+	k, ok := r.keys[kid]
+	if !ok || r.expired(k, time.Now()) {
+		return nil, fmt.Errorf("%w: kid=%s", ErrKeyNotFound, kid)
+	}
+	return &k.key.PublicKey, nil
+}
+
+// JWKS 所有还能用来校验的公钥，按照 RFC 7517 的格式
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	res := JWKS{Keys: make([]JWK, 0, len(r.keys))}
+	for _, k := range r.keys {
+		if r.expired(k, now) {
+			continue
+		}
+		jwk, err := toJWK(k.kid, &k.key.PublicKey)
+		if err != nil {
+			continue
+		}
+		res.Keys = append(res.Keys, jwk)
+	}
+	sort.Slice(res.Keys, func(i, j int) bool {
+		return res.Keys[i].Kid < res.Keys[j].Kid
+	})
+	return res
+}
+
+func (r *KeyRing) expired(k *signingKey, now time.Time) bool {
+	return !k.retiredAt.IsZero() && now.Sub(k.retiredAt) > r.retention
+}
+
+// purge 把已经过了保留期的旧密钥删掉，调用者要持有写锁
+func (r *KeyRing) purge(now time.Time) {
+	for kid, k := range r.keys {
+		if r.expired(k, now) {
+			delete(r.keys, kid)
+		}
+	}
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+func toJWK(kid string, pub *ecdsa.PublicKey) (JWK, error) {
+	ecdhKey, err := pub.ECDH()
+	if err != nil {
+		return JWK{}, err
+	}
+	// 非压缩格式：0x04 || X || Y
+	raw := ecdhKey.Bytes()[1:]
+	size := len(raw) / 2
+	return JWK{
+		Kty: "EC",
+		Crv: "P-521",
+		X:   base64.RawURLEncoding.EncodeToString(raw[:size]),
+		Y:   base64.RawURLEncoding.EncodeToString(raw[size:]),
+		Kid: kid,
+		Alg: "ES512",
+		Use: "sig",
+	}, nil
+}
@@ -0,0 +1,57 @@
+package ijwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestKeyRing_Rotate(t *testing.T) {
+	ring := NewKeyRing(time.Hour)
+	key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	ring.Add("old", key)
+	require.NoError(t, ring.Rotate("old"))
+	newKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	ring.Add("new", newKey)
+
+	h := &RedisJWTHandler{keys: ring}
+	oldToken, err := h.sign(RefreshClaims{Uid: 123, Ssid: "ssid"})
+	require.NoError(t, err)
+
+	// 轮换之后，新 token 用新的 kid，旧 token 依旧可以校验通过
+	require.NoError(t, ring.Rotate("new"))
+	kid, _, err := ring.Signer()
+	require.NoError(t, err)
+	assert.Equal(t, "new", kid)
+	newToken, err := h.sign(RefreshClaims{Uid: 123, Ssid: "ssid"})
+	require.NoError(t, err)
+
+	for _, tokenStr := range []string{oldToken, newToken} {
+		var rc RefreshClaims
+		token, err := jwt.ParseWithClaims(tokenStr, &rc, h.keyFunc)
+		require.NoError(t, err)
+		assert.True(t, token.Valid)
+		assert.Equal(t, int64(123), rc.Uid)
+	}
+	assert.Len(t, ring.JWKS().Keys, 2)
+
+	// 过了保留期，旧密钥就不能再用来校验了
+	ring.keys["old"].retiredAt = time.Now().Add(-time.Hour * 2)
+	_, err = ring.Verifier("old")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Len(t, ring.JWKS().Keys, 1)
+
+	assert.ErrorIs(t, ring.Rotate("not-exist"), ErrKeyNotFound)
+	// 不会再现场生成密钥
+	assert.ErrorIs(t, ring.Rotate(""), ErrEmptyKid)
+	kid, _, err = ring.Signer()
+	require.NoError(t, err)
+	assert.Equal(t, "new", kid)
+}
@@ -0,0 +1,34 @@
+-- 一个 ssid 就是一个 refresh_token 家族，key 里面放的是当前唯一有效的 refresh_token 的 jti
+local key = KEYS[1]
+-- session 被撤销之后留下的标记
+local revoked = KEYS[2]
+-- 这个用户的设备列表，session 续期了它也要跟着续期，不然过期之后就没法按设备撤销了
+local sessions = KEYS[3]
+-- 前端带过来的 jti
+local presented = ARGV[1]
+-- 新签发的 jti
+local next = ARGV[2]
+-- 过期时间，毫秒
+local ttl = tonumber(ARGV[3])
+-- 上线轮换之前签发的老 token，没有 jti，Redis 里面也没有记录
+local legacy = ARGV[4] == "1"
+
+local current = redis.call("GET", key)
+if current == false then
+    if legacy and redis.call("EXISTS", revoked) == 0 then
+        -- 老 token 第一次来刷新，接受它并且补上记录，之后再拿它来就算重复使用了
+        redis.call("SET", key, next, "PX", ttl)
+        redis.call("PEXPIRE", sessions, ttl)
+        return 0
+    end
+    -- 家族已经被撤销，或者已经过期了
+    return -2
+end
+if current ~= presented then
+    -- 用了一个已经用过的 refresh_token，说明很可能被偷了，整个家族作废
+    redis.call("DEL", key)
+    return -1
+end
+redis.call("SET", key, next, "PX", ttl)
+redis.call("PEXPIRE", sessions, ttl)
+return 0
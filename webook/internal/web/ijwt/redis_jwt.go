@@ -1,9 +1,7 @@
 package ijwt
 
 import (
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/pem"
+	_ "embed"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -12,50 +10,40 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/zmsocc/practice/webook/pkg/ginx"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 )
 
-// AtKey        = []byte("95osj3fUD7fo0mlYdDbncXz4VD2igvf0")
-//RtKey        = []byte("0Pf2r0wZBpXVXlQNdpwCXN4ncnlnZSc3")
+//go:embed lua/rotate_refresh.lua
+var luaRotateRefresh string
 
-var (
-	AtPrivateKey *ecdsa.PrivateKey
+// 写在 aud 里面，区分 token 的用途，防止拿 access_token 去刷新，或者反过来
+const (
+	accessAudience  = "access"
+	refreshAudience = "refresh"
 )
 
 type RedisJWTHandler struct {
-	cmd redis.Cmdable
+	cmd  redis.Cmdable
+	keys *KeyRing
+	// 为 nil 的时候 access_token 里面不带权限
+	perms PermissionLoader
 	// 长 token 的过期时间
 	rtExpiration time.Duration
 }
 
-func NewRedisJWTHandler(cmd redis.Cmdable) Handler {
+func NewRedisJWTHandler(cmd redis.Cmdable, keys *KeyRing, perms PermissionLoader) Handler {
 	return &RedisJWTHandler{
 		cmd:          cmd,
-		rtExpiration: time.Hour * 24 * 7,
+		keys:         keys,
+		perms:        perms,
+		rtExpiration: RefreshTokenExpiration,
 	}
 }
 
-func InitPrivateKey() error {
-	privKey, err := os.ReadFile("D:/project-go/practice/ec512-private.pem")
-	if err != nil {
-		return fmt.Errorf("读取私钥文件失败: %w", err)
-	}
-	block, _ := pem.Decode(privKey)
-	if block == nil {
-		return errors.New("PEM 格式解析失败")
-	}
-	key, err := x509.ParseECPrivateKey(block.Bytes)
-	if err != nil {
-		return fmt.Errorf("解析 ECDSA 私钥失败: %w", err)
-	}
-	AtPrivateKey = key
-	return nil
-}
-
 func (h *RedisJWTHandler) CheckSession(ctx *gin.Context, ssid string) error {
-	val, err := h.cmd.Exists(ctx, fmt.Sprintf("users:ssid:%s", ssid)).Result()
+	val, err := h.cmd.Exists(ctx, h.ssidKey(ssid)).Result()
 	switch {
 	case errors.Is(err, redis.Nil):
 		return nil
@@ -72,9 +60,13 @@ func (h *RedisJWTHandler) CheckSession(ctx *gin.Context, ssid string) error {
 func (h *RedisJWTHandler) ClearToken(ctx *gin.Context) error {
 	ctx.Header("X-Jwt-Token", "")
 	ctx.Header("x-refresh-token", "")
-	claims := ctx.MustGet("users").(*UserClaims)
-	return h.cmd.Set(ctx, fmt.Sprintf("users:ssid:%s", claims.Ssid),
-		"", time.Hour*7*24).Err()
+	claims := ctx.MustGet("users").(UserClaims)
+	err := h.RevokeSession(ctx, claims.Uid, claims.Ssid)
+	if errors.Is(err, ErrSessionNotFound) {
+		// 不在设备列表里面的老 session，也要让它失效
+		return h.revoke(ctx, claims.Ssid)
+	}
+	return err
 }
 
 func (h *RedisJWTHandler) ExtractToken(ctx *gin.Context) string {
@@ -87,19 +79,7 @@ func (h *RedisJWTHandler) ExtractToken(ctx *gin.Context) string {
 }
 
 func (h *RedisJWTHandler) SetJWTToken(ctx *gin.Context, uid int64, ssid string) error {
-	uc := UserClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			// 过期时间设置为 1 分钟, 测试
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
-		},
-		Uid:       uid,
-		Ssid:      ssid,
-		UserAgent: ctx.Request.UserAgent(),
-	}
-	// 使用 ECDSA 密钥签名
-	token := jwt.NewWithClaims(jwt.SigningMethodES512, uc)
-	// 使用正确格式的私钥
-	tokenStr, err := token.SignedString(AtPrivateKey)
+	tokenStr, err := h.newAccessToken(ctx, uid, ssid)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ginx.Result{
 			Code: 5,
@@ -112,6 +92,29 @@ func (h *RedisJWTHandler) SetJWTToken(ctx *gin.Context, uid int64, ssid string)
 	return nil
 }
 
+func (h *RedisJWTHandler) newAccessToken(ctx *gin.Context, uid int64, ssid string) (string, error) {
+	uc := UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{accessAudience},
+			// 过期时间设置为 1 分钟, 测试
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Uid:       uid,
+		Ssid:      ssid,
+		UserAgent: ctx.Request.UserAgent(),
+	}
+	// 每次刷新都重新查一次，这样收回的权限最多一个 access_token 的有效期之后就失效了
+	if h.perms != nil {
+		roles, perms, err := h.perms.Permissions(ctx, uid)
+		if err != nil {
+			return "", err
+		}
+		uc.Roles, uc.Perms = roles, perms
+	}
+	// 使用 ECDSA 密钥签名
+	return h.sign(uc)
+}
+
 func (h *RedisJWTHandler) SetLoginToken(ctx *gin.Context, uid int64) error {
 	ssid := uuid.New().String()
 	err := h.SetJWTToken(ctx, uid, ssid)
@@ -119,19 +122,80 @@ func (h *RedisJWTHandler) SetLoginToken(ctx *gin.Context, uid int64) error {
 		return err
 	}
 	err = h.SetRefreshToken(ctx, uid, ssid)
-	return err
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return h.AddSession(ctx, Session{
+		Ssid:      ssid,
+		Uid:       uid,
+		Device:    DeviceName(ctx.Request.UserAgent()),
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+		Ctime:     now,
+		LastSeen:  now,
+	})
 }
 
 func (h *RedisJWTHandler) SetRefreshToken(ctx *gin.Context, uid int64, ssid string) error {
-	uc := RefreshClaims{
-		Uid:  uid,
-		Ssid: ssid,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.rtExpiration)),
-		},
+	jti := uuid.New().String()
+	err := h.cmd.Set(ctx, h.refreshKey(ssid), jti, h.rtExpiration).Err()
+	if err != nil {
+		return err
+	}
+	return h.setRefreshToken(ctx, uid, ssid, jti)
+}
+
+// RotateRefreshToken refresh_token 是一次性的，每次刷新都换一个新的。
+// 如果拿来的是一个已经用过的 refresh_token，就把整个 session 撤销掉。
+// 上线之前签发的老 token 在 Redis 里面没有记录，第一次来刷新的时候接受它并且补上记录
+func (h *RedisJWTHandler) RotateRefreshToken(ctx *gin.Context, rc RefreshClaims) error {
+	if rc.ID == "" && !rc.Legacy {
+		return ErrInvalidToken
+	}
+	next := uuid.New().String()
+	// 先把新的 token 都签出来。Redis 里面一旦换过去了，后面就不能再失败，
+	// 不然前端手里还是旧的 refresh_token，下次刷新会被当成重复使用
+	at, err := h.newAccessToken(ctx, rc.Uid, rc.Ssid)
+	if err != nil {
+		return err
+	}
+	rt, err := h.newRefreshToken(rc.Uid, rc.Ssid, next)
+	if err != nil {
+		return err
+	}
+	legacy := 0
+	if rc.Legacy {
+		legacy = 1
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodES512, uc)
-	tokenStr, err := token.SignedString(AtPrivateKey)
+	res, err := h.cmd.Eval(ctx, luaRotateRefresh,
+		[]string{h.refreshKey(rc.Ssid), h.ssidKey(rc.Ssid), h.sessionsKey(rc.Uid)},
+		rc.ID, next, h.rtExpiration.Milliseconds(), legacy).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		ctx.Header("X-Jwt-Token", at)
+		ctx.Header("x-refresh-token", rt)
+		return nil
+	case -1:
+		// 不管设备列表里面有没有这个 session，ssid 都必须失效
+		err = h.RevokeSession(ctx, rc.Uid, rc.Ssid)
+		if errors.Is(err, ErrSessionNotFound) {
+			err = h.revoke(ctx, rc.Ssid)
+		}
+		if err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	default:
+		return ErrInvalidToken
+	}
+}
+
+func (h *RedisJWTHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid, jti string) error {
+	tokenStr, err := h.newRefreshToken(uid, ssid, jti)
 	if err != nil {
 		return err
 	}
@@ -139,6 +203,22 @@ func (h *RedisJWTHandler) SetRefreshToken(ctx *gin.Context, uid int64, ssid stri
 	return nil
 }
 
+func (h *RedisJWTHandler) newRefreshToken(uid int64, ssid, jti string) (string, error) {
+	return h.sign(RefreshClaims{
+		Uid:  uid,
+		Ssid: ssid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{refreshAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.rtExpiration)),
+		},
+	})
+}
+
+func (h *RedisJWTHandler) refreshKey(ssid string) string {
+	return fmt.Sprintf("users:refresh:%s", ssid)
+}
+
 func (h *RedisJWTHandler) ParseToken(ctx *gin.Context, tokenStr string) (UserClaims, error) {
 	// 解析 JWT 令牌
 	var uc UserClaims
@@ -146,7 +226,10 @@ func (h *RedisJWTHandler) ParseToken(ctx *gin.Context, tokenStr string) (UserCla
 	if err != nil {
 		return UserClaims{}, err
 	}
-	if !token.Valid {
+	// 没有 ssid 的不是 access_token，比如两步验证的临时 token。
+	// 老版本的 access_token 没有 aud，有 aud 的必须是 access
+	if !token.Valid || uc.Ssid == "" ||
+		(len(uc.Audience) > 0 && !slices.Contains(uc.Audience, accessAudience)) {
 		return UserClaims{}, ErrInvalidToken
 	}
 	// Redis 会话校验
@@ -156,11 +239,53 @@ func (h *RedisJWTHandler) ParseToken(ctx *gin.Context, tokenStr string) (UserCla
 	return uc, nil
 }
 
+// refreshTokenClaims 解析的时候多带一个 UserAgent，只有 access_token 里面有它，
+// 用来认出拿老版本的 access_token 冒充老版本 refresh_token 的情况
+type refreshTokenClaims struct {
+	RefreshClaims
+	UserAgent string
+}
+
+func (h *RedisJWTHandler) ParseRefreshToken(ctx *gin.Context, tokenStr string) (RefreshClaims, error) {
+	var rc refreshTokenClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &rc, h.keyFunc)
+	if err != nil {
+		return RefreshClaims{}, err
+	}
+	if !token.Valid || rc.Uid == 0 || rc.Ssid == "" {
+		return RefreshClaims{}, ErrInvalidToken
+	}
+	switch {
+	case slices.Contains(rc.Audience, refreshAudience):
+		// 没有 jti 的话 Lua 脚本会把它当成重复使用，把真正的 session 撤销掉
+		if rc.ID == "" {
+			return RefreshClaims{}, ErrInvalidToken
+		}
+	case len(rc.Audience) == 0 && rc.ID == "" && rc.UserAgent == "":
+		rc.Legacy = true
+	default:
+		return RefreshClaims{}, ErrInvalidToken
+	}
+	return rc.RefreshClaims, nil
+}
+
+// sign 用当前的签名密钥签名，并且把 kid 写进 header，校验的时候靠它找公钥
+func (h *RedisJWTHandler) sign(claims jwt.Claims) (string, error) {
+	kid, key, err := h.keys.Signer()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES512, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
 // 密钥处理函数
 func (h *RedisJWTHandler) keyFunc(token *jwt.Token) (interface{}, error) {
 	switch token.Method {
 	case jwt.SigningMethodES512:
-		return AtPrivateKey, nil
+		kid, _ := token.Header["kid"].(string)
+		return h.keys.Verifier(kid)
 	default:
 		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 
@@ -0,0 +1,273 @@
+package ijwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) Chrome/120.0"
+
+// fakePermissionLoader 可以让签发 access_token 的时候失败
+type fakePermissionLoader struct {
+	err error
+}
+
+func (f *fakePermissionLoader) Permissions(ctx context.Context, uid int64) ([]string, []string, error) {
+	return nil, nil, f.err
+}
+
+func newTestHandler(t *testing.T) (*RedisJWTHandler, *miniredis.Miniredis, *fakePermissionLoader) {
+	mr := miniredis.RunT(t)
+	key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	ring := NewKeyRing(time.Hour)
+	ring.Add("test", key)
+	require.NoError(t, ring.Rotate("test"))
+	perms := &fakePermissionLoader{}
+	h := NewRedisJWTHandler(redis.NewClient(&redis.Options{Addr: mr.Addr()}), ring, perms)
+	return h.(*RedisJWTHandler), mr, perms
+}
+
+func newTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.ReleaseMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/users/refresh_token", nil)
+	ctx.Request.Header.Set("User-Agent", testUserAgent)
+	return ctx, recorder
+}
+
+// login 登录一次，返回 refresh_token 的解析结果
+func login(t *testing.T, h *RedisJWTHandler) RefreshClaims {
+	ctx, recorder := newTestContext()
+	require.NoError(t, h.SetLoginToken(ctx, 123))
+	rc, err := h.ParseRefreshToken(ctx, recorder.Header().Get("x-refresh-token"))
+	require.NoError(t, err)
+	return rc
+}
+
+// rotate 刷新一次，成功的话返回新的 refresh_token 的解析结果
+func rotate(t *testing.T, h *RedisJWTHandler, rc RefreshClaims) (RefreshClaims, error) {
+	ctx, recorder := newTestContext()
+	if err := h.RotateRefreshToken(ctx, rc); err != nil {
+		assert.Empty(t, recorder.Header().Get("X-Jwt-Token"))
+		assert.Empty(t, recorder.Header().Get("x-refresh-token"))
+		return RefreshClaims{}, err
+	}
+	_, err := h.ParseToken(ctx, recorder.Header().Get("X-Jwt-Token"))
+	require.NoError(t, err)
+	next, err := h.ParseRefreshToken(ctx, recorder.Header().Get("x-refresh-token"))
+	require.NoError(t, err)
+	return next, nil
+}
+
+func TestRedisJWTHandler_RotateRefreshToken(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	first := login(t, h)
+	assert.False(t, first.Legacy)
+
+	second, err := rotate(t, h, first)
+	require.NoError(t, err)
+	assert.Equal(t, first.Ssid, second.Ssid)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	third, err := rotate(t, h, second)
+	require.NoError(t, err)
+
+	// 拿用过的 refresh_token 来刷新，整个 session 作废
+	_, err = rotate(t, h, first)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	ctx, _ := newTestContext()
+	assert.Error(t, h.CheckSession(ctx, first.Ssid))
+	// 正常用户手里最新的那个也不能用了
+	_, err = rotate(t, h, third)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	sessions, err := h.ListSessions(ctx, 123)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+// 一直在刷新的 session 超过了登录时候的有效期，还要在设备列表里面，还要能被撤销
+func TestRedisJWTHandler_RotateKeepsSessionRevocable(t *testing.T) {
+	h, mr, _ := newTestHandler(t)
+	rc := login(t, h)
+	mr.FastForward(h.rtExpiration - time.Hour)
+	rc, err := rotate(t, h, rc)
+	require.NoError(t, err)
+	mr.FastForward(time.Hour * 2)
+
+	ctx, _ := newTestContext()
+	sessions, err := h.ListSessions(ctx, 123)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, rc.Ssid, sessions[0].Ssid)
+
+	require.NoError(t, h.RevokeAllSessions(ctx, 123))
+	assert.Error(t, h.CheckSession(ctx, rc.Ssid))
+	_, err = rotate(t, h, rc)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRedisJWTHandler_TouchSessionExtendsSessions(t *testing.T) {
+	h, mr, _ := newTestHandler(t)
+	ctx := context.Background()
+	require.NoError(t, h.AddSession(ctx, Session{Ssid: "ssid", Uid: 123,
+		LastSeen: time.Now().Add(-time.Minute * 2)}))
+	mr.FastForward(h.rtExpiration - time.Hour)
+	require.NoError(t, h.TouchSession(ctx, 123, "ssid"))
+	mr.FastForward(time.Hour * 2)
+	assert.True(t, mr.Exists(h.sessionsKey(123)))
+}
+
+func TestRedisJWTHandler_RotateRefreshTokenSignFailed(t *testing.T) {
+	h, mr, perms := newTestHandler(t)
+	rc := login(t, h)
+	jti, err := mr.Get(h.refreshKey(rc.Ssid))
+	require.NoError(t, err)
+
+	// 新 token 签不出来，Redis 里面不能换过去，前端手里的还能接着用
+	perms.err = errors.New("mock error")
+	_, err = rotate(t, h, rc)
+	assert.Error(t, err)
+	current, err := mr.Get(h.refreshKey(rc.Ssid))
+	require.NoError(t, err)
+	assert.Equal(t, jti, current)
+
+	perms.err = nil
+	_, err = rotate(t, h, rc)
+	assert.NoError(t, err)
+}
+
+func TestRedisJWTHandler_RotateLegacyRefreshToken(t *testing.T) {
+	h, mr, _ := newTestHandler(t)
+	// 上线之前签发的 refresh_token：没有 aud、没有 jti，Redis 里面也没有记录
+	legacy, err := h.sign(RefreshClaims{
+		Uid:  123,
+		Ssid: "legacy-ssid",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+	ctx, _ := newTestContext()
+	rc, err := h.ParseRefreshToken(ctx, legacy)
+	require.NoError(t, err)
+	assert.True(t, rc.Legacy)
+
+	// 第一次接受，并且补上记录，之后换成新的 token
+	next, err := rotate(t, h, rc)
+	require.NoError(t, err)
+	assert.False(t, next.Legacy)
+	assert.True(t, mr.Exists(h.refreshKey("legacy-ssid")))
+
+	// 老 token 再来一次就是重复使用
+	_, err = rotate(t, h, rc)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	// session 撤销之后，老 token 也不能再把记录补回来
+	_, err = rotate(t, h, rc)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.False(t, mr.Exists(h.refreshKey("legacy-ssid")))
+}
+
+func TestRedisJWTHandler_ParseRefreshToken(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	expiresAt := jwt.NewNumericDate(time.Now().Add(time.Hour))
+	testCases := []struct {
+		name  string
+		token func(t *testing.T) string
+
+		wantErr    error
+		wantLegacy bool
+	}{
+		{
+			name: "新的 refresh_token",
+			token: func(t *testing.T) string {
+				token, err := h.newRefreshToken(123, "ssid", "jti")
+				require.NoError(t, err)
+				return token
+			},
+		},
+		{
+			name: "老的 refresh_token",
+			token: func(t *testing.T) string {
+				token, err := h.sign(RefreshClaims{Uid: 123, Ssid: "ssid",
+					RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expiresAt}})
+				require.NoError(t, err)
+				return token
+			},
+			wantLegacy: true,
+		},
+		{
+			name: "access_token",
+			token: func(t *testing.T) string {
+				ctx, _ := newTestContext()
+				token, err := h.newAccessToken(ctx, 123, "ssid")
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "老的 access_token",
+			token: func(t *testing.T) string {
+				token, err := h.sign(UserClaims{Uid: 123, Ssid: "ssid", UserAgent: testUserAgent,
+					RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expiresAt}})
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "两步验证的 token",
+			token: func(t *testing.T) string {
+				ctx, _ := newTestContext()
+				token, err := h.NewTwoFactorToken(ctx, 123)
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "aud 是 refresh 但是没有 jti",
+			token: func(t *testing.T) string {
+				token, err := h.newRefreshToken(123, "ssid", "")
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, _ := newTestContext()
+			rc, err := h.ParseRefreshToken(ctx, tc.token(t))
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err == nil {
+				assert.Equal(t, int64(123), rc.Uid)
+				assert.Equal(t, tc.wantLegacy, rc.Legacy)
+			}
+		})
+	}
+}
+
+func TestRedisJWTHandler_ParseTokenRejectsRefreshToken(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	token, err := h.newRefreshToken(123, "ssid", "jti")
+	require.NoError(t, err)
+	ctx, _ := newTestContext()
+	_, err = h.ParseToken(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,203 @@
+package ijwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session 不存在")
+
+// Session 一次登录就是一个 session，用 ssid 标识，也就是一台"登录设备"
+type Session struct {
+	Ssid      string    `json:"ssid"`
+	Uid       int64     `json:"uid"`
+	Device    string    `json:"device"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	Ctime     time.Time `json:"ctime"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// AddSession 登录的时候登记 session。
+// 一个用户的所有 session 放在同一个 hash 里面，field 是 ssid
+func (h *RedisJWTHandler) AddSession(ctx context.Context, s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	key := h.sessionsKey(s.Uid)
+	pipe := h.cmd.TxPipeline()
+	pipe.HSet(ctx, key, s.Ssid, data)
+	// 长 token 过期了，session 也就没用了
+	pipe.Expire(ctx, key, h.rtExpiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TouchSession 更新最后活跃时间。为了不每个请求都写 redis，一分钟内只更新一次
+func (h *RedisJWTHandler) TouchSession(ctx context.Context, uid int64, ssid string) error {
+	s, err := h.getSession(ctx, uid, ssid)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.Sub(s.LastSeen) < time.Minute {
+		return nil
+	}
+	s.LastSeen = now
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	key := h.sessionsKey(uid)
+	pipe := h.cmd.TxPipeline()
+	pipe.HSet(ctx, key, ssid, data)
+	// 还在用的 session 不能因为登录的时候设的过期时间到了就从设备列表里面消失
+	pipe.Expire(ctx, key, h.rtExpiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (h *RedisJWTHandler) ListSessions(ctx context.Context, uid int64) ([]Session, error) {
+	data, err := h.cmd.HGetAll(ctx, h.sessionsKey(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Session, 0, len(data))
+	now := time.Now()
+	for _, val := range data {
+		var s Session
+		if err = json.Unmarshal([]byte(val), &s); err != nil {
+			return nil, err
+		}
+		// 超过长 token 有效期都没有活跃过的，说明已经过期了
+		if now.Sub(s.LastSeen) > h.rtExpiration {
+			continue
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastSeen.After(res[j].LastSeen)
+	})
+	return res, nil
+}
+
+// RevokeSession 踢掉某一台设备。
+// 把 ssid 写进 users:ssid:xxx，CheckSession 就会立刻拒绝它
+func (h *RedisJWTHandler) RevokeSession(ctx context.Context, uid int64, ssid string) error {
+	n, err := h.cmd.HDel(ctx, h.sessionsKey(uid), ssid).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// 不是这个用户的 session，不能让他随便踢别人
+		return ErrSessionNotFound
+	}
+	return h.revoke(ctx, ssid)
+}
+
+// RevokeAllSessions 退出所有设备，except 里面的 ssid 会被保留，一般是当前的 session
+func (h *RedisJWTHandler) RevokeAllSessions(ctx context.Context, uid int64, except ...string) error {
+	key := h.sessionsKey(uid)
+	ssids, err := h.cmd.HKeys(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	pipe := h.cmd.TxPipeline()
+	for _, ssid := range ssids {
+		if contains(except, ssid) {
+			continue
+		}
+		pipe.HDel(ctx, key, ssid)
+		pipe.Set(ctx, h.ssidKey(ssid), "", h.rtExpiration)
+		pipe.Del(ctx, h.refreshKey(ssid))
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// revoke 让 ssid 立刻失效，对应的 refresh_token 家族也一起作废
+func (h *RedisJWTHandler) revoke(ctx context.Context, ssid string) error {
+	pipe := h.cmd.TxPipeline()
+	pipe.Set(ctx, h.ssidKey(ssid), "", h.rtExpiration)
+	pipe.Del(ctx, h.refreshKey(ssid))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (h *RedisJWTHandler) getSession(ctx context.Context, uid int64, ssid string) (Session, error) {
+	data, err := h.cmd.HGet(ctx, h.sessionsKey(uid), ssid).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	var s Session
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+func (h *RedisJWTHandler) sessionsKey(uid int64) string {
+	return fmt.Sprintf("users:sessions:%d", uid)
+}
+
+func (h *RedisJWTHandler) ssidKey(ssid string) string {
+	return fmt.Sprintf("users:ssid:%s", ssid)
+}
+
+func contains(ssids []string, ssid string) bool {
+	for _, s := range ssids {
+		if s == ssid {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceName 从 User-Agent 里面粗略地猜一下设备名字，只是用来给用户看的，不需要很准
+func DeviceName(ua string) string {
+	if ua == "" {
+		return "未知设备"
+	}
+	var browser, os string
+	switch {
+	case strings.Contains(ua, "MicroMessenger"):
+		browser = "微信"
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	case strings.Contains(ua, "PostmanRuntime"):
+		browser = "Postman"
+	default:
+		browser = "未知浏览器"
+	}
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	default:
+		return browser
+	}
+	return browser + " on " + os
+}
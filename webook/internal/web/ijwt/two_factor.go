@@ -0,0 +1,53 @@
+package ijwt
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	twoFactorAudience   = "2fa"
+	twoFactorExpiration = time.Minute * 5
+	// 一个两步验证 token 最多能试几次
+	twoFactorMaxAttempts = 5
+)
+
+func (h *RedisJWTHandler) NewTwoFactorToken(ctx *gin.Context, uid int64) (string, error) {
+	tc := TwoFactorClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{twoFactorAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorExpiration)),
+		},
+		Uid:       uid,
+		UserAgent: ctx.Request.UserAgent(),
+	}
+	return h.sign(tc)
+}
+
+// ParseTwoFactorToken 每解析一次都算一次尝试，超过次数这个 token 就废了，防止暴力破解验证码
+func (h *RedisJWTHandler) ParseTwoFactorToken(ctx *gin.Context, tokenStr string) (TwoFactorClaims, error) {
+	var tc TwoFactorClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &tc, h.keyFunc,
+		jwt.WithAudience(twoFactorAudience))
+	if err != nil {
+		return TwoFactorClaims{}, err
+	}
+	if !token.Valid || tc.ID == "" || tc.UserAgent != ctx.Request.UserAgent() {
+		return TwoFactorClaims{}, ErrInvalidToken
+	}
+	key := fmt.Sprintf("users:2fa:attempts:%s", tc.ID)
+	pipe := h.cmd.TxPipeline()
+	cnt := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, twoFactorExpiration)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return TwoFactorClaims{}, err
+	}
+	if cnt.Val() > twoFactorMaxAttempts {
+		return TwoFactorClaims{}, ErrTooManyAttempts
+	}
+	return tc, nil
+}
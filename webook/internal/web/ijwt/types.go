@@ -1,14 +1,23 @@
 package ijwt
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"time"
 )
 
+// RefreshTokenExpiration 长 token 的过期时间，旧的签名密钥也至少要保留这么久
+const RefreshTokenExpiration = time.Hour * 24 * 7
+
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrTokenExpired = errors.New("token is expired")
+	// ErrRefreshTokenReused 已经用过的 refresh_token 又被拿来用了，很可能被偷了
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrTooManyAttempts 两步验证输错太多次了
+	ErrTooManyAttempts = errors.New("too many attempts")
 )
 
 type Handler interface {
@@ -18,13 +27,33 @@ type Handler interface {
 	SetJWTToken(ctx *gin.Context, uid int64, ssid string) error
 	SetLoginToken(ctx *gin.Context, uid int64) error
 	SetRefreshToken(ctx *gin.Context, uid int64, ssid string) error
+	RotateRefreshToken(ctx *gin.Context, rc RefreshClaims) error
 	ParseToken(ctx *gin.Context, tokenStr string) (UserClaims, error)
+	ParseRefreshToken(ctx *gin.Context, tokenStr string) (RefreshClaims, error)
+
+	// 两步验证。密码校验通过之后先发一个短期的 token，第二步验证通过了才真正登录
+	NewTwoFactorToken(ctx *gin.Context, uid int64) (string, error)
+	ParseTwoFactorToken(ctx *gin.Context, tokenStr string) (TwoFactorClaims, error)
+
+	// 多设备登录管理
+	TouchSession(ctx context.Context, uid int64, ssid string) error
+	ListSessions(ctx context.Context, uid int64) ([]Session, error)
+	RevokeSession(ctx context.Context, uid int64, ssid string) error
+	RevokeAllSessions(ctx context.Context, uid int64, except ...string) error
+}
+
+// PermissionLoader 签发 access_token 的时候查询用户的角色和权限。
+// 返回角色名字和合并之后的权限
+type PermissionLoader interface {
+	Permissions(ctx context.Context, uid int64) ([]string, []string, error)
 }
 
 type RefreshClaims struct {
 	Uid  int64
 	Ssid string
 	jwt.RegisteredClaims
+	// Legacy 上线 refresh_token 轮换之前签发的，没有 aud 也没有 jti，不会写进 JWT
+	Legacy bool `json:"-"`
 }
 
 type UserClaims struct {
@@ -32,4 +61,30 @@ type UserClaims struct {
 	Uid       int64
 	Ssid      string
 	UserAgent string
+	Roles     []string `json:",omitempty"`
+	Perms     []string `json:",omitempty"`
+	// 下面两个只有用个人访问令牌请求的时候才有，不会写进 JWT
+	AccessTokenId int64    `json:"-"`
+	Scopes        []string `json:"-"`
+}
+
+// IsAccessToken 是不是用个人访问令牌登录的
+func (uc UserClaims) IsAccessToken() bool {
+	return uc.AccessTokenId > 0
+}
+
+func (uc UserClaims) HasPermission(perm string) bool {
+	for _, p := range uc.Perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorClaims 密码对了但是还没通过两步验证，这个 token 只能拿来提交第二步
+type TwoFactorClaims struct {
+	jwt.RegisteredClaims
+	Uid       int64
+	UserAgent string
 }
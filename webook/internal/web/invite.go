@@ -0,0 +1,114 @@
+package web
+
+import (
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+const (
+	invitePageDefaultLimit = 20
+	invitePageMaxLimit     = 100
+)
+
+// InviteHandler 用户自己的邀请码，管理员的接口在 AdminHandler 里面
+type InviteHandler struct {
+	svc service.InviteService
+	l   logger.Logger
+}
+
+func NewInviteHandler(svc service.InviteService, l logger.Logger) *InviteHandler {
+	return &InviteHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *InviteHandler) RegisterRoutes(server *gin.Engine) {
+	ig := server.Group("/invites")
+	// 注册页面用来判断要不要显示邀请码输入框，不需要登录
+	ig.GET("/mode", ginx.WrapBody(h.Mode))
+	ig.POST("", ginx.WrapBody(h.Generate))
+	ig.GET("", ginx.WrapBody(h.List))
+	ig.GET("/invitees", ginx.WrapBody(h.Invitees))
+}
+
+func (h *InviteHandler) Mode(ctx *gin.Context) (Result, error) {
+	return Result{Data: InviteModeVO{Required: h.svc.Required()}}, nil
+}
+
+func (h *InviteHandler) Generate(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	c, err := h.svc.Generate(ctx, uc.Uid)
+	if errors.Is(err, service.ErrInviteQuotaExceeded) {
+		return Result{Code: 4, Msg: "还有没用完的邀请码，请先把它们发出去"}, nil
+	}
+	if err != nil {
+		h.l.Error("生成邀请码失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toInviteCodeVO(c)}, nil
+}
+
+func (h *InviteHandler) List(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	offset, limit, ok := pageQuery(ctx, invitePageDefaultLimit, invitePageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	codes, err := h.svc.List(ctx, uc.Uid, offset, limit)
+	if err != nil {
+		h.l.Error("查询邀请码失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: slice.Map[domain.InviteCode, InviteCodeVO](codes,
+		func(idx int, src domain.InviteCode) InviteCodeVO {
+			return toInviteCodeVO(src)
+		})}, nil
+}
+
+// Invitees 我邀请了谁
+func (h *InviteHandler) Invitees(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	offset, limit, ok := pageQuery(ctx, invitePageDefaultLimit, invitePageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	invs, err := h.svc.Invitations(ctx, uc.Uid, offset, limit)
+	if err != nil {
+		h.l.Error("查询邀请记录失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toInvitationVOs(invs)}, nil
+}
+
+func toInviteCodeVO(c domain.InviteCode) InviteCodeVO {
+	vo := InviteCodeVO{
+		Code:    c.Code,
+		MaxUses: c.MaxUses,
+		Used:    c.Used,
+		Usable:  c.Usable(time.Now()),
+		Ctime:   c.Ctime.Format(time.DateTime),
+	}
+	if !c.ExpireAt.IsZero() {
+		vo.ExpireAt = c.ExpireAt.Format(time.DateTime)
+	}
+	return vo
+}
+
+func toInvitationVOs(invs []domain.Invitation) []InvitationVO {
+	return slice.Map[domain.Invitation, InvitationVO](invs, func(idx int, src domain.Invitation) InvitationVO {
+		return InvitationVO{
+			Inviter: src.Inviter,
+			Invitee: src.Invitee,
+			Code:    src.Code,
+			Ctime:   src.Ctime.Format(time.DateTime),
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/internal/web/middleware"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"net/http"
+)
+
+// JWKSHandler 对外暴露校验 token 用的公钥，以及轮换签名密钥
+type JWKSHandler struct {
+	keys *ijwt.KeyRing
+	l    logger.Logger
+}
+
+func NewJWKSHandler(keys *ijwt.KeyRing, l logger.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+		l:    l,
+	}
+}
+
+func (h *JWKSHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/.well-known/jwks.json", h.JWKS)
+	server.POST("/admin/jwt/rotate",
+		middleware.RequirePermission(domain.PermJWTRotate), ginx.WrapBody(h.Rotate))
+}
+
+func (h *JWKSHandler) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.keys.JWKS())
+}
+
+// Rotate 切换到配置里面已经加载的另一把签名密钥，旧的密钥继续用来校验，已经登录的用户不受影响
+func (h *JWKSHandler) Rotate(ctx *gin.Context) (Result, error) {
+	type RotateReq struct {
+		Kid string `json:"kid"`
+	}
+	var req RotateReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	err := h.keys.Rotate(req.Kid)
+	if errors.Is(err, ijwt.ErrEmptyKid) {
+		return Result{Code: 4, Msg: "请指定要切换的 kid"}, nil
+	}
+	if errors.Is(err, ijwt.ErrKeyNotFound) {
+		return Result{Code: 4, Msg: "密钥不存在"}, nil
+	}
+	if err != nil {
+		h.l.Error("轮换签名密钥失败", logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	kid, _, _ := h.keys.Signer()
+	h.l.Info("轮换签名密钥成功", logger.String("kid", kid))
+	return Result{Msg: "轮换成功", Data: kid}, nil
+}
@@ -2,21 +2,34 @@ package middleware
 
 import (
 	"encoding/gob"
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/zmsocc/practice/webook/internal/service"
 	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type LoginJWTMiddlewareBuilder struct {
 	paths []string
+	// 登录不登录都可以访问的路由，带了 token 就校验
+	optionalPaths []string
+	// 允许用个人访问令牌访问的路由，以及需要的权限范围，key 是注册路由时候的路径
+	tokenScopes map[string]string
+	tokenSvc    service.AccessTokenService
+	statusSvc   service.UserStatusService
 	ijwt.Handler
 }
 
-func NewLoginJWTMiddlewareBuilder(jwtHdl ijwt.Handler) *LoginJWTMiddlewareBuilder {
+func NewLoginJWTMiddlewareBuilder(jwtHdl ijwt.Handler, tokenSvc service.AccessTokenService,
+	statusSvc service.UserStatusService) *LoginJWTMiddlewareBuilder {
 	return &LoginJWTMiddlewareBuilder{
-		Handler: jwtHdl,
+		tokenScopes: make(map[string]string),
+		tokenSvc:    tokenSvc,
+		statusSvc:   statusSvc,
+		Handler:     jwtHdl,
 	}
 }
 
@@ -25,28 +38,47 @@ func (l *LoginJWTMiddlewareBuilder) IgnorePaths(path string) *LoginJWTMiddleware
 	return l
 }
 
+// OptionalPaths 不登录也能访问 path，但是带了 token 的话照常校验，
+// 这样接口里面可以拿到当前用户，比如作者主页要返回“我有没有关注他”
+func (l *LoginJWTMiddlewareBuilder) OptionalPaths(path string) *LoginJWTMiddlewareBuilder {
+	l.optionalPaths = append(l.optionalPaths, path)
+	return l
+}
+
+// AllowAccessToken 允许用个人访问令牌访问 path，令牌必须有 scope 这个权限范围。
+// 没有声明过的路由只能用 JWT 访问。path 是注册路由时候的路径，比如 /pub/:id
+func (l *LoginJWTMiddlewareBuilder) AllowAccessToken(path, scope string) *LoginJWTMiddlewareBuilder {
+	l.tokenScopes[path] = scope
+	return l
+}
+
 func (l *LoginJWTMiddlewareBuilder) Build() gin.HandlerFunc {
 	// 用 go 的方式编码解码
 	gob.Register(time.Now())
 	return func(ctx *gin.Context) {
 		for _, path := range l.paths {
-			if ctx.Request.URL.Path == path {
+			// 带参数的路由，比如 /authors/:id，按注册时候的路径匹配
+			if ctx.Request.URL.Path == path || ctx.FullPath() == path {
 				return
 			}
 		}
-		// 用 JWT 来校验
 		tokenStr := l.ExtractToken(ctx)
-		uc := ijwt.UserClaims{}
-		// ParseWithClaims 里面一定要传入指针
-		token, err := jwt.ParseWithClaims(tokenStr, &uc, func(token *jwt.Token) (interface{}, error) {
-			return []byte("95osj3fUD7fo0mlYdDbncXz4VD2igvf0"), nil
-		})
+		if tokenStr == "" && l.isOptional(ctx) {
+			return
+		}
+		if strings.HasPrefix(tokenStr, service.AccessTokenPrefix) {
+			l.checkAccessToken(ctx, tokenStr)
+			return
+		}
+		// 用 JWT 来校验
+		// 验签的同时也会通过 CheckSession 检查 session 是否已经失效
+		uc, err := l.ParseToken(ctx, tokenStr)
 		if err != nil {
-			// 没登录
+			// 没登录，或者要么 redis 有问题， 要么已经退出登录
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		if token == nil || !token.Valid || uc.Uid == 0 {
+		if uc.Uid == 0 {
 			// 没登录
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
@@ -56,12 +88,66 @@ func (l *LoginJWTMiddlewareBuilder) Build() gin.HandlerFunc {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		err = l.CheckSession(ctx, uc.Ssid)
-		if err != nil {
-			// 要么 redis 有问题， 要么已经退出登录
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+		// 不用再查用户状态，封禁和暂停的时候所有 session 都已经撤销了，上面的 CheckSession 就能拦住
+		// 记录一下设备最后活跃时间，失败了也不影响正常请求
+		_ = l.TouchSession(ctx, uc.Uid, uc.Ssid)
 		ctx.Set("users", uc)
 	}
 }
+
+// checkStatus 被封禁或者暂停的用户，个人访问令牌还没过期也不能再用
+func (l *LoginJWTMiddlewareBuilder) checkStatus(ctx *gin.Context, uid int64) bool {
+	u, err := l.statusSvc.Check(ctx, uid)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrUserBanned):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, ginx.Result{Code: 4, Msg: "账号已被封禁"})
+	case errors.Is(err, service.ErrUserSuspended):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, ginx.Result{
+			Code: 4,
+			Msg:  "账号已被暂停使用，" + u.SuspendedUntil.Format(time.DateTime) + " 之后恢复",
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+	default:
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}
+	return false
+}
+
+func (l *LoginJWTMiddlewareBuilder) isOptional(ctx *gin.Context) bool {
+	for _, path := range l.optionalPaths {
+		if ctx.Request.URL.Path == path || ctx.FullPath() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAccessToken 个人访问令牌没有 session，也不校验 User-Agent，
+// 但是只能访问声明过的路由，并且要有对应的权限范围
+func (l *LoginJWTMiddlewareBuilder) checkAccessToken(ctx *gin.Context, tokenStr string) {
+	scope, ok := l.tokenScopes[ctx.FullPath()]
+	if !ok {
+		ctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	t, err := l.tokenSvc.Verify(ctx, tokenStr)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	if !t.HasScope(scope) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	if !l.checkStatus(ctx, t.Uid) {
+		return
+	}
+	ctx.Set("users", ijwt.UserClaims{
+		Uid:           t.Uid,
+		AccessTokenId: t.Id,
+		Scopes:        t.Scopes,
+	})
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	ijwtmocks "github.com/zmsocc/practice/webook/internal/web/ijwt/mocks"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type loginJWTMocks struct {
+	jwtHdl    *ijwtmocks.MockHandler
+	tokenSvc  *svcmocks.MockAccessTokenService
+	statusSvc *svcmocks.MockUserStatusService
+}
+
+func TestLoginJWTMiddlewareBuilder_AccessToken(t *testing.T) {
+	const token = service.AccessTokenPrefix + "abcdef"
+	testCases := []struct {
+		name string
+		mock func(m loginJWTMocks)
+		path string
+
+		wantCode   int
+		wantClaims ijwt.UserClaims
+	}{
+		{
+			name: "令牌有对应的权限范围",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+				m.tokenSvc.EXPECT().Verify(gomock.Any(), token).Return(domain.AccessToken{
+					Id: 10, Uid: 1, Scopes: []string{domain.ScopeArticleRead},
+				}, nil)
+				m.statusSvc.EXPECT().Check(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusOK,
+			wantClaims: ijwt.UserClaims{
+				Uid:           1,
+				AccessTokenId: 10,
+				Scopes:        []string{domain.ScopeArticleRead},
+			},
+		},
+		{
+			name: "没有声明可以用令牌访问的路由",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+			},
+			path:     "/users/tokens",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "令牌无效",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+				m.tokenSvc.EXPECT().Verify(gomock.Any(), token).
+					Return(domain.AccessToken{}, service.ErrInvalidAccessToken)
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "令牌没有这个权限范围",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+				m.tokenSvc.EXPECT().Verify(gomock.Any(), token).Return(domain.AccessToken{
+					Id: 10, Uid: 1, Scopes: []string{domain.ScopeUserRead},
+				}, nil)
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "用户被封禁了",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+				m.tokenSvc.EXPECT().Verify(gomock.Any(), token).Return(domain.AccessToken{
+					Id: 10, Uid: 1, Scopes: []string{domain.ScopeArticleRead},
+				}, nil)
+				m.statusSvc.EXPECT().Check(gomock.Any(), int64(1)).Return(domain.User{}, service.ErrUserBanned)
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "用户被暂停了",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return(token)
+				m.tokenSvc.EXPECT().Verify(gomock.Any(), token).Return(domain.AccessToken{
+					Id: 10, Uid: 1, Scopes: []string{domain.ScopeArticleRead},
+				}, nil)
+				m.statusSvc.EXPECT().Check(gomock.Any(), int64(1)).Return(domain.User{}, service.ErrUserSuspended)
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			// 封禁和暂停的时候 session 已经撤销了，ParseToken 就过不去，不用再查用户状态
+			name: "JWT 照常校验，不查用户状态",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return("jwt")
+				m.jwtHdl.EXPECT().ParseToken(gomock.Any(), "jwt").
+					Return(ijwt.UserClaims{Uid: 1, Ssid: "ssid", UserAgent: "test"}, nil)
+				m.jwtHdl.EXPECT().TouchSession(gomock.Any(), int64(1), "ssid").Return(nil)
+			},
+			path:       "/users/tokens",
+			wantCode:   http.StatusOK,
+			wantClaims: ijwt.UserClaims{Uid: 1, Ssid: "ssid", UserAgent: "test"},
+		},
+		{
+			name: "JWT 无效",
+			mock: func(m loginJWTMocks) {
+				m.jwtHdl.EXPECT().ExtractToken(gomock.Any()).Return("jwt")
+				m.jwtHdl.EXPECT().ParseToken(gomock.Any(), "jwt").
+					Return(ijwt.UserClaims{}, errors.New("mock error"))
+			},
+			path:     "/pub/2",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := loginJWTMocks{
+				jwtHdl:    ijwtmocks.NewMockHandler(ctrl),
+				tokenSvc:  svcmocks.NewMockAccessTokenService(ctrl),
+				statusSvc: svcmocks.NewMockUserStatusService(ctrl),
+			}
+			tc.mock(m)
+
+			gin.SetMode(gin.ReleaseMode)
+			server := gin.New()
+			server.Use(NewLoginJWTMiddlewareBuilder(m.jwtHdl, m.tokenSvc, m.statusSvc).
+				AllowAccessToken("/pub/:id", domain.ScopeArticleRead).
+				Build())
+			var claims ijwt.UserClaims
+			handle := func(ctx *gin.Context) {
+				claims = ctx.MustGet("users").(ijwt.UserClaims)
+			}
+			server.GET("/pub/:id", handle)
+			server.GET("/users/tokens", handle)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("User-Agent", "test")
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			assert.Equal(t, tc.wantCode, resp.Code)
+			assert.Equal(t, tc.wantClaims, claims)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"net/http"
+)
+
+// RequirePermission 要求登录用户拥有 perm 权限，必须放在登录校验之后
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		val, ok := ctx.Get("users")
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		uc, ok := val.(ijwt.UserClaims)
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if !uc.HasPermission(perm) {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+}
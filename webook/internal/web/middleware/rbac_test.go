@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequirePermission(t *testing.T) {
+	testCases := []struct {
+		name   string
+		claims any
+
+		wantCode int
+	}{
+		{
+			name:     "有权限",
+			claims:   ijwt.UserClaims{Uid: 1, Perms: []string{"user:status", "user:role"}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "没有这个权限",
+			claims:   ijwt.UserClaims{Uid: 1, Perms: []string{"user:status"}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "没有任何权限",
+			claims:   ijwt.UserClaims{Uid: 1},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "没有登录",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "claims 类型不对",
+			claims:   &ijwt.UserClaims{Uid: 1, Perms: []string{"user:role"}},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set("users", tc.claims)
+				}
+			})
+			server.GET("/admin", RequirePermission("user:role"), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			assert.Equal(t, tc.wantCode, resp.Code)
+		})
+	}
+}
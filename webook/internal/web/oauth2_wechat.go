@@ -0,0 +1,148 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/service/oauth2"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"net/http"
+	"time"
+)
+
+const (
+	stateCookieName = "jwt-state"
+	stateExpiration = time.Minute * 10
+)
+
+var errInvalidState = errors.New("state 不匹配")
+
+type OAuth2WechatHandler struct {
+	svc     oauth2.Service
+	userSvc service.UserService
+	jwtHdl  ijwt.Handler
+	// 签名 state cookie 用的密钥
+	stateKey []byte
+	l        logger.Logger
+}
+
+func NewOAuth2WechatHandler(svc oauth2.Service, userSvc service.UserService,
+	jwtHdl ijwt.Handler, stateKey []byte, l logger.Logger) *OAuth2WechatHandler {
+	return &OAuth2WechatHandler{
+		svc:      svc,
+		userSvc:  userSvc,
+		jwtHdl:   jwtHdl,
+		stateKey: stateKey,
+		l:        l,
+	}
+}
+
+func (h *OAuth2WechatHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/oauth2/wechat")
+	g.GET("/login", h.Login)
+	g.Any("/callback", ginx.WrapBody(h.Callback))
+}
+
+// Login 生成 state，放进签名过的 cookie 里面，然后跳转到微信的授权页面。
+// 第一次登录会自动注册，邀请码通过 invite_code 参数带过来，和 state 一起放在 cookie 里面
+func (h *OAuth2WechatHandler) Login(ctx *gin.Context) {
+	state := uuid.New().String()
+	authURL, err := h.svc.AuthURL(ctx, state)
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
+		return
+	}
+	if err = h.setStateCookie(ctx, state, ctx.Query("invite_code")); err != nil {
+		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
+		return
+	}
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+func (h *OAuth2WechatHandler) Callback(ctx *gin.Context) (Result, error) {
+	sc, err := h.verifyState(ctx)
+	if err != nil {
+		h.l.Warn("微信登录 state 校验失败",
+			logger.String("ip", ctx.ClientIP()), logger.Error(err))
+		return Result{Code: 4, Msg: "登录失败，请重试"}, nil
+	}
+	code := ctx.Query("code")
+	if code == "" {
+		// 用户拒绝了授权
+		return Result{Code: 4, Msg: "授权失败"}, nil
+	}
+	info, err := h.svc.VerifyCode(ctx, code)
+	if err != nil {
+		h.l.Error("微信换取用户信息失败", logger.Error(err))
+		return Result{Code: 4, Msg: "授权码有误"}, nil
+	}
+	u, err := h.userSvc.FindOrCreateByOAuth(ctx, info, sc.InviteCode)
+	if res, ok := inviteResult(err); ok {
+		return res, nil
+	}
+	if res, ok := userStatusResult(u, err); ok {
+		return res, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if err = h.jwtHdl.SetLoginToken(ctx, u.Id); err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "登录成功"}, nil
+}
+
+type StateClaims struct {
+	jwt.RegisteredClaims
+	State string
+	// 第一次登录自动注册的时候用的邀请码
+	InviteCode string
+}
+
+func (h *OAuth2WechatHandler) setStateCookie(ctx *gin.Context, state, inviteCode string) error {
+	claims := StateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(stateExpiration)),
+		},
+		State:      state,
+		InviteCode: inviteCode,
+	}
+	tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(h.stateKey)
+	if err != nil {
+		return err
+	}
+	// 只有回调接口会带上这个 cookie
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(stateCookieName, tokenStr, int(stateExpiration.Seconds()),
+		"/oauth2/wechat/callback", "", false, true)
+	return nil
+}
+
+func (h *OAuth2WechatHandler) verifyState(ctx *gin.Context) (StateClaims, error) {
+	state := ctx.Query("state")
+	tokenStr, err := ctx.Cookie(stateCookieName)
+	if err != nil {
+		return StateClaims{}, fmt.Errorf("拿不到 state 的 cookie %w", err)
+	}
+	var sc StateClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &sc, func(token *jwt.Token) (interface{}, error) {
+		return h.stateKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}))
+	if err != nil {
+		return StateClaims{}, fmt.Errorf("state cookie 无效 %w", err)
+	}
+	if !token.Valid {
+		return StateClaims{}, errors.New("state cookie 无效")
+	}
+	// 用过了就删掉
+	ctx.SetCookie(stateCookieName, "", -1, "/oauth2/wechat/callback", "", false, true)
+	if sc.State == "" || sc.State != state {
+		return StateClaims{}, errInvalidState
+	}
+	return sc, nil
+}
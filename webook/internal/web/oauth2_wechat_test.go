@@ -0,0 +1,127 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	oauth2mocks "github.com/zmsocc/practice/webook/internal/service/oauth2/mocks"
+	ijwtmocks "github.com/zmsocc/practice/webook/internal/web/ijwt/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 先走一遍 /login 拿到 state 和 cookie，再带着它们回调，邀请码要跟着 state 一起过来
+func TestOAuth2WechatHandler_Callback(t *testing.T) {
+	info := domain.OAuthInfo{Provider: "wechat", OpenId: "open-id"}
+	testCases := []struct {
+		name       string
+		mock       func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler)
+		inviteCode string
+		// 回调的时候篡改 state
+		wrongState bool
+
+		wantRes Result
+	}{
+		{
+			name: "用邀请码注册",
+			mock: func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler) {
+				userSvc.EXPECT().FindOrCreateByOAuth(gomock.Any(), info, "ABCD2345").
+					Return(domain.User{Id: 1}, nil)
+				jwtHdl.EXPECT().SetLoginToken(gomock.Any(), int64(1)).Return(nil)
+			},
+			inviteCode: "ABCD2345",
+			wantRes:    Result{Msg: "登录成功"},
+		},
+		{
+			name: "需要邀请码",
+			mock: func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler) {
+				userSvc.EXPECT().FindOrCreateByOAuth(gomock.Any(), info, "").
+					Return(domain.User{}, service.ErrInviteCodeRequired)
+			},
+			wantRes: Result{Code: 4, Msg: "内测期间注册需要邀请码"},
+		},
+		{
+			name: "邀请码无效",
+			mock: func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler) {
+				userSvc.EXPECT().FindOrCreateByOAuth(gomock.Any(), info, "ABCD2345").
+					Return(domain.User{}, service.ErrInvalidInviteCode)
+			},
+			inviteCode: "ABCD2345",
+			wantRes:    Result{Code: 4, Msg: "邀请码无效"},
+		},
+		{
+			name: "账号被封禁",
+			mock: func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler) {
+				userSvc.EXPECT().FindOrCreateByOAuth(gomock.Any(), info, "").
+					Return(domain.User{Id: 1}, service.ErrUserBanned)
+			},
+			wantRes: Result{Code: 4, Msg: "账号已被封禁"},
+		},
+		{
+			name: "系统错误",
+			mock: func(userSvc *svcmocks.MockUserService, jwtHdl *ijwtmocks.MockHandler) {
+				userSvc.EXPECT().FindOrCreateByOAuth(gomock.Any(), info, "").
+					Return(domain.User{}, errors.New("mock error"))
+			},
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:       "state 不匹配",
+			inviteCode: "ABCD2345",
+			wrongState: true,
+			wantRes:    Result{Code: 4, Msg: "登录失败，请重试"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			oauthSvc := oauth2mocks.NewMockService(ctrl)
+			userSvc := svcmocks.NewMockUserService(ctrl)
+			jwtHdl := ijwtmocks.NewMockHandler(ctrl)
+			if tc.mock != nil {
+				tc.mock(userSvc, jwtHdl)
+			}
+			var state string
+			oauthSvc.EXPECT().AuthURL(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ any, s string) (string, error) {
+					state = s
+					return "https://open.weixin.qq.com/connect/qrconnect?state=" + s, nil
+				})
+			if !tc.wrongState {
+				oauthSvc.EXPECT().VerifyCode(gomock.Any(), "code").Return(info, nil)
+			}
+			gin.SetMode(gin.ReleaseMode)
+			server := gin.New()
+			NewOAuth2WechatHandler(oauthSvc, userSvc, jwtHdl, []byte("state-key"),
+				logger.NewNopLogger()).RegisterRoutes(server)
+
+			req, err := http.NewRequest(http.MethodGet, "/oauth2/wechat/login?invite_code="+tc.inviteCode, nil)
+			require.NoError(t, err)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusFound, resp.Code)
+			cookies := resp.Result().Cookies()
+			require.Len(t, cookies, 1)
+
+			if tc.wrongState {
+				state = "wrong"
+			}
+			req, err = http.NewRequest(http.MethodGet,
+				"/oauth2/wechat/callback?code=code&state="+state, bytes.NewBuffer(nil))
+			require.NoError(t, err)
+			req.AddCookie(cookies[0])
+			resp = httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
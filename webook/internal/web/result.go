@@ -1,5 +1,22 @@
 package web
 
-import "github.com/zmsocc/practice/webook/pkg/ginx"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"strconv"
+)
 
 type Result = ginx.Result
+
+// pageQuery 从查询参数里面拿 offset 和 limit，limit 没传就用 defaultLimit，超过 maxLimit 就截断
+func pageQuery(ctx *gin.Context, defaultLimit, maxLimit int) (int, int, bool) {
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 {
+		return 0, 0, false
+	}
+	return offset, min(limit, maxLimit), true
+}
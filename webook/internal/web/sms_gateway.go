@@ -0,0 +1,67 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/internal/service/sms/auth"
+	"github.com/zmsocc/practice/webook/internal/service/sms/template"
+	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"net/http"
+)
+
+// SMSGatewayHandler 内部的其它服务通过这里发短信。不需要登录，
+// 靠 Authorization 头里面的业务 token 鉴权，token 不对的时候返回 401。客户端在 sms/gateway 里面
+type SMSGatewayHandler struct {
+	svc *auth.Service
+	l   logger.Logger
+}
+
+func NewSMSGatewayHandler(svc *auth.Service, l logger.Logger) *SMSGatewayHandler {
+	return &SMSGatewayHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *SMSGatewayHandler) RegisterRoutes(server *gin.Engine) {
+	server.POST("/sms/send", ginx.WrapBody(h.Send))
+}
+
+func (h *SMSGatewayHandler) Send(ctx *gin.Context) (Result, error) {
+	type SendReq struct {
+		Args    []string `json:"args"`
+		Numbers []string `json:"numbers"`
+	}
+	var req SendReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{}, err
+	}
+	token := extractTokenFromHeader(ctx)
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, Result{Code: 4, Msg: "缺少业务 token"})
+		return Result{}, auth.ErrInvalidToken
+	}
+	if len(req.Numbers) == 0 {
+		return Result{Code: 4, Msg: "手机号不能为空"}, nil
+	}
+	err := h.svc.Send(ctx, token, req.Args, req.Numbers...)
+	var lerr *sms.LimitedError
+	switch {
+	case err == nil:
+		return Result{Msg: "发送成功"}, nil
+	case errors.Is(err, auth.ErrInvalidToken):
+		h.l.Warn("短信业务 token 不合法", logger.String("ip", ctx.ClientIP()), logger.Error(err))
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, Result{Code: 4, Msg: "业务 token 不合法"})
+		return Result{}, err
+	case errors.Is(err, template.ErrUnknownBiz), errors.Is(err, template.ErrArgsMismatch):
+		return Result{Code: 4, Msg: err.Error()}, nil
+	case errors.As(err, &lerr):
+		// 把限流的维度带回去，客户端还原成 LimitedError
+		return Result{Code: 6, Msg: "短信发送太频繁", Data: lerr.Scope}, nil
+	default:
+		h.l.Error("短信网关发送失败", logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
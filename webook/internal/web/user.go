@@ -3,13 +3,16 @@ package web
 import (
 	"errors"
 	regexp "github.com/dlclark/regexp2"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/zmsocc/practice/webook/internal/domain"
 	"github.com/zmsocc/practice/webook/internal/service"
 	"github.com/zmsocc/practice/webook/internal/web/ijwt"
 	"github.com/zmsocc/practice/webook/pkg/ginx"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"github.com/zmsocc/practice/webook/pkg/mask"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -19,29 +22,42 @@ const (
 	emailRegexpPattern    = "^[a-zA-Z0-9_-]+@[a-zA-Z0-9_-]+(\\.[a-zA-Z0-9_-]+)+$"
 	passwordRegexpPattern = "^(?=.*[A-Za-z])(?=.*\\d)(?=.*[$@$!%*#?&])[A-Za-z\\d$@$!%*#?&]{8,}$" // 只能由数字组成
 	biz                   = "login"
+	bizResetPassword      = "reset_password"
+	bizBindPhone          = "bind_phone"
+	bizBindEmail          = "bind_email"
+
+	loginLogPageDefaultLimit = 20
+	loginLogPageMaxLimit     = 100
 )
 
 // ^\d{1,9}$
 
-var (
-	AtPrivateKey = ijwt.AtPrivateKey
-)
-
 type UserHandler struct {
 	svc            service.UserService
 	emailRegexp    *regexp.Regexp
 	passwordRegexp *regexp.Regexp
 	userHdl        ijwt.Handler
 	codeSvc        service.CodeService
+	attemptSvc     service.LoginAttemptService
+	loginLogSvc    service.LoginLogService
+	captchaSvc     service.CaptchaService
+	l              logger.Logger
 }
 
-func NewUserHandler(svc service.UserService, userHdl ijwt.Handler, codeSvc service.CodeService) *UserHandler {
+func NewUserHandler(svc service.UserService, userHdl ijwt.Handler,
+	codeSvc service.CodeService, attemptSvc service.LoginAttemptService,
+	loginLogSvc service.LoginLogService, captchaSvc service.CaptchaService,
+	l logger.Logger) *UserHandler {
 	return &UserHandler{
 		svc:            svc,
 		emailRegexp:    regexp.MustCompile(emailRegexpPattern, regexp.None),
 		passwordRegexp: regexp.MustCompile(passwordRegexpPattern, regexp.None),
 		userHdl:        userHdl,
 		codeSvc:        codeSvc,
+		attemptSvc:     attemptSvc,
+		loginLogSvc:    loginLogSvc,
+		captchaSvc:     captchaSvc,
+		l:              l,
 	}
 }
 
@@ -49,12 +65,36 @@ func (h *UserHandler) RegisterRoutes(server *gin.Engine) {
 	ug := server.Group("/users")
 	ug.POST("/signup", h.SignUp)
 	ug.POST("/login", ginx.WrapBody(h.LoginJWT))
-	ug.POST("/logout", h.Logout)
+	ug.POST("/login/2fa", ginx.WrapBody(h.LoginTwoFactor))
+	ug.POST("/logout", ginx.WrapBody(h.Logout))
 	ug.POST("/edit", h.jwtMiddleware(), ginx.WrapBody(h.EditJWT))
 	ug.GET("/profile", h.ProfileJWT)
 	ug.POST("/login_sms/code/send", h.SendSMSLoginCode)
 	ug.POST("/login_sms", ginx.WrapBody(h.SMSLogin))
 	ug.POST("/refresh_token", h.RefreshToken)
+
+	// 忘记密码
+	ug.POST("/password/forgot", ginx.WrapBody(h.ForgotPassword))
+	ug.POST("/password/reset", ginx.WrapBody(h.ResetPassword))
+	ug.POST("/password/change", ginx.WrapBody(h.ChangePassword))
+
+	// 两步验证
+	ug.POST("/2fa/totp/enroll", ginx.WrapBody(h.EnrollTotp))
+	ug.POST("/2fa/totp/activate", ginx.WrapBody(h.ActivateTotp))
+	ug.POST("/2fa/totp/disable", ginx.WrapBody(h.DisableTotp))
+
+	// 登录设备管理
+	ug.GET("/sessions", ginx.WrapBody(h.Sessions))
+	ug.DELETE("/sessions/:ssid", ginx.WrapBody(h.RevokeSession))
+	ug.DELETE("/sessions", ginx.WrapBody(h.RevokeAllSessions))
+	// 登录记录
+	ug.GET("/security/logins", ginx.WrapBody(h.LoginLogs))
+
+	// 绑定手机号和邮箱，已经被别的账号用了的话，可以选择合并
+	ug.POST("/bind/phone/code/send", ginx.WrapBody(h.SendBindPhoneCode))
+	ug.POST("/bind/phone", ginx.WrapBody(h.BindPhone))
+	ug.POST("/bind/email/code/send", ginx.WrapBody(h.SendBindEmailCode))
+	ug.POST("/bind/email", ginx.WrapBody(h.BindEmail))
 }
 
 func (h *UserHandler) SignUp(ctx *gin.Context) {
@@ -62,11 +102,26 @@ func (h *UserHandler) SignUp(ctx *gin.Context) {
 		Email           string `json:"email"`
 		Password        string `json:"password"`
 		ConfirmPassword string `json:"confirmPassword"`
+		CaptchaId       string `json:"captcha_id"`
+		CaptchaAnswer   string `json:"captcha_answer"`
+		InviteCode      string `json:"invite_code"`
 	}
 	var req SignUpReq
 	if err := ctx.Bind(&req); err != nil {
 		return
 	}
+	err := h.captchaSvc.Check(ctx, service.CaptchaSceneSignup, ctx.ClientIP(), req.CaptchaId, req.CaptchaAnswer)
+	switch {
+	case errors.Is(err, service.ErrCaptchaRequired):
+		ctx.String(http.StatusOK, "请输入图形验证码")
+		return
+	case errors.Is(err, service.ErrInvalidCaptcha):
+		ctx.String(http.StatusOK, "图形验证码错误")
+		return
+	case err != nil:
+		ctx.String(http.StatusOK, "系统错误")
+		return
+	}
 	isEmail, err := h.emailRegexp.MatchString(req.Email)
 	if err != nil {
 		ctx.String(http.StatusOK, "系统错误")
@@ -92,11 +147,19 @@ func (h *UserHandler) SignUp(ctx *gin.Context) {
 	err = h.svc.Signup(ctx, domain.User{
 		Email:    req.Email,
 		Password: req.Password,
-	})
+	}, req.InviteCode)
 	if errors.Is(err, service.ErrUserDuplicateEmail) {
 		ctx.String(http.StatusOK, "邮箱冲突")
 		return
 	}
+	if errors.Is(err, service.ErrInviteCodeRequired) {
+		ctx.String(http.StatusOK, "内测期间注册需要邀请码")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidInviteCode) {
+		ctx.String(http.StatusOK, "邀请码无效")
+		return
+	}
 	if err != nil {
 		ctx.String(http.StatusOK, "服务器异常，注册失败")
 		return
@@ -139,26 +202,126 @@ func (h *UserHandler) LoginJWT(ctx *gin.Context) (Result, error) {
 	if err := ctx.Bind(&req); err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
+	ip := ctx.ClientIP()
+	lock, err := h.attemptSvc.Check(ctx, req.Email, ip)
+	if errors.Is(err, service.ErrLoginLocked) {
+		h.recordLogin(ctx, domain.LoginLog{
+			Method:  domain.LoginMethodPassword,
+			Account: req.Email,
+			Reason:  "账号或 IP 已锁定",
+		})
+		return h.lockedResult(lock), nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
 	u, err := h.svc.Login(ctx, req.Email, req.Password)
 	if errors.Is(err, service.ErrInvalidUserOrEmail) {
+		h.recordLogin(ctx, domain.LoginLog{
+			Method:  domain.LoginMethodPassword,
+			Account: req.Email,
+			Reason:  "邮箱或密码错误",
+		})
+		lock, er := h.attemptSvc.Fail(ctx, req.Email, ip)
+		if er != nil {
+			h.l.Error("记录登录失败次数失败", logger.Error(er))
+		}
+		if lock > 0 {
+			return h.lockedResult(lock), nil
+		}
 		return Result{Code: 4, Msg: "无效的邮箱或密码"}, nil
 	}
+	if res, ok := userStatusResult(u, err); ok {
+		h.recordLogin(ctx, domain.LoginLog{
+			Uid:     u.Id,
+			Method:  domain.LoginMethodPassword,
+			Account: req.Email,
+			Reason:  err.Error(),
+		})
+		return res, nil
+	}
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
+	if err = h.attemptSvc.Unlock(ctx, req.Email); err != nil {
+		h.l.Error("清除登录失败次数失败", logger.Error(err))
+	}
+	if u.TotpEnabled {
+		// 开启了两步验证，先不发真正的 token
+		token, err := h.userHdl.NewTwoFactorToken(ctx, u.Id)
+		if err != nil {
+			return Result{Code: 5, Msg: "系统错误"}, nil
+		}
+		return Result{Msg: "请输入两步验证码", Data: TwoFactorVO{Need2FA: true, Token: token}}, nil
+	}
 	if err = h.userHdl.SetLoginToken(ctx, u.Id); err != nil {
 		return Result{Msg: "系统错误"}, nil
 	}
+	h.recordLogin(ctx, domain.LoginLog{
+		Uid:     u.Id,
+		Method:  domain.LoginMethodPassword,
+		Account: req.Email,
+		Success: true,
+	})
 	return Result{Msg: "登录成功"}, nil
 }
 
-func (h *UserHandler) Logout(ctx *gin.Context) {
-	sess := sessions.Default(ctx)
-	sess.Options(sessions.Options{
-		MaxAge: -1,
+// lockedResult 账号或者 IP 被临时锁定，Data 是还要等多少秒
+func (h *UserHandler) lockedResult(lock time.Duration) Result {
+	return Result{
+		Code: 7,
+		Msg:  "登录失败次数太多，账号暂时锁定，请稍后再试",
+		Data: int64(math.Ceil(lock.Seconds())),
+	}
+}
+
+// LoginTwoFactor 登录的第二步，提交验证器上的验证码或者恢复码
+func (h *UserHandler) LoginTwoFactor(ctx *gin.Context) (Result, error) {
+	type LoginTwoFactorReq struct {
+		Token string `json:"token"`
+		Code  string `json:"code"`
+	}
+	var req LoginTwoFactorReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	tc, err := h.userHdl.ParseTwoFactorToken(ctx, req.Token)
+	if errors.Is(err, ijwt.ErrTooManyAttempts) {
+		return Result{Code: 6, Msg: "验证太频繁，请重新登录"}, nil
+	}
+	if err != nil {
+		return Result{Code: 4, Msg: "登录已过期，请重新登录"}, nil
+	}
+	err = h.svc.VerifyTwoFactor(ctx, tc.Uid, req.Code)
+	if errors.Is(err, service.ErrInvalidTotpCode) {
+		h.recordLogin(ctx, domain.LoginLog{
+			Uid:    tc.Uid,
+			Method: domain.LoginMethodTotp,
+			Reason: "两步验证码错误",
+		})
+		return Result{Code: 4, Msg: "两步验证码错误"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if err = h.userHdl.SetLoginToken(ctx, tc.Uid); err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	h.recordLogin(ctx, domain.LoginLog{
+		Uid:     tc.Uid,
+		Method:  domain.LoginMethodTotp,
+		Success: true,
 	})
-	sess.Save()
-	ctx.JSON(http.StatusOK, Result{Msg: "退出登录成功"})
+	return Result{Msg: "登录成功"}, nil
+}
+
+// Logout 清掉响应头里面的 token，并且让当前设备的 session 失效，refresh_token 也就不能再用了
+func (h *UserHandler) Logout(ctx *gin.Context) (Result, error) {
+	if err := h.userHdl.ClearToken(ctx); err != nil {
+		h.l.Error("退出登录失败", logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "退出登录成功"}, nil
 }
 
 func (h *UserHandler) EditJWT(ctx *gin.Context) (Result, error) {
@@ -196,10 +359,15 @@ func (h *UserHandler) EditJWT(ctx *gin.Context) (Result, error) {
 
 func (h *UserHandler) ProfileJWT(ctx *gin.Context) {
 	type ProfileJWTReq struct {
+		// 手机号和邮箱都是脱敏之后的
 		Email    string `json:"email"`
+		Phone    string `json:"phone"`
 		Nickname string `json:"nickname"`
 		Birthday string `json:"birthday"`
 		AboutMe  string `json:"about_me"`
+		AvatarVO
+		// 0 正常，1 禁言，2 暂停，3 封禁
+		Status uint8 `json:"status"`
 	}
 	uc := ctx.MustGet("users").(ijwt.UserClaims)
 	u, err := h.svc.Profile(ctx, uc.Uid)
@@ -208,16 +376,24 @@ func (h *UserHandler) ProfileJWT(ctx *gin.Context) {
 		return
 	}
 	ctx.JSON(http.StatusOK, ProfileJWTReq{
-		Email:    u.Email,
+		Email:    mask.Email(u.Email),
+		Phone:    mask.Phone(u.Phone),
 		Nickname: u.Nickname,
 		Birthday: u.Birthday.Format(time.DateOnly),
 		AboutMe:  u.AboutMe,
+		AvatarVO: AvatarVO{
+			Avatar:      u.Avatar,
+			AvatarThumb: u.AvatarThumb,
+		},
+		Status: u.Status.ToUint8(),
 	})
 }
 
 func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context) {
 	type SendSMSLoginCodeReq struct {
-		Phone string `json:"phone"`
+		Phone         string `json:"phone"`
+		CaptchaId     string `json:"captcha_id"`
+		CaptchaAnswer string `json:"captcha_answer"`
 	}
 	var req SendSMSLoginCodeReq
 	if err := ctx.Bind(&req); err != nil {
@@ -228,13 +404,27 @@ func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, Result{Code: 4, Msg: "请输入手机号码"})
 		return
 	}
-	err := h.codeSvc.Send(ctx, biz, req.Phone)
+	err := h.captchaSvc.Check(ctx, service.CaptchaSceneSMSLogin, ctx.ClientIP(), req.CaptchaId, req.CaptchaAnswer)
+	if res, ok := captchaResult(err); ok {
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
+		return
+	}
+	err = h.codeSvc.Send(ctx, biz, req.Phone)
 	switch {
 	case err == nil:
 		ctx.JSON(http.StatusOK, Result{Msg: "验证码发送成功"})
 	case errors.Is(err, service.ErrCodeSendTooMany):
 		ctx.JSON(http.StatusOK, Result{Code: 4, Msg: "验证码发送太频繁, 请稍后再试"})
+	case errors.Is(err, service.ErrCodeSendTooManyToday):
+		ctx.JSON(http.StatusOK, Result{Code: 6, Msg: "今天发送验证码的次数太多了，请明天再试"})
+	case errors.Is(err, service.ErrSMSBusy):
+		ctx.JSON(http.StatusOK, Result{Code: 6, Msg: "短信服务繁忙，请稍后再试"})
 	default:
+		h.l.Error("发送短信验证码失败", logger.Phone("phone", req.Phone), logger.Error(err))
 		ctx.JSON(http.StatusOK, Result{Msg: "系统错误"})
 	}
 }
@@ -243,6 +433,8 @@ func (h *UserHandler) SMSLogin(ctx *gin.Context) (Result, error) {
 	type SMSLoginReq struct {
 		Phone string `json:"phone"`
 		Code  string `json:"code"`
+		// 第一次登录会自动注册，开启了邀请注册的时候需要
+		InviteCode string `json:"invite_code"`
 	}
 	var req SMSLoginReq
 	if err := ctx.Bind(&req); err != nil {
@@ -251,53 +443,605 @@ func (h *UserHandler) SMSLogin(ctx *gin.Context) (Result, error) {
 	if req.Code == "" {
 		return Result{Code: 4, Msg: "验证码为空，请输入验证码"}, nil
 	}
+	// 验证码校验通过就被用掉了，所以第一次登录要先检查邀请码
+	err := h.svc.CheckInvite(ctx, req.Phone, req.InviteCode)
+	if res, ok := inviteResult(err); ok {
+		return res, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
 	ok, err := h.codeSvc.Verify(ctx, biz, req.Phone, req.Code)
 	if errors.Is(err, service.ErrCodeVerifyTooMany) {
 		// 可能有人搞你
+		h.recordLogin(ctx, domain.LoginLog{
+			Method:  domain.LoginMethodSMS,
+			Account: req.Phone,
+			Reason:  "验证太频繁",
+		})
 		return Result{Code: 6, Msg: "验证太频繁，请稍后再试"}, nil
 	}
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
 	if !ok {
+		h.recordLogin(ctx, domain.LoginLog{
+			Method:  domain.LoginMethodSMS,
+			Account: req.Phone,
+			Reason:  "验证码错误",
+		})
 		return Result{Code: 4, Msg: "验证码有误"}, nil
 	}
-	user, err := h.svc.FindOrCreate(ctx, req.Phone)
+	user, err := h.svc.FindOrCreate(ctx, req.Phone, req.InviteCode)
+	// 前面检查过了，这里只有并发的时候邀请码被别人用完了才会出现
+	if res, ok := inviteResult(err); ok {
+		return res, nil
+	}
+	if res, ok := userStatusResult(user, err); ok {
+		h.recordLogin(ctx, domain.LoginLog{
+			Uid:     user.Id,
+			Method:  domain.LoginMethodSMS,
+			Account: req.Phone,
+			Reason:  err.Error(),
+		})
+		return res, nil
+	}
 	if err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
+	// 短信验证通过，证明了是本人，顺便解除密码登录的锁定
+	if err = h.attemptSvc.Unlock(ctx, user.Email); err != nil {
+		h.l.Error("解除登录锁定失败", logger.Error(err))
+	}
 	if err = h.userHdl.SetLoginToken(ctx, user.Id); err != nil {
 		return Result{Code: 5, Msg: "系统错误"}, nil
 	}
+	h.recordLogin(ctx, domain.LoginLog{
+		Uid:     user.Id,
+		Method:  domain.LoginMethodSMS,
+		Account: req.Phone,
+		Success: true,
+	})
 	return Result{Msg: "登陆成功"}, nil
 }
 
-// RefreshToken 可以同时刷新长短 token，用 redis 来记录是否有效，即 refresh_token 是一次性的
+// RefreshToken 同时刷新长短 token，用 redis 来记录是否有效，即 refresh_token 是一次性的
 func (h *UserHandler) RefreshToken(ctx *gin.Context) {
 	// 只有这个接口，拿出来的才是 refresh_token，其它地方都是 access_token
 	tokenStr := h.userHdl.ExtractToken(ctx)
-	var rc ijwt.RefreshClaims
-	token, err := jwt.ParseWithClaims(tokenStr, &rc, func(token *jwt.Token) (interface{}, error) {
-		return AtPrivateKey, nil
-	})
-	if err != nil || !token.Valid {
+	rc, err := h.userHdl.ParseRefreshToken(ctx, tokenStr)
+	if err != nil {
+		h.recordLogin(ctx, domain.LoginLog{
+			Method: domain.LoginMethodRefreshToken,
+			Reason: "refresh_token 无效",
+		})
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 	err = h.userHdl.CheckSession(ctx, rc.Ssid)
 	if err != nil {
+		h.recordLogin(ctx, domain.LoginLog{
+			Uid:    rc.Uid,
+			Method: domain.LoginMethodRefreshToken,
+			Reason: "session 已失效",
+		})
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	// 换一对新的 access_token 和 refresh_token
+	err = h.userHdl.RotateRefreshToken(ctx, rc)
+	if errors.Is(err, ijwt.ErrRefreshTokenReused) {
+		// 安全事件，refresh_token 很可能已经泄露，整个 session 已经被撤销
+		h.l.Warn("refresh_token 被重复使用，已撤销该 session",
+			logger.Int64("uid", rc.Uid),
+			logger.String("ssid", rc.Ssid),
+			logger.String("ip", ctx.ClientIP()),
+			logger.String("user_agent", ctx.Request.UserAgent()))
+		h.recordLogin(ctx, domain.LoginLog{
+			Uid:    rc.Uid,
+			Method: domain.LoginMethodRefreshToken,
+			Reason: "refresh_token 被重复使用",
+		})
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
-	// 搞个新的 access_token
-	err = h.userHdl.SetJWTToken(ctx, rc.Uid, rc.Ssid)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
+	h.recordLogin(ctx, domain.LoginLog{
+		Uid:     rc.Uid,
+		Method:  domain.LoginMethodRefreshToken,
+		Success: true,
+	})
+	_ = h.userHdl.TouchSession(ctx, rc.Uid, rc.Ssid)
 	ctx.JSON(http.StatusOK, Result{Msg: "刷新成功"})
 }
 
+// captchaResult 需要图形验证码或者图形验证码不对的时候返回 Code 9，前端弹出或者刷新验证码，其它错误返回 false
+func captchaResult(err error) (Result, bool) {
+	switch {
+	case errors.Is(err, service.ErrCaptchaRequired):
+		return Result{Code: 9, Msg: "请输入图形验证码"}, true
+	case errors.Is(err, service.ErrInvalidCaptcha):
+		return Result{Code: 9, Msg: "图形验证码错误"}, true
+	default:
+		return Result{}, false
+	}
+}
+
+// inviteResult 注册需要邀请码或者邀请码不能用的时候给前端的提示，其它错误返回 false
+func inviteResult(err error) (Result, bool) {
+	switch {
+	case errors.Is(err, service.ErrInviteCodeRequired):
+		return Result{Code: 4, Msg: "内测期间注册需要邀请码"}, true
+	case errors.Is(err, service.ErrInvalidInviteCode):
+		return Result{Code: 4, Msg: "邀请码无效"}, true
+	default:
+		return Result{}, false
+	}
+}
+
+// userStatusResult 账号被封禁、暂停或者禁言的时候给前端的提示，其它错误返回 false
+func userStatusResult(u domain.User, err error) (Result, bool) {
+	switch {
+	case errors.Is(err, service.ErrUserBanned):
+		return Result{Code: 4, Msg: "账号已被封禁"}, true
+	case errors.Is(err, service.ErrUserSuspended):
+		return Result{
+			Code: 4,
+			Msg:  "账号已被暂停使用，" + u.SuspendedUntil.Format(time.DateTime) + " 之后恢复",
+		}, true
+	case errors.Is(err, service.ErrUserMuted):
+		return Result{Code: 4, Msg: "你已被禁言，暂时不能发表文章、点赞和收藏"}, true
+	default:
+		return Result{}, false
+	}
+}
+
+// recordLogin 记录登录审计日志，IP 和设备从请求里面拿，在后台记录，不影响登录
+func (h *UserHandler) recordLogin(ctx *gin.Context, l domain.LoginLog) {
+	l.Ip = ctx.ClientIP()
+	l.UserAgent = ctx.Request.UserAgent()
+	h.loginLogSvc.Record(ctx, l)
+}
+
+// ForgotPassword 往注册邮箱发送重置密码的验证码
+func (h *UserHandler) ForgotPassword(ctx *gin.Context) (Result, error) {
+	type ForgotPasswordReq struct {
+		Email string `json:"email"`
+	}
+	var req ForgotPasswordReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	isEmail, err := h.emailRegexp.MatchString(req.Email)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if !isEmail {
+		return Result{Code: 4, Msg: "邮箱格式有误"}, nil
+	}
+	// 不管邮箱有没有注册，都返回一样的结果，避免被人拿来探测哪些邮箱注册过
+	const msg = "如果该邮箱已注册，验证码已发送"
+	_, err = h.svc.FindByEmail(ctx, req.Email)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return Result{Msg: msg}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	err = h.codeSvc.SendEmail(ctx, bizResetPassword, req.Email)
+	switch {
+	// 发送太频繁也不能单独提示，没注册的邮箱永远不会太频繁，一对比就知道注册过了
+	case err == nil, errors.Is(err, service.ErrCodeSendTooMany):
+		return Result{Msg: msg}, nil
+	default:
+		h.l.Error("发送重置密码验证码失败", logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+// ResetPassword 校验邮箱验证码之后重置密码，所有已登录的设备都会被踢下线
+func (h *UserHandler) ResetPassword(ctx *gin.Context) (Result, error) {
+	type ResetPasswordReq struct {
+		Email           string `json:"email"`
+		Code            string `json:"code"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirmPassword"`
+	}
+	var req ResetPasswordReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.Code == "" {
+		return Result{Code: 4, Msg: "验证码为空，请输入验证码"}, nil
+	}
+	if req.ConfirmPassword != req.Password {
+		return Result{Code: 4, Msg: "两次输入的密码不同"}, nil
+	}
+	isPassword, err := h.passwordRegexp.MatchString(req.Password)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if !isPassword {
+		return Result{Code: 4, Msg: "密码必须包含字母、数字、特殊字符，并且长度至少为8"}, nil
+	}
+	ok, err := h.codeSvc.Verify(ctx, bizResetPassword, req.Email, req.Code)
+	if errors.Is(err, service.ErrCodeVerifyTooMany) {
+		return Result{Code: 6, Msg: "验证太频繁，请稍后再试"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if !ok {
+		return Result{Code: 4, Msg: "验证码有误"}, nil
+	}
+	u, err := h.svc.ResetPassword(ctx, req.Email, req.Password)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return Result{Code: 4, Msg: "验证码有误"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if err = h.userHdl.RevokeAllSessions(ctx, u.Id); err != nil {
+		// 密码已经改好了，踢下线失败只记录一下
+		h.l.Error("重置密码后退出所有设备失败",
+			logger.Int64("uid", u.Id), logger.Error(err))
+	}
+	if err = h.attemptSvc.Unlock(ctx, req.Email); err != nil {
+		h.l.Error("解除登录锁定失败", logger.Error(err))
+	}
+	return Result{Msg: "密码重置成功，请重新登录"}, nil
+}
+
+// ChangePassword 修改密码，除了当前设备，其它设备都会被踢下线
+func (h *UserHandler) ChangePassword(ctx *gin.Context) (Result, error) {
+	type ChangePasswordReq struct {
+		OldPassword     string `json:"oldPassword"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirmPassword"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req ChangePasswordReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.ConfirmPassword != req.Password {
+		return Result{Code: 4, Msg: "两次输入的密码不同"}, nil
+	}
+	isPassword, err := h.passwordRegexp.MatchString(req.Password)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if !isPassword {
+		return Result{Code: 4, Msg: "密码必须包含字母、数字、特殊字符，并且长度至少为8"}, nil
+	}
+	err = h.svc.ChangePassword(ctx, uc.Uid, req.OldPassword, req.Password)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidOldPassword):
+		return Result{Code: 4, Msg: "原密码错误"}, nil
+	case errors.Is(err, service.ErrUserNotFound):
+		return Result{Code: 4, Msg: "用户不存在"}, nil
+	default:
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if err = h.userHdl.RevokeAllSessions(ctx, uc.Uid, uc.Ssid); err != nil {
+		h.l.Error("修改密码后退出其它设备失败",
+			logger.Int64("uid", uc.Uid), logger.Error(err))
+	}
+	return Result{Msg: "密码修改成功"}, nil
+}
+
+func (h *UserHandler) EnrollTotp(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	uri, err := h.svc.EnrollTotp(ctx, uc.Uid)
+	if errors.Is(err, service.ErrTotpAlreadyEnabled) {
+		return Result{Code: 4, Msg: "两步验证已经开启"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: TotpEnrollVO{URI: uri}}, nil
+}
+
+func (h *UserHandler) ActivateTotp(ctx *gin.Context) (Result, error) {
+	type ActivateTotpReq struct {
+		Code string `json:"code"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req ActivateTotpReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if res, locked := h.checkTwoFactorLock(ctx, uc.Uid); locked {
+		return res, nil
+	}
+	codes, err := h.svc.ActivateTotp(ctx, uc.Uid, req.Code)
+	switch {
+	case err == nil:
+		h.unlockTwoFactor(ctx, uc.Uid)
+		// 恢复码只展示这一次
+		return Result{Msg: "两步验证已开启，请妥善保存恢复码", Data: codes}, nil
+	case errors.Is(err, service.ErrTotpAlreadyEnabled):
+		return Result{Code: 4, Msg: "两步验证已经开启"}, nil
+	case errors.Is(err, service.ErrTotpNotEnrolled):
+		return Result{Code: 4, Msg: "请先绑定验证器"}, nil
+	case errors.Is(err, service.ErrInvalidTotpCode):
+		return h.failTwoFactor(ctx, uc.Uid), nil
+	default:
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+func (h *UserHandler) DisableTotp(ctx *gin.Context) (Result, error) {
+	type DisableTotpReq struct {
+		Code string `json:"code"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req DisableTotpReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if res, locked := h.checkTwoFactorLock(ctx, uc.Uid); locked {
+		return res, nil
+	}
+	err := h.svc.DisableTotp(ctx, uc.Uid, req.Code)
+	switch {
+	case err == nil:
+		h.unlockTwoFactor(ctx, uc.Uid)
+		return Result{Msg: "两步验证已关闭"}, nil
+	case errors.Is(err, service.ErrTotpNotEnrolled):
+		return Result{Code: 4, Msg: "两步验证没有开启"}, nil
+	case errors.Is(err, service.ErrInvalidTotpCode):
+		return h.failTwoFactor(ctx, uc.Uid), nil
+	default:
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+// checkTwoFactorLock 开启、关闭两步验证的时候验证码错太多次就锁定，locked 为 true 直接返回 res
+func (h *UserHandler) checkTwoFactorLock(ctx *gin.Context, uid int64) (res Result, locked bool) {
+	lock, err := h.attemptSvc.CheckTwoFactor(ctx, uid)
+	if errors.Is(err, service.ErrTwoFactorLocked) {
+		return h.twoFactorLockedResult(lock), true
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, true
+	}
+	return Result{}, false
+}
+
+func (h *UserHandler) failTwoFactor(ctx *gin.Context, uid int64) Result {
+	lock, err := h.attemptSvc.FailTwoFactor(ctx, uid)
+	if err != nil {
+		h.l.Error("记录两步验证失败次数失败", logger.Int64("uid", uid), logger.Error(err))
+	}
+	if lock > 0 {
+		return h.twoFactorLockedResult(lock)
+	}
+	return Result{Code: 4, Msg: "两步验证码错误"}
+}
+
+func (h *UserHandler) unlockTwoFactor(ctx *gin.Context, uid int64) {
+	if err := h.attemptSvc.UnlockTwoFactor(ctx, uid); err != nil {
+		h.l.Error("清除两步验证失败次数失败", logger.Int64("uid", uid), logger.Error(err))
+	}
+}
+
+// twoFactorLockedResult Data 是还要等多少秒
+func (h *UserHandler) twoFactorLockedResult(lock time.Duration) Result {
+	return Result{
+		Code: 7,
+		Msg:  "两步验证码错误次数太多，请稍后再试",
+		Data: int64(math.Ceil(lock.Seconds())),
+	}
+}
+
+func (h *UserHandler) Sessions(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	sessions, err := h.userHdl.ListSessions(ctx, uc.Uid)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{
+		Data: slice.Map[ijwt.Session, SessionVO](sessions, func(idx int, src ijwt.Session) SessionVO {
+			return SessionVO{
+				Ssid:     src.Ssid,
+				Device:   src.Device,
+				IP:       src.IP,
+				Ctime:    src.Ctime.Format(time.DateTime),
+				LastSeen: src.LastSeen.Format(time.DateTime),
+				Current:  src.Ssid == uc.Ssid,
+			}
+		}),
+	}, nil
+}
+
+// RevokeSession 踢掉某一台设备
+func (h *UserHandler) RevokeSession(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	ssid := ctx.Param("ssid")
+	err := h.userHdl.RevokeSession(ctx, uc.Uid, ssid)
+	if errors.Is(err, ijwt.ErrSessionNotFound) {
+		return Result{Code: 4, Msg: "设备不存在或已下线"}, nil
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "下线成功"}, nil
+}
+
+// RevokeAllSessions 退出所有设备，包括当前这个
+func (h *UserHandler) RevokeAllSessions(ctx *gin.Context) (Result, error) {
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	if err := h.userHdl.RevokeAllSessions(ctx, uc.Uid); err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Msg: "已退出所有设备"}, nil
+}
+
+// LoginLogs 自己的登录记录，按时间倒序
+func (h *UserHandler) LoginLogs(ctx *gin.Context) (Result, error) {
+	offset, limit, ok := pageQuery(ctx, loginLogPageDefaultLimit, loginLogPageMaxLimit)
+	if !ok {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	logs, err := h.loginLogSvc.List(ctx, uc.Uid, offset, limit)
+	if err != nil {
+		h.l.Error("查询登录记录失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	return Result{Data: toLoginLogVOs(logs)}, nil
+}
+
+func toLoginLogVOs(logs []domain.LoginLog) []LoginLogVO {
+	return slice.Map[domain.LoginLog, LoginLogVO](logs, func(idx int, src domain.LoginLog) LoginLogVO {
+		return LoginLogVO{
+			Method:    src.Method,
+			Account:   mask.Account(src.Account),
+			Ip:        src.Ip,
+			UserAgent: src.UserAgent,
+			Success:   src.Success,
+			Reason:    src.Reason,
+			Ctime:     src.Ctime.Format(time.DateTime),
+		}
+	})
+}
+
+func (h *UserHandler) SendBindPhoneCode(ctx *gin.Context) (Result, error) {
+	type SendBindPhoneCodeReq struct {
+		Phone string `json:"phone"`
+	}
+	var req SendBindPhoneCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if req.Phone == "" {
+		return Result{Code: 4, Msg: "请输入手机号码"}, nil
+	}
+	return h.sendCodeResult(h.codeSvc.Send(ctx, bizBindPhone, req.Phone))
+}
+
+func (h *UserHandler) SendBindEmailCode(ctx *gin.Context) (Result, error) {
+	type SendBindEmailCodeReq struct {
+		Email string `json:"email"`
+	}
+	var req SendBindEmailCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	isEmail, err := h.emailRegexp.MatchString(req.Email)
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if !isEmail {
+		return Result{Code: 4, Msg: "邮箱格式有误"}, nil
+	}
+	return h.sendCodeResult(h.codeSvc.SendEmail(ctx, bizBindEmail, req.Email))
+}
+
+func (h *UserHandler) sendCodeResult(err error) (Result, error) {
+	switch {
+	case err == nil:
+		return Result{Msg: "验证码发送成功"}, nil
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		return Result{Code: 4, Msg: "验证码发送太频繁, 请稍后再试"}, nil
+	case errors.Is(err, service.ErrCodeSendTooManyToday):
+		return Result{Code: 6, Msg: "今天发送验证码的次数太多了，请明天再试"}, nil
+	case errors.Is(err, service.ErrSMSBusy):
+		return Result{Code: 6, Msg: "短信服务繁忙，请稍后再试"}, nil
+	default:
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+}
+
+// BindPhone 绑定手机号。手机号已经注册过别的账号的时候返回 Code 8，
+// 前端提示用户之后，带上 merge=true 再请求一次就会把那个账号合并进来
+func (h *UserHandler) BindPhone(ctx *gin.Context) (Result, error) {
+	type BindPhoneReq struct {
+		Phone string `json:"phone"`
+		Code  string `json:"code"`
+		Merge bool   `json:"merge"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req BindPhoneReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if res, ok := h.verifyBindCode(ctx, bizBindPhone, req.Phone, req.Code); !ok {
+		return res, nil
+	}
+	merged, err := h.svc.BindPhone(ctx, uc.Uid, req.Phone, req.Merge)
+	return h.bindResult(ctx, uc.Uid, merged, err)
+}
+
+// BindEmail 同 BindPhone
+func (h *UserHandler) BindEmail(ctx *gin.Context) (Result, error) {
+	type BindEmailReq struct {
+		Email string `json:"email"`
+		Code  string `json:"code"`
+		Merge bool   `json:"merge"`
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	var req BindEmailReq
+	if err := ctx.Bind(&req); err != nil {
+		return Result{Code: 4, Msg: "参数错误"}, nil
+	}
+	if res, ok := h.verifyBindCode(ctx, bizBindEmail, req.Email, req.Code); !ok {
+		return res, nil
+	}
+	merged, err := h.svc.BindEmail(ctx, uc.Uid, req.Email, req.Merge)
+	return h.bindResult(ctx, uc.Uid, merged, err)
+}
+
+// verifyBindCode 校验验证码，证明手机号或者邮箱是自己的。
+// 注意验证码用过一次就失效了，所以冲突之后再带 merge 请求，要重新发验证码
+func (h *UserHandler) verifyBindCode(ctx *gin.Context, biz, target, code string) (Result, bool) {
+	if code == "" {
+		return Result{Code: 4, Msg: "验证码为空，请输入验证码"}, false
+	}
+	ok, err := h.codeSvc.Verify(ctx, biz, target, code)
+	if errors.Is(err, service.ErrCodeVerifyTooMany) {
+		return Result{Code: 6, Msg: "验证太频繁，请稍后再试"}, false
+	}
+	if err != nil {
+		return Result{Code: 5, Msg: "系统错误"}, false
+	}
+	if !ok {
+		return Result{Code: 4, Msg: "验证码有误"}, false
+	}
+	return Result{}, true
+}
+
+func (h *UserHandler) bindResult(ctx *gin.Context, uid, merged int64, err error) (Result, error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrPhoneAlreadySet):
+		return Result{Code: 4, Msg: "当前账号已经绑定手机号"}, nil
+	case errors.Is(err, service.ErrEmailAlreadySet):
+		return Result{Code: 4, Msg: "当前账号已经绑定邮箱"}, nil
+	case errors.Is(err, service.ErrAccountConflict):
+		return Result{Code: 8, Msg: "已被其他账号使用，是否合并账号"}, nil
+	default:
+		h.l.Error("绑定失败", logger.Int64("uid", uid), logger.Error(err))
+		return Result{Code: 5, Msg: "系统错误"}, nil
+	}
+	if merged == 0 {
+		return Result{Msg: "绑定成功"}, nil
+	}
+	// 被合并的账号已经没用了，上面登录的设备都踢掉
+	if err = h.userHdl.RevokeAllSessions(ctx, merged); err != nil {
+		h.l.Error("合并账号后退出被合并账号的设备失败",
+			logger.Int64("uid", uid),
+			logger.Int64("merged", merged),
+			logger.Error(err))
+	}
+	return Result{Msg: "账号合并成功"}, nil
+}
+
 func (h *UserHandler) jwtMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 1.从请求中获取 token
@@ -0,0 +1,607 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
+	svcmocks "github.com/zmsocc/practice/webook/internal/service/mocks"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	ijwtmocks "github.com/zmsocc/practice/webook/internal/web/ijwt/mocks"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// userHandlerMocks UserHandler 依赖的所有服务，用例里面只给用得到的设置 EXPECT
+type userHandlerMocks struct {
+	svc      *svcmocks.MockUserService
+	jwtHdl   *ijwtmocks.MockHandler
+	codeSvc  *svcmocks.MockCodeService
+	attempt  *svcmocks.MockLoginAttemptService
+	loginLog *svcmocks.MockLoginLogService
+	captcha  *svcmocks.MockCaptchaService
+}
+
+// serveUser 发一个 JSON 请求给 UserHandler，uid 大于 0 的时候模拟已经登录
+func serveUser(t *testing.T, mock func(m userHandlerMocks), uid int64,
+	method, path, body string) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	m := userHandlerMocks{
+		svc:      svcmocks.NewMockUserService(ctrl),
+		jwtHdl:   ijwtmocks.NewMockHandler(ctrl),
+		codeSvc:  svcmocks.NewMockCodeService(ctrl),
+		attempt:  svcmocks.NewMockLoginAttemptService(ctrl),
+		loginLog: svcmocks.NewMockLoginLogService(ctrl),
+		captcha:  svcmocks.NewMockCaptchaService(ctrl),
+	}
+	if mock != nil {
+		mock(m)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	if uid > 0 {
+		server.Use(func(ctx *gin.Context) {
+			ctx.Set("users", ijwt.UserClaims{Uid: uid, Ssid: "ssid"})
+		})
+	}
+	h := NewUserHandler(m.svc, m.jwtHdl, m.codeSvc, m.attempt, m.loginLog, m.captcha,
+		logger.NewNopLogger())
+	h.RegisterRoutes(server)
+
+	req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
+
+func decodeResult(t *testing.T, resp *httptest.ResponseRecorder) Result {
+	require.Equal(t, http.StatusOK, resp.Code)
+	var res Result
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	return res
+}
+
+func TestUserHandler_ForgotPassword(t *testing.T) {
+	const sentMsg = "如果该邮箱已注册，验证码已发送"
+	testCases := []struct {
+		name    string
+		mock    func(m userHandlerMocks)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "发送成功",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{Id: 1}, nil)
+				m.codeSvc.EXPECT().SendEmail(gomock.Any(), bizResetPassword, "123@qq.com").
+					Return(nil)
+			},
+			reqBody: `{"email":"123@qq.com"}`,
+			wantRes: Result{Msg: sentMsg},
+		},
+		{
+			name: "邮箱没注册，结果一样",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{}, service.ErrUserNotFound)
+			},
+			reqBody: `{"email":"123@qq.com"}`,
+			wantRes: Result{Msg: sentMsg},
+		},
+		{
+			name: "发送太频繁，结果也一样",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{Id: 1}, nil)
+				m.codeSvc.EXPECT().SendEmail(gomock.Any(), bizResetPassword, "123@qq.com").
+					Return(service.ErrCodeSendTooMany)
+			},
+			reqBody: `{"email":"123@qq.com"}`,
+			wantRes: Result{Msg: sentMsg},
+		},
+		{
+			name:    "邮箱格式不对",
+			reqBody: `{"email":"123"}`,
+			wantRes: Result{Code: 4, Msg: "邮箱格式有误"},
+		},
+		{
+			name: "发送失败",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+					Return(domain.User{Id: 1}, nil)
+				m.codeSvc.EXPECT().SendEmail(gomock.Any(), bizResetPassword, "123@qq.com").
+					Return(errors.New("mock error"))
+			},
+			reqBody: `{"email":"123@qq.com"}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 0, http.MethodPost, "/users/password/forgot", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_ResetPassword(t *testing.T) {
+	const body = `{"email":"123@qq.com","code":"123456","password":"hello#world123","confirmPassword":"hello#world123"}`
+	testCases := []struct {
+		name    string
+		mock    func(m userHandlerMocks)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "重置成功，踢掉所有设备",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizResetPassword, "123@qq.com", "123456").
+					Return(true, nil)
+				m.svc.EXPECT().ResetPassword(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{Id: 1}, nil)
+				m.jwtHdl.EXPECT().RevokeAllSessions(gomock.Any(), int64(1)).Return(nil)
+				m.attempt.EXPECT().Unlock(gomock.Any(), "123@qq.com").Return(nil)
+			},
+			reqBody: body,
+			wantRes: Result{Msg: "密码重置成功，请重新登录"},
+		},
+		{
+			name: "验证码不对",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizResetPassword, "123@qq.com", "123456").
+					Return(false, nil)
+			},
+			reqBody: body,
+			wantRes: Result{Code: 4, Msg: "验证码有误"},
+		},
+		{
+			name: "验证太频繁",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizResetPassword, "123@qq.com", "123456").
+					Return(false, service.ErrCodeVerifyTooMany)
+			},
+			reqBody: body,
+			wantRes: Result{Code: 6, Msg: "验证太频繁，请稍后再试"},
+		},
+		{
+			name: "用户不存在，和验证码不对一样",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizResetPassword, "123@qq.com", "123456").
+					Return(true, nil)
+				m.svc.EXPECT().ResetPassword(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{}, service.ErrUserNotFound)
+			},
+			reqBody: body,
+			wantRes: Result{Code: 4, Msg: "验证码有误"},
+		},
+		{
+			name:    "两次密码不一样",
+			reqBody: `{"email":"123@qq.com","code":"123456","password":"hello#world123","confirmPassword":"hello#world124"}`,
+			wantRes: Result{Code: 4, Msg: "两次输入的密码不同"},
+		},
+		{
+			name:    "密码太简单",
+			reqBody: `{"email":"123@qq.com","code":"123456","password":"hello","confirmPassword":"hello"}`,
+			wantRes: Result{Code: 4, Msg: "密码必须包含字母、数字、特殊字符，并且长度至少为8"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 0, http.MethodPost, "/users/password/reset", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	const body = `{"oldPassword":"hello#world123","password":"new#world123","confirmPassword":"new#world123"}`
+	testCases := []struct {
+		name    string
+		mock    func(m userHandlerMocks)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "修改成功，踢掉其它设备",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().ChangePassword(gomock.Any(), int64(1), "hello#world123", "new#world123").
+					Return(nil)
+				m.jwtHdl.EXPECT().RevokeAllSessions(gomock.Any(), int64(1), "ssid").Return(nil)
+			},
+			reqBody: body,
+			wantRes: Result{Msg: "密码修改成功"},
+		},
+		{
+			name: "踢设备失败，密码还是改了",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().ChangePassword(gomock.Any(), int64(1), "hello#world123", "new#world123").
+					Return(nil)
+				m.jwtHdl.EXPECT().RevokeAllSessions(gomock.Any(), int64(1), "ssid").
+					Return(errors.New("mock error"))
+			},
+			reqBody: body,
+			wantRes: Result{Msg: "密码修改成功"},
+		},
+		{
+			name: "原密码错误",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().ChangePassword(gomock.Any(), int64(1), "hello#world123", "new#world123").
+					Return(service.ErrInvalidOldPassword)
+			},
+			reqBody: body,
+			wantRes: Result{Code: 4, Msg: "原密码错误"},
+		},
+		{
+			name: "用户不存在",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().ChangePassword(gomock.Any(), int64(1), "hello#world123", "new#world123").
+					Return(service.ErrUserNotFound)
+			},
+			reqBody: body,
+			wantRes: Result{Code: 4, Msg: "用户不存在"},
+		},
+		{
+			name: "系统错误",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().ChangePassword(gomock.Any(), int64(1), "hello#world123", "new#world123").
+					Return(errors.New("mock error"))
+			},
+			reqBody: body,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name:    "两次密码不一样",
+			reqBody: `{"oldPassword":"hello#world123","password":"new#world123","confirmPassword":"new#world124"}`,
+			wantRes: Result{Code: 4, Msg: "两次输入的密码不同"},
+		},
+		{
+			name:    "新密码太简单",
+			reqBody: `{"oldPassword":"hello#world123","password":"hello","confirmPassword":"hello"}`,
+			wantRes: Result{Code: 4, Msg: "密码必须包含字母、数字、特殊字符，并且长度至少为8"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 1, http.MethodPost, "/users/password/change", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_ActivateTotp(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(m userHandlerMocks)
+
+		wantRes Result
+	}{
+		{
+			name: "开启成功",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+				m.svc.EXPECT().ActivateTotp(gomock.Any(), int64(1), "123456").
+					Return([]string{"abcde-fghjk"}, nil)
+				m.attempt.EXPECT().UnlockTwoFactor(gomock.Any(), int64(1)).Return(nil)
+			},
+			wantRes: Result{Msg: "两步验证已开启，请妥善保存恢复码", Data: []any{"abcde-fghjk"}},
+		},
+		{
+			name: "验证码错误",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+				m.svc.EXPECT().ActivateTotp(gomock.Any(), int64(1), "123456").
+					Return(nil, service.ErrInvalidTotpCode)
+				m.attempt.EXPECT().FailTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+			},
+			wantRes: Result{Code: 4, Msg: "两步验证码错误"},
+		},
+		{
+			name: "错太多次，锁定",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+				m.svc.EXPECT().ActivateTotp(gomock.Any(), int64(1), "123456").
+					Return(nil, service.ErrInvalidTotpCode)
+				m.attempt.EXPECT().FailTwoFactor(gomock.Any(), int64(1)).Return(time.Minute*5, nil)
+			},
+			wantRes: Result{Code: 7, Msg: "两步验证码错误次数太多，请稍后再试", Data: float64(300)},
+		},
+		{
+			name: "已经锁定，不校验验证码",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).
+					Return(time.Minute, service.ErrTwoFactorLocked)
+			},
+			wantRes: Result{Code: 7, Msg: "两步验证码错误次数太多，请稍后再试", Data: float64(60)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 1, http.MethodPost, "/users/2fa/totp/activate", `{"code":"123456"}`)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_DisableTotp(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(m userHandlerMocks)
+
+		wantRes Result
+	}{
+		{
+			name: "关闭成功",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+				m.svc.EXPECT().DisableTotp(gomock.Any(), int64(1), "123456").Return(nil)
+				m.attempt.EXPECT().UnlockTwoFactor(gomock.Any(), int64(1)).Return(nil)
+			},
+			wantRes: Result{Msg: "两步验证已关闭"},
+		},
+		{
+			name: "验证码错误",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+				m.svc.EXPECT().DisableTotp(gomock.Any(), int64(1), "123456").
+					Return(service.ErrInvalidTotpCode)
+				m.attempt.EXPECT().FailTwoFactor(gomock.Any(), int64(1)).Return(time.Duration(0), nil)
+			},
+			wantRes: Result{Code: 4, Msg: "两步验证码错误"},
+		},
+		{
+			name: "已经锁定",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).
+					Return(time.Minute, service.ErrTwoFactorLocked)
+			},
+			wantRes: Result{Code: 7, Msg: "两步验证码错误次数太多，请稍后再试", Data: float64(60)},
+		},
+		{
+			name: "检查锁定失败",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().CheckTwoFactor(gomock.Any(), int64(1)).
+					Return(time.Duration(0), errors.New("mock error"))
+			},
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 1, http.MethodPost, "/users/2fa/totp/disable", `{"code":"123456"}`)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_LoginJWTLockout(t *testing.T) {
+	const body = `{"email":"123@qq.com","password":"hello#world123"}`
+	testCases := []struct {
+		name string
+		mock func(m userHandlerMocks)
+
+		wantRes Result
+	}{
+		{
+			name: "登录成功，解除账号锁定",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().Check(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Duration(0), nil)
+				m.svc.EXPECT().Login(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{Id: 1}, nil)
+				m.attempt.EXPECT().Unlock(gomock.Any(), "123@qq.com").Return(nil)
+				m.jwtHdl.EXPECT().SetLoginToken(gomock.Any(), int64(1)).Return(nil)
+				m.loginLog.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			wantRes: Result{Msg: "登录成功"},
+		},
+		{
+			name: "已经锁定，不校验密码",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().Check(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Minute, service.ErrLoginLocked)
+				m.loginLog.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			wantRes: Result{Code: 7, Msg: "登录失败次数太多，账号暂时锁定，请稍后再试", Data: float64(60)},
+		},
+		{
+			name: "密码错误，还没到阈值",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().Check(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Duration(0), nil)
+				m.svc.EXPECT().Login(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{}, service.ErrInvalidUserOrEmail)
+				// 这时候还不知道 uid，带上账号，由 service 去查
+				m.loginLog.EXPECT().Record(gomock.Any(), domain.LoginLog{
+					Method:  domain.LoginMethodPassword,
+					Account: "123@qq.com",
+					Reason:  "邮箱或密码错误",
+				})
+				m.attempt.EXPECT().Fail(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Duration(0), nil)
+			},
+			wantRes: Result{Code: 4, Msg: "无效的邮箱或密码"},
+		},
+		{
+			name: "密码错误，达到阈值开始锁定",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().Check(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Duration(0), nil)
+				m.svc.EXPECT().Login(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{}, service.ErrInvalidUserOrEmail)
+				m.loginLog.EXPECT().Record(gomock.Any(), gomock.Any())
+				m.attempt.EXPECT().Fail(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Minute*2, nil)
+			},
+			wantRes: Result{Code: 7, Msg: "登录失败次数太多，账号暂时锁定，请稍后再试", Data: float64(120)},
+		},
+		{
+			name: "检查锁定失败",
+			mock: func(m userHandlerMocks) {
+				m.attempt.EXPECT().Check(gomock.Any(), "123@qq.com", gomock.Any()).
+					Return(time.Duration(0), errors.New("mock error"))
+			},
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 0, http.MethodPost, "/users/login", body)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_BindPhone(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m userHandlerMocks)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "绑定成功",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizBindPhone, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().BindPhone(gomock.Any(), int64(1), "15212345678", false).Return(int64(0), nil)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456"}`,
+			wantRes: Result{Msg: "绑定成功"},
+		},
+		{
+			name: "冲突，提示合并",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizBindPhone, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().BindPhone(gomock.Any(), int64(1), "15212345678", false).
+					Return(int64(0), service.ErrAccountConflict)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456"}`,
+			wantRes: Result{Code: 8, Msg: "已被其他账号使用，是否合并账号"},
+		},
+		{
+			name: "合并成功，踢掉被合并账号的设备",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizBindPhone, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().BindPhone(gomock.Any(), int64(1), "15212345678", true).Return(int64(2), nil)
+				m.jwtHdl.EXPECT().RevokeAllSessions(gomock.Any(), int64(2)).Return(nil)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","merge":true}`,
+			wantRes: Result{Msg: "账号合并成功"},
+		},
+		{
+			name: "验证码不对，不能合并",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizBindPhone, "15212345678", "123456").Return(false, nil)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","merge":true}`,
+			wantRes: Result{Code: 4, Msg: "验证码有误"},
+		},
+		{
+			name: "合并失败",
+			mock: func(m userHandlerMocks) {
+				m.codeSvc.EXPECT().Verify(gomock.Any(), bizBindPhone, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().BindPhone(gomock.Any(), int64(1), "15212345678", true).
+					Return(int64(0), errors.New("mock error"))
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","merge":true}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 1, http.MethodPost, "/users/bind/phone", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
+
+func TestUserHandler_SMSLogin(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(m userHandlerMocks)
+		reqBody string
+
+		wantRes Result
+	}{
+		{
+			name: "第一次登录，带了邀请码",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "ABCD2345").Return(nil)
+				m.codeSvc.EXPECT().Verify(gomock.Any(), biz, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().FindOrCreate(gomock.Any(), "15212345678", "ABCD2345").
+					Return(domain.User{Id: 1, Email: "123@qq.com"}, nil)
+				m.attempt.EXPECT().Unlock(gomock.Any(), "123@qq.com").Return(nil)
+				m.jwtHdl.EXPECT().SetLoginToken(gomock.Any(), int64(1)).Return(nil)
+				m.loginLog.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","invite_code":"ABCD2345"}`,
+			wantRes: Result{Msg: "登陆成功"},
+		},
+		{
+			// 验证码还没用掉，填上邀请码之后可以接着用
+			name: "需要邀请码，不校验验证码",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "").Return(service.ErrInviteCodeRequired)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456"}`,
+			wantRes: Result{Code: 4, Msg: "内测期间注册需要邀请码"},
+		},
+		{
+			name: "邀请码无效，不校验验证码",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "ABCD2345").Return(service.ErrInvalidInviteCode)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","invite_code":"ABCD2345"}`,
+			wantRes: Result{Code: 4, Msg: "邀请码无效"},
+		},
+		{
+			name: "检查邀请码失败",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "").Return(errors.New("mock error"))
+			},
+			reqBody: `{"phone":"15212345678","code":"123456"}`,
+			wantRes: Result{Code: 5, Msg: "系统错误"},
+		},
+		{
+			name: "邀请码刚好被别人用完了",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "ABCD2345").Return(nil)
+				m.codeSvc.EXPECT().Verify(gomock.Any(), biz, "15212345678", "123456").Return(true, nil)
+				m.svc.EXPECT().FindOrCreate(gomock.Any(), "15212345678", "ABCD2345").
+					Return(domain.User{}, service.ErrInvalidInviteCode)
+			},
+			reqBody: `{"phone":"15212345678","code":"123456","invite_code":"ABCD2345"}`,
+			wantRes: Result{Code: 4, Msg: "邀请码无效"},
+		},
+		{
+			name: "验证码错误",
+			mock: func(m userHandlerMocks) {
+				m.svc.EXPECT().CheckInvite(gomock.Any(), "15212345678", "").Return(nil)
+				m.codeSvc.EXPECT().Verify(gomock.Any(), biz, "15212345678", "123456").Return(false, nil)
+				m.loginLog.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			reqBody: `{"phone":"15212345678","code":"123456"}`,
+			wantRes: Result{Code: 4, Msg: "验证码有误"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serveUser(t, tc.mock, 0, http.MethodPost, "/users/login_sms", tc.reqBody)
+			assert.Equal(t, tc.wantRes, decodeResult(t, resp))
+		})
+	}
+}
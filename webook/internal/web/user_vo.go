@@ -0,0 +1,122 @@
+package web
+
+type SessionVO struct {
+	Ssid     string `json:"ssid"`
+	Device   string `json:"device"`
+	IP       string `json:"ip"`
+	Ctime    string `json:"ctime"`
+	LastSeen string `json:"last_seen"`
+	// 是不是当前正在用的这个设备
+	Current bool `json:"current"`
+}
+
+// TwoFactorVO 密码正确但是开启了两步验证，前端要拿 Token 再调用 /users/login/2fa
+type TwoFactorVO struct {
+	Need2FA bool   `json:"need_2fa"`
+	Token   string `json:"token"`
+}
+
+type TotpEnrollVO struct {
+	URI string `json:"uri"`
+}
+
+type RoleVO struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type AccessTokenVO struct {
+	Id     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// 空字符串代表从来没用过或者永不过期
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Ctime      string `json:"ctime"`
+	// 明文令牌，只有创建的时候返回这一次
+	Token string `json:"token,omitempty"`
+}
+
+type ExportTaskVO struct {
+	// 1 正在打包，2 可以下载，3 失败了
+	Status uint8  `json:"status"`
+	Ctime  string `json:"ctime"`
+	Utime  string `json:"utime"`
+}
+
+type AvatarVO struct {
+	Avatar      string `json:"avatar"`
+	AvatarThumb string `json:"avatar_thumb"`
+}
+
+type AuthorProfileVO struct {
+	Id       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	AboutMe  string `json:"about_me"`
+	Avatar   string `json:"avatar"`
+	// 注册日期
+	Ctime      string `json:"ctime"`
+	ArticleCnt int64  `json:"article_cnt"`
+	ReadCnt    int64  `json:"read_cnt"`
+	LikeCnt    int64  `json:"like_cnt"`
+	CollectCnt int64  `json:"collect_cnt"`
+	Followers  int64  `json:"followers"`
+	Followees  int64  `json:"followees"`
+	// 当前登录的用户有没有关注这个作者，没登录就是 false
+	Followed bool        `json:"followed"`
+	Articles []ArticleVO `json:"articles"`
+}
+
+type FollowReq struct {
+	Followee int64 `json:"followee"`
+}
+
+type FollowVO struct {
+	Uid int64 `json:"uid"`
+	// 关注时间
+	Ctime string `json:"ctime"`
+}
+
+type FollowListVO struct {
+	List []FollowVO `json:"list"`
+	// 下一页的游标，0 代表没有下一页了
+	NextCursor int64 `json:"next_cursor"`
+}
+
+type LoginLogVO struct {
+	Method    string `json:"method"`
+	Account   string `json:"account"`
+	Ip        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Success   bool   `json:"success"`
+	Reason    string `json:"reason"`
+	Ctime     string `json:"ctime"`
+}
+
+type CaptchaVO struct {
+	Id string `json:"id"`
+	// data URL，前端直接放到 img 的 src 里面
+	Image string `json:"image"`
+}
+
+type InviteModeVO struct {
+	Required bool `json:"required"`
+}
+
+type InviteCodeVO struct {
+	Code    string `json:"code"`
+	MaxUses int32  `json:"max_uses"`
+	Used    int32  `json:"used"`
+	Usable  bool   `json:"usable"`
+	// 为空代表不过期
+	ExpireAt string `json:"expire_at"`
+	Ctime    string `json:"ctime"`
+}
+
+type InvitationVO struct {
+	Inviter int64  `json:"inviter"`
+	Invitee int64  `json:"invitee"`
+	Code    string `json:"code"`
+	Ctime   string `json:"ctime"`
+}
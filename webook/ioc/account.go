@@ -0,0 +1,42 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/job"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/repository/articles"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/service/sms/async"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+func InitAccountService(userRepo repository.UserRepository, artRepo articles.ArticleRepository,
+	intrRepo repository.InteractiveRepository, exportRepo repository.UserExportRepository,
+	jwtHdl ijwt.Handler, l logger.Logger) service.AccountService {
+	type Config struct {
+		// 注销的冷静期
+		DeletionGrace time.Duration `yaml:"deletionGrace"`
+	}
+	var cfg = Config{
+		DeletionGrace: time.Hour * 24 * 7,
+	}
+	err := viper.UnmarshalKey("account", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return service.NewAccountService(userRepo, artRepo, intrRepo, exportRepo,
+		jwtHdl, initExportStorage(), cfg.DeletionGrace, l)
+}
+
+// InitJobs 所有的后台任务在这里注册
+func InitJobs(accountSvc service.AccountService, userSvc service.UserService,
+	loginLogSvc service.LoginLogService, asyncSMS *async.Service, l logger.Logger) []job.Job {
+	return []job.Job{
+		job.NewAsyncSMSJob(asyncSMS, time.Second*5, l),
+		job.NewAccountDeletionJob(accountSvc, time.Minute*10, l),
+		job.NewExportCleanupJob(accountSvc, time.Hour, l),
+		job.NewFieldEncryptionJob(userSvc, loginLogSvc, time.Hour, l),
+	}
+}
@@ -0,0 +1,34 @@
+package ioc
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"slices"
+	"sync"
+)
+
+var (
+	configMu        sync.Mutex
+	configListeners []func()
+)
+
+// OnConfigChange 注册配置文件改了之后的回调，可以注册多个，按注册的顺序调用。
+// viper.OnConfigChange 只保留最后一个回调，不要直接用它
+func OnConfigChange(fn func()) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	configListeners = append(configListeners, fn)
+}
+
+// WatchConfig 开始监听配置文件，要在所有组件都初始化好、回调都注册完之后再调用
+func WatchConfig() {
+	viper.OnConfigChange(func(in fsnotify.Event) {
+		configMu.Lock()
+		listeners := slices.Clone(configListeners)
+		configMu.Unlock()
+		for _, fn := range listeners {
+			fn()
+		}
+	})
+	viper.WatchConfig()
+}
@@ -0,0 +1,43 @@
+package ioc
+
+import (
+	"encoding/base64"
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+)
+
+// InitFieldCipher 手机号、邮箱这种敏感字段的加密密钥
+func InitFieldCipher() *cryptox.FieldCipher {
+	type KeyConfig struct {
+		Kid string `yaml:"kid"`
+		// base64 编码的 32 字节密钥
+		Key string `yaml:"key"`
+	}
+	type Config struct {
+		// 当前用来加密的 kid，旧的密钥继续留在 keys 里面解密，等数据都重新加密完了再删
+		Active   string      `yaml:"active"`
+		Keys     []KeyConfig `yaml:"keys"`
+		IndexKey string      `yaml:"indexKey"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("crypto", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	keys := make(map[string][]byte, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		keys[k.Kid], err = base64.StdEncoding.DecodeString(secret("crypto.keys."+k.Kid, k.Key))
+		if err != nil {
+			panic(err)
+		}
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(secret("crypto.indexKey", cfg.IndexKey))
+	if err != nil {
+		panic(err)
+	}
+	c, err := cryptox.NewFieldCipher(cfg.Active, keys, indexKey)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,17 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/service/email"
+	"github.com/zmsocc/practice/webook/internal/service/email/file"
+	"github.com/zmsocc/practice/webook/internal/service/email/memory"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+)
+
+func InitEmailService(l logger.Logger) email.Service {
+	// 配置了文件路径就写文件，方便本地查看验证码，不然只记一条不带内容的日志
+	if path := viper.GetString("email.file"); path != "" {
+		return file.NewService(path)
+	}
+	return memory.NewService(l)
+}
@@ -0,0 +1,38 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/repository"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"time"
+)
+
+// InitInviteService 改了配置文件之后自动重新加载，内测结束的时候直接关掉 invite.required 就可以
+func InitInviteService(repo repository.InviteRepository, l logger.Logger) service.InviteService {
+	cfg, err := loadInviteConfig()
+	if err != nil {
+		panic(err)
+	}
+	svc := service.NewInviteService(repo, cfg)
+	OnConfigChange(func() {
+		cfg, err := loadInviteConfig()
+		if err != nil {
+			l.Error("重新加载邀请码配置失败，继续使用原来的配置", logger.Error(err))
+			return
+		}
+		svc.Reload(cfg)
+		l.Info("重新加载邀请码配置", logger.Bool("required", cfg.Required))
+	})
+	return svc
+}
+
+func loadInviteConfig() (service.InviteConfig, error) {
+	var cfg = service.InviteConfig{
+		UserMaxUses:    1,
+		UserExpiration: time.Hour * 24 * 7,
+		UserQuota:      3,
+	}
+	err := viper.UnmarshalKey("invite", &cfg)
+	return cfg, err
+}
@@ -0,0 +1,34 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/web"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+)
+
+func InitJWTKeyRing() *ijwt.KeyRing {
+	type Config struct {
+		// 当前用来签名的 kid，不填就用最后一把
+		Active string           `yaml:"active"`
+		Keys   []ijwt.KeyConfig `yaml:"keys"`
+	}
+	var cfg = Config{
+		Keys: []ijwt.KeyConfig{
+			{Kid: "default", Path: "ec512-private.pem"},
+		},
+	}
+	err := viper.UnmarshalKey("jwt", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	keys, err := ijwt.LoadKeyRing(cfg.Keys, cfg.Active, ijwt.RefreshTokenExpiration)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+func InitJWKSHandler(keys *ijwt.KeyRing, l logger.Logger) *web.JWKSHandler {
+	return web.NewJWKSHandler(keys, l)
+}
@@ -36,6 +36,7 @@ func NewSyncProducer(client sarama.Client) sarama.SyncProducer {
 }
 
 // NewConsumers 面临的问题依旧是所有的 Consumer 在这里注册一下
-func NewConsumers(c1 *article.InteractiveReadEventBatchConsumer) []event.Consumer {
-	return []event.Consumer{c1}
+func NewConsumers(c1 *article.InteractiveReadEventBatchConsumer,
+	c2 *article.HistoryReadEventConsumer) []event.Consumer {
+	return []event.Consumer{c1, c2}
 }
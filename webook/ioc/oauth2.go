@@ -0,0 +1,39 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/service"
+	"github.com/zmsocc/practice/webook/internal/service/oauth2"
+	"github.com/zmsocc/practice/webook/internal/service/oauth2/wechat"
+	"github.com/zmsocc/practice/webook/internal/web"
+	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+)
+
+type wechatConfig struct {
+	AppId       string `yaml:"appId"`
+	AppSecret   string `yaml:"appSecret"`
+	RedirectURL string `yaml:"redirectURL"`
+	StateKey    string `yaml:"stateKey"`
+}
+
+func loadWechatConfig() wechatConfig {
+	var cfg wechatConfig
+	err := viper.UnmarshalKey("oauth2.wechat", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func InitWechatService() oauth2.Service {
+	cfg := loadWechatConfig()
+	return wechat.NewService(cfg.AppId, cfg.AppSecret, cfg.RedirectURL)
+}
+
+func InitOAuth2WechatHandler(svc oauth2.Service, userSvc service.UserService,
+	jwtHdl ijwt.Handler, l logger.Logger) *web.OAuth2WechatHandler {
+	cfg := loadWechatConfig()
+	stateKey := secret("oauth2.wechat.stateKey", cfg.StateKey)
+	return web.NewOAuth2WechatHandler(svc, userSvc, jwtHdl, []byte(stateKey), l)
+}
@@ -0,0 +1,16 @@
+package ioc
+
+import (
+	"fmt"
+	"os"
+)
+
+// secret 密钥之类的配置不能提交到仓库里面，配置文件里面只写 ${WEBOOK_XXX} 这样的占位，
+// 启动的时候从环境变量展开。线上由 k8s 的 Secret 注入，本地开发自己 export
+func secret(key, val string) string {
+	res := os.ExpandEnv(val)
+	if res == "" {
+		panic(fmt.Sprintf("没有配置 %s，请设置配置文件里面占位的环境变量", key))
+	}
+	return res
+}
@@ -1,12 +1,160 @@
 package ioc
 
 import (
+	"encoding/base64"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/repository"
 	"github.com/zmsocc/practice/webook/internal/service/sms"
+	"github.com/zmsocc/practice/webook/internal/service/sms/async"
+	"github.com/zmsocc/practice/webook/internal/service/sms/auth"
+	"github.com/zmsocc/practice/webook/internal/service/sms/failover"
 	"github.com/zmsocc/practice/webook/internal/service/sms/memory"
+	smsratelimit "github.com/zmsocc/practice/webook/internal/service/sms/ratelimit"
+	"github.com/zmsocc/practice/webook/internal/service/sms/template"
+	"github.com/zmsocc/practice/webook/internal/service/sms/tencent"
+	"github.com/zmsocc/practice/webook/pkg/cryptox"
+	"github.com/zmsocc/practice/webook/pkg/logger"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
+	"time"
 )
 
-func InitSMSService(cmd redis.Cmdable) sms.Service {
-	// tencent.InitSmsTencentService()
-	return memory.NewService()
+// InitSMSTemplateRegistry 每个业务场景的短信模板，改了配置文件之后自动重新加载
+func InitSMSTemplateRegistry(l logger.Logger) *template.Registry {
+	var tpls []template.Template
+	err := viper.UnmarshalKey("sms.templates", &tpls)
+	if err != nil {
+		panic(err)
+	}
+	registry, err := template.NewRegistry(tpls)
+	if err != nil {
+		panic(err)
+	}
+	OnConfigChange(func() {
+		var tpls []template.Template
+		err := viper.UnmarshalKey("sms.templates", &tpls)
+		if err == nil {
+			err = registry.Reload(tpls)
+		}
+		if err != nil {
+			l.Error("重新加载短信模板失败，继续使用原来的配置", logger.Error(err))
+			return
+		}
+		l.Info("重新加载短信模板", logger.Int32("count", int32(len(tpls))))
+	})
+	return registry
+}
+
+// InitSMSService 最外面先按模板校验参数，再限流，超过限制的短信既不发送也不会存起来
+func InitSMSService(cmd redis.Cmdable, svc *async.Service, tpls *template.Registry,
+	cipher *cryptox.FieldCipher) sms.Service {
+	type Config struct {
+		// 所有短信加起来每秒最多发多少条
+		QPS int `yaml:"qps"`
+		// 一个手机号一天最多收到多少条
+		DailyPerNumber int `yaml:"dailyPerNumber"`
+	}
+	var cfg = Config{
+		QPS:            100,
+		DailyPerNumber: 10,
+	}
+	err := viper.UnmarshalKey("sms.limit", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return template.NewService(smsratelimit.NewService(svc,
+		ratelimit.NewRedisSlidingWindowLimiter(cmd, time.Second, cfg.QPS),
+		ratelimit.NewRedisSlidingWindowLimiter(cmd, time.Hour*24, cfg.DailyPerNumber), cipher), tpls)
+}
+
+// InitSMSGatewayService 给内部其它服务用的短信入口，业务 token 用 sms.gateway.key 签名，
+// 每个调用方单独限流，一个调用方出了问题不会把别人的配额也用完
+func InitSMSGatewayService(svc sms.Service, cmd redis.Cmdable) *auth.Service {
+	type Config struct {
+		// 每个调用方每分钟最多发多少条
+		CallerLimit int `yaml:"callerLimit"`
+	}
+	var cfg = Config{
+		CallerLimit: 600,
+	}
+	err := viper.UnmarshalKey("sms.gateway", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return auth.NewService(svc, smsGatewayKey(),
+		ratelimit.NewRedisSlidingWindowLimiter(cmd, time.Minute, cfg.CallerLimit))
+}
+
+// InitSMSTokenIssuer 给 -issue-sms-token 用，只能签发业务 token，不能发短信
+func InitSMSTokenIssuer() *auth.Service {
+	return auth.NewService(nil, smsGatewayKey(), nil)
+}
+
+// smsGatewayKey sms.gateway.key 是 base64 编码的签名密钥
+func smsGatewayKey() []byte {
+	key, err := base64.StdEncoding.DecodeString(secret("sms.gateway.key", viper.GetString("sms.gateway.key")))
+	if err != nil {
+		panic(err)
+	}
+	if len(key) < 32 {
+		panic(fmt.Errorf("短信网关的签名密钥太短了，至少 32 字节"))
+	}
+	return key
+}
+
+// InitAsyncSMSService 多个服务商之间自动切换，都不行的时候存起来由后台任务重试
+func InitAsyncSMSService(repo repository.AsyncSMSRepository, tpls *template.Registry, l logger.Logger) *async.Service {
+	type Config struct {
+		// 按顺序排列的服务商，ordered 的时候第一个是主服务商
+		Providers []string `yaml:"providers"`
+		// ordered 或者 round_robin
+		Strategy string        `yaml:"strategy"`
+		Timeout  time.Duration `yaml:"timeout"`
+		Cooldown time.Duration `yaml:"cooldown"`
+	}
+	fcfg := failover.DefaultConfig()
+	var cfg = Config{
+		Providers: []string{"memory"},
+		Strategy:  "ordered",
+		Timeout:   fcfg.Timeout,
+		Cooldown:  fcfg.Cooldown,
+	}
+	err := viper.UnmarshalKey("sms", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	switch cfg.Strategy {
+	case "ordered":
+		fcfg.Strategy = failover.StrategyOrdered
+	case "round_robin":
+		fcfg.Strategy = failover.StrategyRoundRobin
+	default:
+		panic(fmt.Errorf("未知的短信服务商选择策略 %s", cfg.Strategy))
+	}
+	fcfg.Timeout, fcfg.Cooldown = cfg.Timeout, cfg.Cooldown
+	providers := make([]failover.Provider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		providers = append(providers, failover.Provider{
+			Name: name,
+			Svc:  initSMSProvider(name, tpls, l),
+		})
+	}
+	acfg := async.DefaultConfig()
+	err = viper.UnmarshalKey("sms.async", &acfg)
+	if err != nil {
+		panic(err)
+	}
+	return async.NewService(failover.NewService(providers, fcfg), repo, acfg, l)
+}
+
+func initSMSProvider(name string, tpls *template.Registry, l logger.Logger) sms.Service {
+	switch name {
+	case "tencent":
+		return tencent.InitSmsTencentService(tpls)
+	case "memory":
+		return memory.NewService(l)
+	default:
+		panic(fmt.Errorf("未知的短信服务商 %s", name))
+	}
 }
@@ -0,0 +1,55 @@
+package ioc
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/service/storage"
+	"github.com/zmsocc/practice/webook/internal/service/storage/local"
+)
+
+// localStoragePath 本地存储的文件挂在这个路径下面
+const localStoragePath = "/uploads"
+
+type localStorageConfig struct {
+	Dir string `yaml:"dir"`
+	// 访问文件的 URL 前缀，前面有 nginx 或者 CDN 的时候改成对应的域名
+	BaseURL string `yaml:"baseURL"`
+}
+
+func loadLocalStorageConfig() localStorageConfig {
+	cfg := localStorageConfig{
+		Dir:     "./uploads",
+		BaseURL: localStoragePath,
+	}
+	err := viper.UnmarshalKey("storage.local", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func InitStorage() storage.Service {
+	cfg := loadLocalStorageConfig()
+	return local.NewService(cfg.Dir, cfg.BaseURL)
+}
+
+// initExportStorage 导出的个人数据单独放，不挂到静态目录下面，只能登录之后下载。
+// 部署多个实例的时候 dir 要挂共享的存储，不然打包和下载落到不同的实例上就找不到文件
+func initExportStorage() storage.Service {
+	type Config struct {
+		Dir string `yaml:"dir"`
+	}
+	cfg := Config{
+		Dir: "./exports",
+	}
+	err := viper.UnmarshalKey("storage.export", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return local.NewService(cfg.Dir, "")
+}
+
+// registerLocalStorage 本地存储的文件直接由 gin 提供下载
+func registerLocalStorage(server *gin.Engine) {
+	server.Static(localStoragePath, loadLocalStorageConfig().Dir)
+}
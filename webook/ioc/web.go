@@ -4,11 +4,15 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/internal/service"
 	"github.com/zmsocc/practice/webook/internal/web"
 	"github.com/zmsocc/practice/webook/internal/web/ijwt"
 	"github.com/zmsocc/practice/webook/internal/web/middleware"
 	"github.com/zmsocc/practice/webook/pkg/ginx/middlewares/metric"
 	"github.com/zmsocc/practice/webook/pkg/ginx/middlewares/ratelimit"
+	limiter "github.com/zmsocc/practice/webook/pkg/ratelimit"
 	"strings"
 	"time"
 )
@@ -28,7 +32,8 @@ func corsHdl() gin.HandlerFunc {
 	})
 }
 
-func InitMiddlewares(jwtHdl ijwt.Handler, cmd redis.Cmdable) []gin.HandlerFunc {
+func InitMiddlewares(jwtHdl ijwt.Handler, tokenSvc service.AccessTokenService,
+	statusSvc service.UserStatusService, cmd redis.Cmdable) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		corsHdl(),
 		(&metric.MiddlewareBuilder{
@@ -39,24 +44,69 @@ func InitMiddlewares(jwtHdl ijwt.Handler, cmd redis.Cmdable) []gin.HandlerFunc {
 			Help:       "统计 GIN 的 GTTP 接口",
 			InstanceID: "my-instance-1",
 		}).Build(),
-		middleware.NewLoginJWTMiddlewareBuilder(jwtHdl).
+		middleware.NewLoginJWTMiddlewareBuilder(jwtHdl, tokenSvc, statusSvc).
 			IgnorePaths("/users/signup").
 			IgnorePaths("/users/login").
+			IgnorePaths("/users/login/2fa").
 			IgnorePaths("/users/login_sms/code/send").
 			IgnorePaths("/users/login_sms").
 			IgnorePaths("/users/refresh_token").
+			IgnorePaths("/users/password/forgot").
+			IgnorePaths("/users/password/reset").
+			IgnorePaths("/captcha").
+			IgnorePaths("/invites/mode").
+			// 用业务 token 鉴权
+			IgnorePaths("/sms/send").
 			IgnorePaths("/test/metrics").
+			IgnorePaths("/.well-known/jwks.json").
+			IgnorePaths("/oauth2/wechat/login").
+			IgnorePaths("/oauth2/wechat/callback").
+			IgnorePaths(localStoragePath+"/*filepath").
+			OptionalPaths("/authors/:id").
+			// 个人访问令牌能访问的接口
+			AllowAccessToken("/users/profile", domain.ScopeUserRead).
+			AllowAccessToken("/articles/detail/:id", domain.ScopeArticleRead).
+			AllowAccessToken("/articles/list", domain.ScopeArticleRead).
+			AllowAccessToken("/pub/:id", domain.ScopeArticleRead).
+			AllowAccessToken("/articles/edit", domain.ScopeArticleWrite).
+			AllowAccessToken("/articles/publish", domain.ScopeArticleWrite).
+			AllowAccessToken("/articles/withdraw", domain.ScopeArticleWrite).
+			Build(),
+		ratelimit.NewBuilder(limiter.NewRedisSlidingWindowLimiter(cmd, time.Minute, 100)).
+			// 内部服务往往从同一个出口 IP 过来，按调用方限流，见 InitSMSGatewayService
+			IgnorePaths("/sms/send").
 			Build(),
-		ratelimit.NewBuilder(cmd, time.Minute, 100).Build(),
 	}
 }
 
 func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler,
-	articleHdl *web.ArticleHandler) *gin.Engine {
+	articleHdl *web.ArticleHandler, jwksHdl *web.JWKSHandler,
+	wechatHdl *web.OAuth2WechatHandler, adminHdl *web.AdminHandler,
+	tokenHdl *web.AccessTokenHandler, accountHdl *web.AccountHandler,
+	authorHdl *web.AuthorHandler, followHdl *web.FollowHandler,
+	avatarHdl *web.AvatarHandler, captchaHdl *web.CaptchaHandler,
+	inviteHdl *web.InviteHandler, smsGatewayHdl *web.SMSGatewayHandler) *gin.Engine {
 	server := gin.Default()
+	// 只有从这些代理过来的请求才相信 X-Forwarded-For，不然谁都能伪造 IP 绕过限流和图形验证码。
+	// 没配置的话 ClientIP 就是连接的对端地址
+	if err := server.SetTrustedProxies(viper.GetStringSlice("server.trustedProxies")); err != nil {
+		panic(err)
+	}
 	server.Use(mdls...)
 	userHdl.RegisterRoutes(server)
 	articleHdl.RegisterRoutes(server)
+	jwksHdl.RegisterRoutes(server)
+	wechatHdl.RegisterRoutes(server)
+	adminHdl.RegisterRoutes(server)
+	tokenHdl.RegisterRoutes(server)
+	accountHdl.RegisterRoutes(server)
+	authorHdl.RegisterRoutes(server)
+	followHdl.RegisterRoutes(server)
+	avatarHdl.RegisterRoutes(server)
+	captchaHdl.RegisterRoutes(server)
+	inviteHdl.RegisterRoutes(server)
+	smsGatewayHdl.RegisterRoutes(server)
+	registerLocalStorage(server)
 	(&web.ObservabilityHandler{}).RegisterRoutes(server)
 	return server
 }
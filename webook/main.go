@@ -1,15 +1,44 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/zmsocc/practice/webook/internal/web/ijwt"
+	"github.com/spf13/viper"
+	"github.com/zmsocc/practice/webook/internal/domain"
+	"github.com/zmsocc/practice/webook/ioc"
+	"github.com/zmsocc/practice/webook/pkg/mask"
 	"net/http"
+	"time"
+)
+
+var (
+	seedRBAC = flag.Bool("seed-rbac", false, "写入内置的角色和权限之后退出，部署的时候执行一次")
+	adminUid = flag.Int64("admin", 0, "和 -seed-rbac 一起用，授予这个用户管理员角色")
+	// 手机号加密上线之后执行一次，先看一遍报告，确认没问题再加上 -merge
+	phoneConflicts = flag.Bool("phone-conflicts", false, "列出同一个手机号注册的多个账号之后退出")
+	mergeConflicts = flag.Bool("merge", false, "和 -phone-conflicts 一起用，把其它账号合并到保留的账号里面")
+	// 内部其它服务要通过 /sms/send 发短信的时候，由运维签发 token 交给对方
+	smsCaller   = flag.String("issue-sms-token", "", "给这个调用方签发短信网关的业务 token，打印出来之后退出")
+	smsTpl      = flag.String("tpl", "", "和 -issue-sms-token 一起用，token 只能使用这个短信模板")
+	smsTokenTTL = flag.Duration("ttl", time.Hour*24*90, "和 -issue-sms-token 一起用，token 的有效期")
 )
 
 func main() {
-	if err := ijwt.InitPrivateKey(); err != nil {
-		panic(err) // 或优雅处理
+	initViper()
+	if *seedRBAC {
+		initRBAC(*adminUid)
+		return
+	}
+	if *phoneConflicts {
+		resolvePhoneConflicts(*mergeConflicts)
+		return
+	}
+	if *smsCaller != "" {
+		issueSMSToken(*smsCaller, *smsTpl, *smsTokenTTL)
+		return
 	}
 	//server := gin.Default()
 	//server := InitWebServer()
@@ -18,12 +47,20 @@ func main() {
 	initPrometheus()
 
 	app := InitWebServer()
+	// 短信模板这种配置改了之后不用重启。热加载的回调都是初始化的时候注册的，所以放在初始化之后
+	ioc.WatchConfig()
 	for _, c := range app.consumers {
 		err := c.Start()
 		if err != nil {
 			panic(err)
 		}
 	}
+	for _, j := range app.jobs {
+		err := j.Start()
+		if err != nil {
+			panic(err)
+		}
+	}
 	server := app.web
 	//server := gin.Default()
 	server.GET("/hello", func(ctx *gin.Context) {
@@ -32,6 +69,72 @@ func main() {
 	server.Run(":8080")
 }
 
+func initViper() {
+	cfile := flag.String("config", "config/dev.yaml", "配置文件路径")
+	flag.Parse()
+	viper.SetConfigFile(*cfile)
+	err := viper.ReadInConfig()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// initRBAC 第一个管理员只能从这里来，其它角色由管理员在 /admin 里面分配
+func initRBAC(admin int64) {
+	svc := InitRBACService()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	err := svc.InitRoles(ctx, domain.DefaultRoles)
+	if err != nil {
+		panic(err)
+	}
+	if admin > 0 {
+		err = svc.GrantRole(ctx, admin, domain.RoleAdmin)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// resolvePhoneConflicts 同一个手机号的多个账号不能自动处理，不然只能短信登录的账号就再也进不去了。
+// 这里只列出来，merge 为 true 的时候才合并，合并之后文章、点赞这些数据都转移到保留的账号上面
+func resolvePhoneConflicts(merge bool) {
+	svc := InitUserService()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+	defer cancel()
+	conflicts, err := svc.PhoneConflicts(ctx)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("共有 %d 个手机号注册了多个账号\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("%s: 保留 %d，其它账号 %v\n", mask.Phone(c.Phone), c.Uids[0], c.Uids[1:])
+		if !merge {
+			continue
+		}
+		if err = svc.MergePhoneConflict(ctx, c); err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: 已合并\n", mask.Phone(c.Phone))
+	}
+}
+
+// issueSMSToken 业务 token 没有办法单独吊销，有效期不要给太长，要吊销只能换 sms.gateway.key
+func issueSMSToken(caller, tpl string, ttl time.Duration) {
+	if ttl <= 0 {
+		panic(fmt.Errorf("token 的有效期必须大于 0"))
+	}
+	// 模板不存在的话签发出来也用不了
+	if _, err := ioc.InitSMSTemplateRegistry(ioc.InitLogger()).Get(tpl); err != nil {
+		panic(err)
+	}
+	token, err := ioc.InitSMSTokenIssuer().NewToken(caller, tpl, ttl)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(token)
+}
+
 func initPrometheus() {
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
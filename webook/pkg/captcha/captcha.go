@@ -0,0 +1,183 @@
+// Package captcha 生成数字图形验证码，纯 Go 实现，字形是内置的点阵
+package captcha
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/big"
+	mrand "math/rand/v2"
+)
+
+const (
+	glyphW = 5
+	glyphH = 7
+	// 每个点放大成 scale x scale 的方块
+	scale = 4
+	// 字符之间和四周留白
+	padding = 6
+)
+
+// 5x7 点阵，1 代表有笔画
+var glyphs = [10][glyphH]string{
+	{"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	{"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	{"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	{"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	{"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	{"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	{"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	{"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	{"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	{"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+}
+
+var ErrInvalidAnswer = errors.New("验证码只能是数字")
+
+// RandomDigits 用 crypto/rand 生成 n 位数字
+func RandomDigits(n int) (string, error) {
+	res := make([]byte, n)
+	for i := range res {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		res[i] = byte('0' + d.Int64())
+	}
+	return string(res), nil
+}
+
+// Size answer 画出来的图片大小
+func Size(answer string) (int, int) {
+	w := padding*2 + len(answer)*(glyphW*scale+padding)
+	h := padding*4 + glyphH*scale
+	return w, h
+}
+
+// PNG 把 answer 画成 PNG。每个字符随机旋转、缩放、偏移，和干扰曲线一起做一次波浪扭曲，
+// 字符之间会挤在一起，最后再撒上干扰点，这样直接切分字符然后按点阵匹配就认不出来了
+func PNG(answer string) ([]byte, error) {
+	for _, c := range answer {
+		if c < '0' || c > '9' {
+			return nil, ErrInvalidAnswer
+		}
+	}
+	w, h := Size(answer)
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src.SetRGBA(x, y, lightColor())
+		}
+	}
+	// 干扰曲线的颜色和粗细跟字符差不多，穿过字符，不能简单按颜色或者线宽过滤掉
+	drawCurve(src, scale/2+1, darkColor())
+	for i, c := range answer {
+		// 字符中心，左右随机挪动，相邻的字符可能会贴在一起
+		cx := padding + i*(glyphW*scale+padding) + glyphW*scale/2 + mrand.IntN(padding+1) - padding/2
+		cy := h/2 + mrand.IntN(padding+1) - padding/2
+		// 旋转 -25° 到 25°，缩放 0.85 到 1.15
+		angle := (mrand.Float64() - 0.5) * math.Pi * 5 / 18
+		k := 0.85 + mrand.Float64()*0.3
+		drawGlyph(src, glyphs[c-'0'], cx, cy, angle, k, darkColor())
+	}
+	img := warp(src)
+	for i := 0; i < 2; i++ {
+		drawCurve(img, 1, darkColor())
+	}
+	for i := 0; i < w*h/12; i++ {
+		if mrand.IntN(2) == 0 {
+			img.SetRGBA(mrand.IntN(w), mrand.IntN(h), darkColor())
+		} else {
+			img.SetRGBA(mrand.IntN(w), mrand.IntN(h), lightColor())
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawGlyph 以 (cx, cy) 为中心画一个字符，旋转 angle 弧度，放大 k 倍。
+// 对目标区域的每个点反过来算它落在点阵的哪个格子里，这样旋转之后笔画不会有空洞
+func drawGlyph(img *image.RGBA, g [glyphH]string, cx, cy int, angle, k float64, c color.RGBA) {
+	sin, cos := math.Sincos(angle)
+	cell := float64(scale) * k
+	r := int(math.Ceil(math.Hypot(glyphW, glyphH) * cell / 2))
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			gx := (cos*float64(dx)+sin*float64(dy))/cell + glyphW/2.0
+			gy := (-sin*float64(dx)+cos*float64(dy))/cell + glyphH/2.0
+			if gx < 0 || gy < 0 || gx >= glyphW || gy >= glyphH {
+				continue
+			}
+			if g[int(gy)][int(gx)] != '1' {
+				continue
+			}
+			x, y := cx+dx, cy+dy
+			if image.Pt(x, y).In(img.Rect) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawCurve 横穿整张图画一条粗细为 width 的正弦曲线
+func drawCurve(img *image.RGBA, width int, c color.RGBA) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	y0 := float64(h/4 + mrand.IntN(h/2+1))
+	amp := float64(h) / 6 * (0.5 + mrand.Float64())
+	period := float64(w) * (0.5 + mrand.Float64())
+	phase := mrand.Float64() * 2 * math.Pi
+	for x := 0; x < w; x++ {
+		y := int(y0 + amp*math.Sin(2*math.Pi*float64(x)/period+phase))
+		for t := 0; t < width; t++ {
+			if image.Pt(x, y+t).In(img.Rect) {
+				img.SetRGBA(x, y+t, c)
+			}
+		}
+	}
+}
+
+// warp 整张图做一次横竖两个方向的波浪扭曲，字符的笔画不再是直的
+func warp(src *image.RGBA) *image.RGBA {
+	b := src.Rect
+	dst := image.NewRGBA(b)
+	ax, ay := 1.5+mrand.Float64()*1.5, 1.5+mrand.Float64()*1.5
+	px, py := float64(b.Dy())*(0.8+mrand.Float64()*0.4), float64(b.Dx())*(0.3+mrand.Float64()*0.3)
+	phx, phy := mrand.Float64()*2*math.Pi, mrand.Float64()*2*math.Pi
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			sx := x + int(ax*math.Sin(2*math.Pi*float64(y)/px+phx))
+			sy := y + int(ay*math.Sin(2*math.Pi*float64(x)/py+phy))
+			dst.SetRGBA(x, y, src.RGBAAt(clamp(sx, 0, b.Dx()-1), clamp(sy, 0, b.Dy()-1)))
+		}
+	}
+	return dst
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func lightColor() color.RGBA {
+	return color.RGBA{
+		R: uint8(220 + mrand.IntN(36)),
+		G: uint8(220 + mrand.IntN(36)),
+		B: uint8(220 + mrand.IntN(36)),
+		A: 0xff,
+	}
+}
+
+func darkColor() color.RGBA {
+	return color.RGBA{
+		R: uint8(mrand.IntN(120)),
+		G: uint8(mrand.IntN(120)),
+		B: uint8(mrand.IntN(120)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,52 @@
+package captcha
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	testCases := []struct {
+		name    string
+		answer  string
+		wantErr error
+	}{
+		{name: "数字", answer: "0123456789"},
+		{name: "不是数字", answer: "12a4", wantErr: ErrInvalidAnswer},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := PNG(tc.answer)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			w, h := Size(tc.answer)
+			assert.Equal(t, w, img.Bounds().Dx())
+			assert.Equal(t, h, img.Bounds().Dy())
+		})
+	}
+}
+
+// 每次的旋转、扭曲和干扰都是随机的，同一个答案也画不出同样的图
+func TestPNG_Random(t *testing.T) {
+	a, err := PNG("1234")
+	require.NoError(t, err)
+	b, err := PNG("1234")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestRandomDigits(t *testing.T) {
+	answer, err := RandomDigits(6)
+	require.NoError(t, err)
+	assert.Len(t, answer, 6)
+	for _, c := range answer {
+		assert.True(t, c >= '0' && c <= '9')
+	}
+}
@@ -0,0 +1,117 @@
+// Package cryptox 数据库字段级别的加密。
+// 密文的格式是 kid:base64(nonce+密文)，kid 用来找解密的密钥，所以轮换之后旧数据还是能解开。
+// 加密之后没办法按值查询，所以另外提供一个 HMAC 的盲索引，用来做等值查询和唯一索引
+package cryptox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const sep = ":"
+
+var (
+	ErrUnknownKey          = errors.New("cryptox: 未知的密钥")
+	ErrMalformedCiphertext = errors.New("cryptox: 密文格式不对")
+)
+
+type FieldCipher struct {
+	active   string
+	aeads    map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewFieldCipher keys 是 kid 到 AES-256 密钥的映射，active 是当前用来加密的 kid。
+// indexKey 用来计算盲索引，它不能轮换，换了之后所有的盲索引都要重新算
+func NewFieldCipher(active string, keys map[string][]byte, indexKey []byte) (*FieldCipher, error) {
+	if len(indexKey) < 32 {
+		return nil, errors.New("cryptox: 盲索引的密钥至少 32 字节")
+	}
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for kid, key := range keys {
+		if kid == "" || strings.Contains(kid, sep) {
+			return nil, fmt.Errorf("cryptox: kid %q 不能为空，也不能包含 %s", kid, sep)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("cryptox: 密钥 %s 必须是 32 字节", kid)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		aeads[kid] = aead
+	}
+	if _, ok := aeads[active]; !ok {
+		return nil, fmt.Errorf("cryptox: 当前密钥 %s 不存在", active)
+	}
+	return &FieldCipher{
+		active:   active,
+		aeads:    aeads,
+		indexKey: indexKey,
+	}, nil
+}
+
+// Active 当前用来加密的 kid
+func (c *FieldCipher) Active() string {
+	return c.active
+}
+
+// Prefix 用当前密钥加密出来的密文都以它开头
+func (c *FieldCipher) Prefix() string {
+	return c.active + sep
+}
+
+func (c *FieldCipher) Encrypt(plain string) (string, error) {
+	aead := c.aeads[c.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return c.Prefix() + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *FieldCipher) Decrypt(ciphertext string) (string, error) {
+	kid, data, ok := strings.Cut(ciphertext, sep)
+	if !ok {
+		return "", ErrMalformedCiphertext
+	}
+	aead, ok := c.aeads[kid]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedCiphertext
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted 看上去是不是密文，数据迁移的时候用来区分还没加密的旧数据
+func IsEncrypted(val string) bool {
+	return strings.Contains(val, sep)
+}
+
+// BlindIndex 相同的明文得到相同的结果，没有 indexKey 没办法从明文算出来
+func (c *FieldCipher) BlindIndex(plain string) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
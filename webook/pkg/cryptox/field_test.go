@@ -0,0 +1,99 @@
+package cryptox
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestFieldCipher_Encrypt(t *testing.T) {
+	c, err := NewFieldCipher("v1", map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 32),
+	}, bytes.Repeat([]byte{9}, 32))
+	require.NoError(t, err)
+
+	a, err := c.Encrypt("13800138000")
+	require.NoError(t, err)
+	b, err := c.Encrypt("13800138000")
+	require.NoError(t, err)
+	// 随机 nonce，相同的明文每次加密出来都不一样
+	assert.NotEqual(t, a, b)
+	assert.True(t, strings.HasPrefix(a, "v1:"))
+	assert.True(t, IsEncrypted(a))
+	assert.NotContains(t, a, "13800138000")
+
+	plain, err := c.Decrypt(a)
+	require.NoError(t, err)
+	assert.Equal(t, "13800138000", plain)
+
+	_, err = c.Decrypt("v1:" + strings.Repeat("A", 40))
+	assert.Error(t, err)
+	_, err = c.Decrypt("13800138000")
+	assert.ErrorIs(t, err, ErrMalformedCiphertext)
+	_, err = c.Decrypt("v2:AAAA")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestFieldCipher_Rotate(t *testing.T) {
+	v1 := bytes.Repeat([]byte{1}, 32)
+	v2 := bytes.Repeat([]byte{2}, 32)
+	indexKey := bytes.Repeat([]byte{9}, 32)
+	old, err := NewFieldCipher("v1", map[string][]byte{"v1": v1}, indexKey)
+	require.NoError(t, err)
+	ciphertext, err := old.Encrypt("foo@example.com")
+	require.NoError(t, err)
+
+	c, err := NewFieldCipher("v2", map[string][]byte{"v1": v1, "v2": v2}, indexKey)
+	require.NoError(t, err)
+	// 旧密钥加密的数据还能解开
+	plain, err := c.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "foo@example.com", plain)
+	assert.Equal(t, "v2:", c.Prefix())
+	// 盲索引不受轮换影响
+	assert.Equal(t, old.BlindIndex("foo@example.com"), c.BlindIndex("foo@example.com"))
+	assert.NotEqual(t, c.BlindIndex("foo@example.com"), c.BlindIndex("bar@example.com"))
+}
+
+func TestNewFieldCipher(t *testing.T) {
+	indexKey := bytes.Repeat([]byte{9}, 32)
+	testCases := []struct {
+		name     string
+		active   string
+		keys     map[string][]byte
+		indexKey []byte
+	}{
+		{
+			name:     "当前密钥不存在",
+			active:   "v2",
+			keys:     map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)},
+			indexKey: indexKey,
+		},
+		{
+			name:     "密钥长度不对",
+			active:   "v1",
+			keys:     map[string][]byte{"v1": bytes.Repeat([]byte{1}, 16)},
+			indexKey: indexKey,
+		},
+		{
+			name:     "kid 带了分隔符",
+			active:   "v:1",
+			keys:     map[string][]byte{"v:1": bytes.Repeat([]byte{1}, 32)},
+			indexKey: indexKey,
+		},
+		{
+			name:     "盲索引密钥太短",
+			active:   "v1",
+			keys:     map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)},
+			indexKey: []byte("short"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewFieldCipher(tc.active, tc.keys, tc.indexKey)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,31 +1,23 @@
 package ratelimit
 
 import (
-	_ "embed"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
+	"github.com/zmsocc/practice/webook/pkg/ratelimit"
 	"net/http"
-	"time"
 )
 
 type Builder struct {
-	prefix   string
-	cmd      redis.Cmdable
-	interval time.Duration
-	// 阈值
-	rate int
+	prefix  string
+	limiter ratelimit.Limiter
+	// 不按 IP 限流的路由，它们自己有别的限流
+	paths []string
 }
 
-//go:embed slide_widow.lua
-var luaScript string
-
-func NewBuilder(cmd redis.Cmdable, interval time.Duration, rate int) *Builder {
+func NewBuilder(limiter ratelimit.Limiter) *Builder {
 	return &Builder{
-		prefix:   "ip-limiter",
-		cmd:      cmd,
-		interval: interval,
-		rate:     rate,
+		prefix:  "ip-limiter",
+		limiter: limiter,
 	}
 }
 
@@ -34,8 +26,19 @@ func (b *Builder) Prefix(prefix string) *Builder {
 	return b
 }
 
+func (b *Builder) IgnorePaths(path string) *Builder {
+	b.paths = append(b.paths, path)
+	return b
+}
+
 func (b *Builder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		for _, path := range b.paths {
+			if ctx.Request.URL.Path == path || ctx.FullPath() == path {
+				ctx.Next()
+				return
+			}
+		}
 		limited, err := b.limit(ctx)
 		if err != nil {
 			ctx.AbortWithStatus(http.StatusInternalServerError)
@@ -51,5 +54,5 @@ func (b *Builder) Build() gin.HandlerFunc {
 
 func (b *Builder) limit(ctx *gin.Context) (bool, error) {
 	key := fmt.Sprintf("%s:%s", b.prefix, ctx.ClientIP())
-	return b.cmd.Eval(ctx, luaScript, []string{key}, b.interval.Milliseconds(), b.rate, time.Now().UnixMilli()).Bool()
+	return b.limiter.Limit(ctx, key)
 }
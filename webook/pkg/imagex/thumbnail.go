@@ -0,0 +1,56 @@
+// Package imagex 纯 Go 的图片处理，不依赖 cgo，也不依赖第三方库
+package imagex
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail 从 src 的中间裁出最大的正方形，缩放成 size x size。
+// 缩小的时候用区域平均，放大的时候相当于最近邻。
+// 透明的部分铺白底，方便后面编码成 JPEG
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := span(y, size, side)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := span(x, size, side)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(x0+sx, y0+sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			r, g, bl, a = r/n, g/n, bl/n, a/n
+			// RGBA() 返回的是预乘过 alpha 的，直接加上白底剩下的部分
+			white := 0xffff - a
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) >> 8),
+				G: uint8((g + white) >> 8),
+				B: uint8((bl + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// span 目标第 i 个像素对应源图的 [start, end)，至少一个像素
+func span(i, size, side int) (int, int) {
+	start := i * side / size
+	end := (i + 1) * side / size
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
@@ -0,0 +1,73 @@
+package imagex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  func() image.Image
+		size int
+		want color.RGBA
+	}{
+		{
+			// 左右两边是黑色，中间的正方形是红色，裁剪之后只剩红色
+			name: "裁剪中间",
+			src: func() image.Image {
+				img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+				for y := 0; y < 20; y++ {
+					for x := 0; x < 40; x++ {
+						c := color.RGBA{A: 0xff}
+						if x >= 10 && x < 30 {
+							c = color.RGBA{R: 0xff, A: 0xff}
+						}
+						img.SetRGBA(x, y, c)
+					}
+				}
+				return img
+			},
+			size: 4,
+			want: color.RGBA{R: 0xff, A: 0xff},
+		},
+		{
+			// 黑白相间，缩小之后是平均的灰色
+			name: "区域平均",
+			src: func() image.Image {
+				img := image.NewGray(image.Rect(0, 0, 8, 8))
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						if (x+y)%2 == 0 {
+							img.SetGray(x, y, color.Gray{Y: 0xff})
+						}
+					}
+				}
+				return img
+			},
+			size: 2,
+			want: color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+		},
+		{
+			name: "透明的铺白底",
+			src: func() image.Image {
+				return image.NewNRGBA(image.Rect(0, 0, 3, 3))
+			},
+			size: 6,
+			want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := Thumbnail(tc.src(), tc.size)
+			assert.Equal(t, image.Rect(0, 0, tc.size, tc.size), dst.Bounds())
+			for y := 0; y < tc.size; y++ {
+				for x := 0; x < tc.size; x++ {
+					assert.Equal(t, tc.want, dst.RGBAAt(x, y))
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package logger
 
+import "github.com/zmsocc/practice/webook/pkg/mask"
+
 func String(key string, value string) Field {
 	return Field{
 		Key:   key,
@@ -21,9 +23,31 @@ func Int64(key string, value int64) Field {
 	}
 }
 
+func Bool(key string, value bool) Field {
+	return Field{
+		Key:   key,
+		Value: value,
+	}
+}
+
 func Error(err error) Field {
 	return Field{
 		Key:   "error",
 		Value: err,
 	}
 }
+
+// Phone 手机号脱敏之后再写日志
+func Phone(key string, phone string) Field {
+	return String(key, mask.Phone(phone))
+}
+
+// Email 邮箱脱敏之后再写日志
+func Email(key string, email string) Field {
+	return String(key, mask.Email(email))
+}
+
+// Account 账号可能是手机号也可能是邮箱，同样脱敏
+func Account(key string, account string) Field {
+	return String(key, mask.Account(account))
+}
@@ -0,0 +1,33 @@
+// Package mask 脱敏，手机号和邮箱写日志、返回给前端之前都要过一遍
+package mask
+
+import "strings"
+
+// Phone 保留前 3 位和后 4 位，13812345678 变成 138****5678。太短的全部打码
+func Phone(phone string) string {
+	n := len(phone)
+	if n < 8 {
+		return strings.Repeat("*", n)
+	}
+	return phone[:3] + strings.Repeat("*", n-7) + phone[n-4:]
+}
+
+// Email 只保留用户名的第一个字符和域名，foo@example.com 变成 f***@example.com
+func Email(email string) string {
+	name, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return strings.Repeat("*", len(email))
+	}
+	if len(name) <= 1 {
+		return "*@" + domain
+	}
+	return name[:1] + "***@" + domain
+}
+
+// Account 登录用的账号，可能是手机号也可能是邮箱
+func Account(account string) string {
+	if strings.Contains(account, "@") {
+		return Email(account)
+	}
+	return Phone(account)
+}
@@ -0,0 +1,26 @@
+package mask
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAccount(t *testing.T) {
+	testCases := []struct {
+		name    string
+		account string
+		want    string
+	}{
+		{name: "手机号", account: "13812345678", want: "138****5678"},
+		{name: "带区号的手机号", account: "+8613812345678", want: "+86*******5678"},
+		{name: "太短", account: "12345", want: "*****"},
+		{name: "邮箱", account: "foo@example.com", want: "f***@example.com"},
+		{name: "用户名只有一个字符", account: "f@example.com", want: "*@example.com"},
+		{name: "空", account: "", want: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Account(tc.account))
+		})
+	}
+}
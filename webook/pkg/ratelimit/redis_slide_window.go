@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math/rand/v2"
+	"time"
+)
+
+//go:embed slide_window.lua
+var luaSlideWindow string
+
+// RedisSlidingWindowLimiter 基于 Redis 的滑动窗口限流，多个实例共享同一个窗口
+type RedisSlidingWindowLimiter struct {
+	cmd redis.Cmdable
+	// 窗口大小
+	interval time.Duration
+	// 窗口内最多允许多少个请求
+	rate int
+}
+
+func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, interval time.Duration, rate int) Limiter {
+	return &RedisSlidingWindowLimiter{
+		cmd:      cmd,
+		interval: interval,
+		rate:     rate,
+	}
+}
+
+func (l *RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, rand.Uint64())
+	return l.cmd.Eval(ctx, luaSlideWindow, []string{key},
+		l.interval.Milliseconds(), l.rate, now, member).Bool()
+}
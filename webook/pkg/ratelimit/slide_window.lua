@@ -0,0 +1,24 @@
+-- 基于 ZSET 的滑动窗口限流，窗口内的每个请求是一个成员，score 是请求的时间
+
+-- 限流对象
+local key = KEYS[1]
+-- 窗口大小
+local window = tonumber(ARGV[1])
+-- 阈值
+local threshold = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+-- 这一次请求的成员，同一毫秒里面可能有多个请求，所以不能直接用 now
+local member = ARGV[4]
+-- 窗口的起始时间
+local min = now - window
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', min)
+local cnt = redis.call('ZCOUNT', key, '-inf', '+inf')
+if cnt >= threshold then
+    -- 执行限流
+    return "true"
+else
+    redis.call('ZADD', key, now, member)
+    redis.call('PEXPIRE', key, window)
+    return "false"
+end
@@ -0,0 +1,62 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	// RFC 6238 附录 B 的测试数据，取后 6 位
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).
+		EncodeToString([]byte("12345678901234567890"))
+	testCases := []struct {
+		name string
+		ts   int64
+		want string
+	}{
+		{name: "59", ts: 59, want: "287082"},
+		{name: "1111111109", ts: 1111111109, want: "081804"},
+		{name: "1234567890", ts: 1234567890, want: "005924"},
+		{name: "2000000000", ts: 2000000000, want: "279037"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := Code(secret, time.Unix(tc.ts, 0))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+
+	assert.True(t, Validate(secret, code, now))
+	assert.True(t, Validate(secret, code, now.Add(period*time.Second)))
+	assert.False(t, Validate(secret, code, now.Add(period*3*time.Second)))
+	assert.False(t, Validate(secret, "12345", now))
+	assert.False(t, Validate("not base32!", code, now))
+}
+
+func TestMatch(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	step := now.Unix() / period
+
+	// 手机时间慢了一个周期，对应的还是生成验证码的那个周期
+	got, ok := Match(secret, code, now.Add(period*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+	_, ok = Match(secret, code, now.Add(period*3*time.Second))
+	assert.False(t, ok)
+}
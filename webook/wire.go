@@ -5,6 +5,7 @@ package main
 import (
 	"github.com/google/wire"
 	"github.com/zmsocc/practice/webook/internal/event/article"
+	"github.com/zmsocc/practice/webook/internal/event/security"
 	"github.com/zmsocc/practice/webook/internal/repository"
 	articles2 "github.com/zmsocc/practice/webook/internal/repository/articles"
 	"github.com/zmsocc/practice/webook/internal/repository/cache"
@@ -28,38 +29,123 @@ func InitWebServer() *App {
 
 		// consumer
 		article.NewInteractiveReadEventBatchConsumer,
+		article.NewHistoryReadEventConsumer,
 		article.NewKafkaProducer,
+		security.NewKafkaProducer,
 
 		// 初始化 DAO
 		dao.NewUserDAO,
 		articles.NewArticleDao,
 		dao.NewInteractiveDAO,
+		dao.NewRBACDAO,
+		dao.NewAccessTokenDAO,
+		dao.NewFollowDAO,
+		dao.NewLoginLogDAO,
+		dao.NewInviteDAO,
+		dao.NewAsyncSMSDAO,
 
 		cache.NewUserCache,
 		cache.NewCodeCache,
+		cache.NewLoginAttemptCache,
 		cache.NewArticleCache,
 		cache.NewRedisInteractiveCache,
+		cache.NewUserExportCache,
+		cache.NewRedisFollowCache,
+		cache.NewCaptchaCache,
 
 		repository.NewUserRepository,
 		repository.NewCodeRepository,
+		repository.NewLoginAttemptRepository,
 		articles2.NewArticleRepository,
 		repository.NewInteractiveRepository,
+		repository.NewRBACRepository,
+		repository.NewAccessTokenRepository,
+		repository.NewUserExportRepository,
+		repository.NewFollowRepository,
+		repository.NewLoginLogRepository,
+		repository.NewCaptchaRepository,
+		repository.NewInviteRepository,
+		repository.NewAsyncSMSRepository,
 
 		service.NewUserService,
 		service.NewCodeService,
+		service.NewLoginAttemptService,
 		service.NewArticleService,
 		service.NewInteractiveService,
+		service.NewRBACService,
+		service.NewAccessTokenService,
+		ioc.InitAccountService,
+		service.NewAuthorService,
+		service.NewFollowService,
+		service.NewLoginLogService,
+		service.NewCaptchaService,
+		ioc.InitInviteService,
+		service.NewAvatarService,
+		service.NewUserStatusService,
+		wire.Bind(new(ijwt.PermissionLoader), new(service.RBACService)),
+		wire.Bind(new(service.SessionRevoker), new(ijwt.Handler)),
 
 		// 直接基于内存实现
+		ioc.InitSMSTemplateRegistry,
+		ioc.InitAsyncSMSService,
 		ioc.InitSMSService,
+		ioc.InitSMSGatewayService,
+		ioc.InitEmailService,
+		ioc.InitWechatService,
+		ioc.InitStorage,
 
 		web.NewUserHandler,
 		web.NewArticleHandler,
+		web.NewAdminHandler,
+		web.NewAccessTokenHandler,
+		web.NewAccountHandler,
+		web.NewAuthorHandler,
+		web.NewFollowHandler,
+		web.NewAvatarHandler,
+		web.NewCaptchaHandler,
+		web.NewInviteHandler,
+		web.NewSMSGatewayHandler,
 		ijwt.NewRedisJWTHandler,
+		ioc.InitJWTKeyRing,
+		ioc.InitFieldCipher,
+		ioc.InitJWKSHandler,
+		ioc.InitOAuth2WechatHandler,
 
 		ioc.InitWebServer,
 		ioc.InitMiddlewares,
+		ioc.InitJobs,
 		wire.Struct(new(App), "*"),
 	)
 	return new(App)
 }
+
+// InitRBACService 给 -seed-rbac 用，只需要数据库
+func InitRBACService() service.RBACService {
+	wire.Build(
+		ioc.InitDB,
+		dao.NewRBACDAO,
+		repository.NewRBACRepository,
+		service.NewRBACService,
+	)
+	return nil
+}
+
+// InitUserService 给 -phone-conflicts 用
+func InitUserService() service.UserService {
+	wire.Build(
+		ioc.InitDB,
+		ioc.InitRedis,
+		ioc.InitLogger,
+		ioc.InitFieldCipher,
+		dao.NewUserDAO,
+		dao.NewInviteDAO,
+		cache.NewUserCache,
+		cache.NewRedisInteractiveCache,
+		cache.NewRedisFollowCache,
+		repository.NewUserRepository,
+		repository.NewInviteRepository,
+		ioc.InitInviteService,
+		service.NewUserService,
+	)
+	return nil
+}
@@ -8,6 +8,7 @@ package main
 
 import (
 	"github.com/zmsocc/practice/webook/internal/event/article"
+	"github.com/zmsocc/practice/webook/internal/event/security"
 	"github.com/zmsocc/practice/webook/internal/repository"
 	articles2 "github.com/zmsocc/practice/webook/internal/repository/articles"
 	"github.com/zmsocc/practice/webook/internal/repository/cache"
@@ -23,37 +24,117 @@ import (
 
 func InitWebServer() *App {
 	cmdable := ioc.InitRedis()
-	handler := ijwt.NewRedisJWTHandler(cmdable)
-	v := ioc.InitMiddlewares(handler, cmdable)
+	keyRing := ioc.InitJWTKeyRing()
 	db := ioc.InitDB()
+	rbacdao := dao.NewRBACDAO(db)
+	rbacRepository := repository.NewRBACRepository(rbacdao)
+	rbacService := service.NewRBACService(rbacRepository)
+	handler := ijwt.NewRedisJWTHandler(cmdable, keyRing, rbacService)
+	accessTokenDAO := dao.NewAccessTokenDAO(db)
+	accessTokenRepository := repository.NewAccessTokenRepository(accessTokenDAO)
+	accessTokenService := service.NewAccessTokenService(accessTokenRepository)
 	userDAO := dao.NewUserDAO(db)
 	userCache := cache.NewUserCache(cmdable)
-	userRepository := repository.NewUserRepository(userDAO, userCache)
-	userService := service.NewUserService(userRepository)
+	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
+	followCache := cache.NewRedisFollowCache(cmdable)
+	fieldCipher := ioc.InitFieldCipher()
+	userRepository := repository.NewUserRepository(userDAO, userCache, interactiveCache, followCache, fieldCipher)
+	userStatusService := service.NewUserStatusService(userRepository, handler)
+	v := ioc.InitMiddlewares(handler, accessTokenService, userStatusService, cmdable)
+	inviteDAO := dao.NewInviteDAO(db)
+	inviteRepository := repository.NewInviteRepository(inviteDAO)
+	logger := ioc.InitLogger()
+	inviteService := ioc.InitInviteService(inviteRepository, logger)
+	userService := service.NewUserService(userRepository, inviteService)
 	codeCache := cache.NewCodeCache(cmdable)
 	codeRepository := repository.NewCodeRepository(codeCache)
-	smsService := ioc.InitSMSService(cmdable)
-	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, handler, codeService)
-	articleDAO := articles.NewArticleDao(db)
-	articleCache := cache.NewArticleCache(cmdable)
-	logger := ioc.InitLogger()
-	articleRepository := articles2.NewArticleRepository(articleDAO, articleCache, logger)
+	asyncSMSDAO := dao.NewAsyncSMSDAO(db)
+	asyncSMSRepository := repository.NewAsyncSMSRepository(asyncSMSDAO, fieldCipher)
+	registry := ioc.InitSMSTemplateRegistry(logger)
+	asyncService := ioc.InitAsyncSMSService(asyncSMSRepository, registry, logger)
+	smsService := ioc.InitSMSService(cmdable, asyncService, registry, fieldCipher)
+	emailService := ioc.InitEmailService(logger)
+	codeService := service.NewCodeService(codeRepository, smsService, emailService)
+	loginAttemptCache := cache.NewLoginAttemptCache(cmdable)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(loginAttemptCache)
+	loginAttemptService := service.NewLoginAttemptService(loginAttemptRepository)
+	loginLogDAO := dao.NewLoginLogDAO(db)
+	loginLogRepository := repository.NewLoginLogRepository(loginLogDAO, fieldCipher)
 	client := ioc.InitKafka()
 	syncProducer := ioc.NewSyncProducer(client)
+	securityProducer := security.NewKafkaProducer(syncProducer)
+	loginLogService := service.NewLoginLogService(loginLogRepository, userRepository, securityProducer, logger)
+	captchaCache := cache.NewCaptchaCache(cmdable)
+	captchaRepository := repository.NewCaptchaRepository(captchaCache)
+	captchaService := service.NewCaptchaService(captchaRepository)
+	userHandler := web.NewUserHandler(userService, handler, codeService, loginAttemptService, loginLogService, captchaService, logger)
+	articleDAO := articles.NewArticleDao(db)
+	articleCache := cache.NewArticleCache(cmdable)
+	articleRepository := articles2.NewArticleRepository(articleDAO, articleCache, userRepository, logger)
 	producer := article.NewKafkaProducer(syncProducer)
-	articleService := service.NewArticleService(articleRepository, logger, producer)
+	articleService := service.NewArticleService(articleRepository, userRepository, logger, producer)
 	interactiveDAO := dao.NewInteractiveDAO(db)
-	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
 	interactiveRepository := repository.NewInteractiveRepository(interactiveDAO, interactiveCache, logger)
-	interactiveService := service.NewInteractiveService(interactiveRepository)
-	articleHandler := web.NewArticleHandler(articleService, logger, interactiveService)
-	engine := ioc.InitWebServer(v, userHandler, articleHandler)
+	interactiveService := service.NewInteractiveService(interactiveRepository, userRepository)
+	followDAO := dao.NewFollowDAO(db)
+	followRepository := repository.NewFollowRepository(followDAO, followCache, logger)
+	followService := service.NewFollowService(followRepository, userRepository)
+	articleHandler := web.NewArticleHandler(articleService, logger, interactiveService, followService)
+	jwksHandler := ioc.InitJWKSHandler(keyRing, logger)
+	oauth2Service := ioc.InitWechatService()
+	oAuth2WechatHandler := ioc.InitOAuth2WechatHandler(oauth2Service, userService, handler, logger)
+	adminHandler := web.NewAdminHandler(articleService, rbacService, loginLogService, userStatusService, inviteService, logger)
+	accessTokenHandler := web.NewAccessTokenHandler(accessTokenService, logger)
+	userExportCache := cache.NewUserExportCache(cmdable)
+	userExportRepository := repository.NewUserExportRepository(userExportCache)
+	accountService := ioc.InitAccountService(userRepository, articleRepository, interactiveRepository, userExportRepository, handler, logger)
+	accountHandler := web.NewAccountHandler(accountService, logger)
+	authorService := service.NewAuthorService(userRepository, articleRepository, interactiveRepository, followRepository)
+	authorHandler := web.NewAuthorHandler(authorService, followService, logger)
+	followHandler := web.NewFollowHandler(followService, logger)
+	storageService := ioc.InitStorage()
+	avatarService := service.NewAvatarService(userRepository, storageService)
+	avatarHandler := web.NewAvatarHandler(avatarService, logger)
+	captchaHandler := web.NewCaptchaHandler(captchaService, logger)
+	inviteHandler := web.NewInviteHandler(inviteService, logger)
+	authService := ioc.InitSMSGatewayService(smsService, cmdable)
+	smsGatewayHandler := web.NewSMSGatewayHandler(authService, logger)
+	engine := ioc.InitWebServer(v, userHandler, articleHandler, jwksHandler, oAuth2WechatHandler, adminHandler, accessTokenHandler, accountHandler, authorHandler, followHandler, avatarHandler, captchaHandler, inviteHandler, smsGatewayHandler)
 	interactiveReadEventBatchConsumer := article.NewInteractiveReadEventBatchConsumer(client, interactiveRepository, logger)
-	v2 := ioc.NewConsumers(interactiveReadEventBatchConsumer)
+	historyReadEventConsumer := article.NewHistoryReadEventConsumer(client, logger, interactiveRepository)
+	v2 := ioc.NewConsumers(interactiveReadEventBatchConsumer, historyReadEventConsumer)
+	v3 := ioc.InitJobs(accountService, userService, loginLogService, asyncService, logger)
 	app := &App{
 		web:       engine,
 		consumers: v2,
+		jobs:      v3,
 	}
 	return app
 }
+
+// InitRBACService 给 -seed-rbac 用，只需要数据库
+func InitRBACService() service.RBACService {
+	db := ioc.InitDB()
+	rbacdao := dao.NewRBACDAO(db)
+	rbacRepository := repository.NewRBACRepository(rbacdao)
+	rbacService := service.NewRBACService(rbacRepository)
+	return rbacService
+}
+
+// InitUserService 给 -phone-conflicts 用
+func InitUserService() service.UserService {
+	db := ioc.InitDB()
+	userDAO := dao.NewUserDAO(db)
+	cmdable := ioc.InitRedis()
+	userCache := cache.NewUserCache(cmdable)
+	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
+	followCache := cache.NewRedisFollowCache(cmdable)
+	fieldCipher := ioc.InitFieldCipher()
+	userRepository := repository.NewUserRepository(userDAO, userCache, interactiveCache, followCache, fieldCipher)
+	inviteDAO := dao.NewInviteDAO(db)
+	inviteRepository := repository.NewInviteRepository(inviteDAO)
+	logger := ioc.InitLogger()
+	inviteService := ioc.InitInviteService(inviteRepository, logger)
+	userService := service.NewUserService(userRepository, inviteService)
+	return userService
+}